@@ -0,0 +1,68 @@
+package h3
+
+import (
+	"net/http"
+	"strings"
+)
+
+// standardMethods RFC 9110 和 RFC 5789 定义的请求方法
+var standardMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// AllowMethods 创建只放行指定请求方法的中间件
+//
+// 作为全局中间件使用时，在路由匹配之前拒绝不在允许列表中的请求：
+//   - 标准方法（GET、POST、DELETE 等）返回 405 Method Not Allowed，并通过 Allow 头列出允许的方法
+//   - 非标准方法（例如 "PROPFIND" 或任意字符串）返回 501 Not Implemented
+//
+// 方法名区分大小写。与 http.ServeMux 一致，允许 GET 时同时允许 HEAD；
+// OPTIONS 需要显式列出。
+//
+// 示例:
+//
+//	mux.Use(h3.AllowMethods("GET", "POST", "PUT", "DELETE", "OPTIONS"))
+func AllowMethods(methods ...string) func(http.Handler) http.Handler {
+	allowed := make(map[string]bool, len(methods)+1)
+	var list []string
+	add := func(method string) {
+		if !allowed[method] {
+			allowed[method] = true
+			list = append(list, method)
+		}
+	}
+	for _, method := range methods {
+		add(method)
+		if method == http.MethodGet {
+			add(http.MethodHead)
+		}
+	}
+	allow := strings.Join(list, ", ")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if allowed[r.Method] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !standardMethods[r.Method] {
+				code := http.StatusNotImplemented
+				http.Error(w, http.StatusText(code), code)
+				return
+			}
+
+			w.Header().Set("Allow", allow)
+			code := http.StatusMethodNotAllowed
+			http.Error(w, http.StatusText(code), code)
+		})
+	}
+}
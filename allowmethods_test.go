@@ -0,0 +1,54 @@
+package h3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAllowMethods(t *testing.T) {
+	called := false
+	mux := NewMux()
+	mux.Use(AllowMethods("GET", "POST"))
+	mux.HandleFunc("/test", func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.Write([]byte("ok"))
+	})
+
+	tests := []struct {
+		method string
+		status int
+		called bool
+		allow  string
+	}{
+		{"GET", http.StatusOK, true, ""},
+		{"HEAD", http.StatusOK, true, ""},
+		{"POST", http.StatusOK, true, ""},
+		{"DELETE", http.StatusMethodNotAllowed, false, "GET, HEAD, POST"},
+		{"OPTIONS", http.StatusMethodNotAllowed, false, "GET, HEAD, POST"},
+		{"BREW", http.StatusNotImplemented, false, ""},
+		{"get", http.StatusNotImplemented, false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			called = false
+			req := httptest.NewRequest(tt.method, "/test", nil)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+
+			if called != tt.called {
+				t.Errorf("handler called = %v, want %v", called, tt.called)
+			}
+
+			if got := rec.Header().Get("Allow"); got != tt.allow {
+				t.Errorf("Allow = %q, want %q", got, tt.allow)
+			}
+		})
+	}
+}
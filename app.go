@@ -3,9 +3,16 @@ package h3
 import (
 	"context"
 	"crypto/tls"
+	"errors"
+	"fmt"
 	"log"
 	"net"
 	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -53,6 +60,26 @@ type Options struct {
 	// ReadTimeout 为零或负值，则没有超时。
 	IdleTimeout time.Duration
 
+	// RequestTimeout 是每个请求上下文的截止时间。
+	// 与 WriteTimeout 不同，它会取消 Handler 收到的 r.Context()，
+	// 使下游调用（如数据库查询）能够及时中止。
+	// 协议升级（WebSocket）和事件流（SSE）等长连接请求不受此限制。
+	// 零值或负值表示没有超时。
+	RequestTimeout time.Duration
+
+	// ErrorResponseWriter 可选地指定 404 和 405 响应的写出方式，
+	// 用于在整个应用中输出统一格式（如 JSON）的错误响应。
+	// 它对挂载的子路由同样生效，但路由自己设置的 NotFound 或 Fallback 优先。
+	// 405 响应的 Allow 头会在调用前设置好。
+	// 如果为 nil，使用标准库的纯文本响应。
+	ErrorResponseWriter func(w http.ResponseWriter, r *http.Request, status int)
+
+	// PanicHandler 可选地指定 Recoverer 和 Mux.Recover 恢复 panic 时的报告方式，
+	// 例如上报到 Sentry 或告警系统。它在写出 500 响应之前被调用，
+	// 参数为请求、恢复的值和 panic 时的调用栈；PanicHandler 自身的 panic 会被恢复并记录。
+	// 如果为 nil，通过 log 包记录 panic 的值和调用栈。
+	PanicHandler func(r *http.Request, recovered any, stack []byte)
+
 	// MaxHeaderBytes 控制服务器在解析请求头的键和值时读取的最大字节数，
 	// 包括请求行。它不限制请求体的大小。
 	// 如果为零，使用 DefaultMaxHeaderBytes。
@@ -70,15 +97,29 @@ type Options struct {
 	// 详情请参见 ConnState 类型和相关常量。
 	ConnState func(net.Conn, http.ConnState)
 
+	// OnIdleClose 指定一个可选的回调函数，当处于空闲状态的 keep-alive 连接
+	// 被关闭时调用，例如因 IdleTimeout 到期或服务器关闭而被回收。
+	// 空闲连接的数量可以通过 App.IdleConnCount 获取。
+	OnIdleClose func(net.Conn)
+
+	// DrainProgress 指定一个可选的回调函数，在 Stop 优雅关闭 HTTP 服务器期间
+	// 每 100 毫秒调用一次，参数为尚未关闭的连接数量（包括活跃和空闲的连接），
+	// 用于在长时间排空时记录进度。关闭完成或 Stop 的 ctx 结束后不再调用。
+	// 被接管的连接（例如 WebSocket）不计入其中。
+	DrainProgress func(remaining int)
+
 	// ErrorLog 指定一个可选的日志记录器，用于记录接受连接时的错误、
 	// Handler 的意外行为以及底层 FileSystem 的错误。
 	// 如果为 nil，通过 log 包的标准日志记录器进行日志记录。
 	ErrorLog *log.Logger
 
-	// HTTP2 配置 HTTP/2 连接。
+	// HTTP2 配置 HTTP/2 连接，例如 MaxConcurrentStreams、MaxReadFrameSize
+	// 和 MaxReceiveBufferPerStream 等限制。
 	//
-	// 此字段目前尚未生效。
-	// 详见 https://go.dev/issue/67813。
+	// 配置会传递给每个监听地址的 http.Server，对 TLS 上的 HTTP/2
+	// 和 Protocols 启用的未加密 HTTP/2 都生效。
+	// 如果设置了不包含 "h2" 的 TLSNextProto，不会提供 HTTP/2，此字段也不生效。
+	// 为 nil 时使用标准库的默认值。
 	HTTP2 *http.HTTP2Config
 
 	// Protocols 是服务器接受的协议集。
@@ -89,14 +130,112 @@ type Options struct {
 	// 如果 Protocols 为 nil，默认通常是 HTTP/1 和 HTTP/2。
 	// 如果 TLSNextProto 不为 nil 且不包含 "h2" 条目，默认仅为 HTTP/1。
 	Protocols *http.Protocols
+
+	// EnableHTTP3 如果为 true，Start 会在 Addr 实际绑定的同一端口上绑定 UDP 监听，
+	// 交给 HTTP3Server 提供 HTTP/3（QUIC）服务，并在 TLS 连接上的 HTTP/1 和 HTTP/2
+	// 响应中通过 Alt-Svc 头通告 HTTP/3 端点，明文响应不添加 Alt-Svc。
+	// 启用时必须设置 HTTP3Server。
+	EnableHTTP3 bool
+
+	// HTTP3Server 提供 HTTP/3 服务的 QUIC 服务器实现。
+	// 标准库不支持 HTTP/3，可以通过适配 quic-go 等第三方库来实现，
+	// h3 本身不依赖任何 QUIC 实现。
+	HTTP3Server QUICServer
+
+	// AltSvc 可选地指定启用 HTTP/3 时通告的 Alt-Svc 头的值。
+	// 如果为空，使用 `h3=":<port>"; ma=86400`，其中端口为实际绑定的 UDP 端口。
+	AltSvc string
+
+	// WrapListener 可选地包装 Start 绑定的每个 TCP 监听器，
+	// 用于在 Accept 层面添加自定义逻辑，例如统计连接数、记录或限制新连接、
+	// 解析 PROXY 协议等。
+	//
+	// 包装在绑定之后、开始服务之前进行，对 Addr 和 AddListener 添加的地址都会调用。
+	// 包装器位于 TLS 之下，看到的是原始的 TCP 连接；TLS 握手在其返回的连接上进行。
+	// HTTP/3 的 UDP 监听不经过此函数。
+	WrapListener func(net.Listener) net.Listener
+
+	// OnListen 可选地指定一个回调函数，在 Start 绑定所有监听地址并启动所有 Servlet 之后、
+	// 开始服务之前调用，用于在服务就绪时执行只需一次的任务，例如写入 PID 文件、向 systemd 发送 READY=1。
+	// 此后 Start 不会再失败。
+	//
+	// 对 Addr 和 AddListener 添加的地址按顺序各调用一次，收到的是经过 WrapListener 包装的监听器，
+	// 可以通过 ln.Addr() 获取实际绑定的地址。返回错误时 Start 逆序停止已启动的 Servlet，
+	// 关闭所有已绑定的监听器，并返回该错误；之前的调用已经产生的副作用需要调用方自行撤销。
+	OnListen func(ln net.Listener) error
+
+	// WaitHijacked 如果为 true，Stop 在关闭 HTTP 服务器之后继续等待被接管的连接
+	// （例如 WebSocket）关闭，直到 Stop 的 ctx 结束。
+	// http.Server.Shutdown 不会跟踪被接管的连接，默认情况下 Stop 返回时它们可能仍在运行。
+	// 通常配合 RegisterOnShutdown 通知这些连接的处理器主动关闭。
+	//
+	// 启用后每个 TCP 连接都会被包装以跟踪其关闭，
+	// 因此 ConnState 等回调收到的是包装后的连接。
+	WaitHijacked bool
+
+	// BaseContextValues 可选地指定注入到每个请求上下文的值，
+	// 用于在启动时一次性提供配置、数据库连接等应用级单例，而不必为每个请求执行中间件。
+	//
+	// 这些值被加入 Start 为所有 TCP 监听器设置的基础上下文中，
+	// 该上下文在 Stop 时被取消，注入值不会影响这一行为。
+	// 直接调用 App.ServeHTTP 或通过 HTTP3Server 处理的请求不包含这些值。
+	BaseContextValues map[any]any
+
+	// LifecycleHook 可选地接收应用的生命周期事件，
+	// 包括服务器开始服务和关闭完成，以及每个 Servlet 的启动和停止，
+	// 用于输出结构化的启动/关闭日志或指标。
+	// 钩子在 Start 和 Stop 的执行路径上同步调用，不应阻塞。
+	LifecycleHook func(event LifecycleEvent)
+
+	// ServletStartRetries 是 Servlet 的 Start 返回错误后的最大重试次数，
+	// 用于容忍启动时依赖暂时不可用（例如数据库尚未就绪）。
+	// 每次重试前等待的时间从 ServletStartBackoff 开始逐次加倍，
+	// 等待期间 Start 的 ctx 结束时立即放弃并返回最后一次的错误。
+	// 零值表示不重试，启动失败立即导致 Start 失败。
+	ServletStartRetries int
+
+	// ServletStartBackoff 是第一次重试前的等待时间，之后每次加倍。
+	// 如果为零，使用 100 毫秒。
+	ServletStartBackoff time.Duration
+
+	// MaxComponents 限制可以注册的应用组件总数，
+	// 用于防止插件式部署中失控的动态注册耗尽内存。
+	// 超出限制时 Register、RegisterVersioned 和 RegisterGroup 触发 panic，
+	// RegisterErr 返回包装了 ErrTooManyComponents 的错误，组件不会被注册。
+	// 零值或负值表示不限制。
+	MaxComponents int
+}
+
+// listener 监听地址及其 TLS 配置
+type listener struct {
+	addr string      // 监听地址
+	tls  *tls.Config // TLS 配置，为 nil 时使用明文 HTTP
 }
 
 // App HTTP 应用
 type App struct {
-	opts  *Options        // 应用配置参数
-	mux   Mux             // 路由复用器
-	servs []Servlet       // 服务组件列表
-	exit  chan chan error // 优雅关闭通道
+	opts  *Options         // 应用配置参数
+	mux   Mux              // 路由复用器
+	servs []Servlet        // 服务组件列表
+	comps []ComponentInfo  // 已注册的组件
+	lns   []listener       // 额外的监听地址
+	exit  chan stopRequest // 优雅关闭通道
+	wg    sync.WaitGroup   // 跟踪服务和关闭 goroutine
+
+	mu         sync.Mutex              // 保护 servs、conns、idle、hijacked、hijackCh、onShutdown、states、addr 和 binding
+	conns      int                     // 尚未关闭的连接数量
+	idle       map[net.Conn]struct{}   // 当前空闲的连接
+	hijacked   map[*trackConn]struct{} // 已被接管且尚未关闭的连接
+	hijackCh   chan struct{}           // 被接管的连接关闭时关闭并重建
+	onShutdown []func()                // Stop 时调用的函数
+	states     []ServletState          // 与 servs 一一对应的 Servlet 状态
+	addr       net.Addr                // Options.Addr 实际绑定的地址
+	binding    bool                    // Start 已经开始绑定监听地址，此后不能再修改 Options.Addr
+
+	root     atomic.Pointer[http.Handler]    // 服务期间使用的根处理器，由 SwapMux 替换
+	cert     atomic.Pointer[tls.Certificate] // 通过 ReloadTLS 加载的证书
+	draining atomic.Bool                     // 是否处于排空状态
+	started  atomic.Bool                     // 是否已经成功启动
 }
 
 // New 创建 HTTP 应用实例
@@ -128,7 +267,7 @@ func New(mux Mux, options ...Options) *App {
 	return &App{
 		opts: &opts,
 		mux:  mux,
-		exit: make(chan chan error),
+		exit: make(chan stopRequest),
 	}
 }
 
@@ -137,22 +276,219 @@ func (a *App) Use(middleware func(http.Handler) http.Handler) {
 	a.mux.Use(middleware)
 }
 
+// SetAddr 修改应用的监听地址
+//
+// 用于在创建应用之后、启动之前更换 Options.Addr，例如在测试中改用其他端口。
+// Start 开始绑定监听地址之后（包括停止之后）不能再修改，此时返回错误；
+// Start 失败时可以修改后重试。地址格式无效时同样返回错误。
+//
+// 参数:
+//   - addr: 监听的 TCP 地址，格式为 "host:port"
+//
+// 返回:
+//   - error: 应用已启动或地址格式无效时返回错误
+//
+// 示例:
+//
+//	app := h3.New(mux, h3.Options{Addr: ":8080"})
+//	if err := app.SetAddr(":9090"); err != nil {
+//		log.Fatal(err)
+//	}
+func (a *App) SetAddr(addr string) error {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.binding {
+		return errors.New("h3: cannot set address after Start")
+	}
+	a.opts.Addr = addr
+	return nil
+}
+
+// Addr 返回 Options.Addr 实际绑定的地址
+//
+// Addr 使用 ":0" 等动态端口时，可以通过它获取最终的端口。
+// 应用从未启动时返回 nil；停止之后仍然返回最后一次绑定的地址。
+//
+// 示例:
+//
+//	app := h3.New(mux, h3.Options{Addr: "127.0.0.1:0"})
+//	_ = app.Start(ctx)
+//	url := "http://" + app.Addr().String()
+func (a *App) Addr() net.Addr {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.addr
+}
+
+// AddListener 添加额外的监听地址
+//
+// 应用默认只监听 Options.Addr。通过 AddListener 可以让同一个应用
+// 同时监听多个地址，例如内部使用的明文端口和对外的 TLS 端口。
+// 所有监听地址共享同一个路由器和 Servlet 组件，Stop 时会一起优雅关闭。
+//
+// 必须在 Start 之前调用。
+//
+// 参数:
+//   - addr: 监听的 TCP 地址，格式为 "host:port"
+//   - tlsConfig: TLS 配置，为 nil 时提供明文 HTTP 服务；
+//     否则必须通过 Certificates 或 GetCertificate 提供证书
+//
+// 示例:
+//
+//	app := h3.New(mux, h3.Options{Addr: ":8080"})
+//	app.AddListener(":8443", &tls.Config{Certificates: []tls.Certificate{cert}})
+func (a *App) AddListener(addr string, tlsConfig *tls.Config) {
+	a.lns = append(a.lns, listener{addr: addr, tls: tlsConfig})
+}
+
 // Register 注册应用组件
 //
 // 此方法会将应用组件的路由挂载到应用的主路由器上。
 // 如果应用组件实现了 Servlet 接口，还会将其添加到服务组件列表中，
 // 以便在应用启动和关闭时自动调用其 Start 和 Stop 方法。
+// 同一个 Servlet 实例只会被添加一次。
 //
 // 参数:
 //   - c: 要注册的应用组件
 func (a *App) Register(c Component) {
+	a.checkComponents(1)
+
 	// 挂载组件路由
 	a.mux.Mount(c.Prefix(), c.Mux())
 
 	// 如果组件实现了 Servlet 接口，添加到服务组件列表
-	if serv, ok := c.(Servlet); ok {
-		a.servs = append(a.servs, serv)
+	_, isServ := c.(Servlet)
+	if isServ {
+		a.addServlet(c.(Servlet))
+	}
+	a.comps = append(a.comps, ComponentInfo{Prefix: c.Prefix(), Servlet: isServ})
+}
+
+// AddServlet 添加不提供路由的服务组件
+//
+// 后台任务等没有 HTTP 路由的服务可以通过此方法参与应用的生命周期，
+// 而不必包装为 Component 挂载一个空的路由器。
+// 添加的 Servlet 与组件提供的 Servlet 位于同一个列表中，
+// 按添加顺序启动、逆序停止，启动失败时的处理方式也相同。
+// 同一个 Servlet 实例只会被添加一次。
+//
+// 必须在 Start 之前调用。
+//
+// 示例:
+//
+//	app.AddServlet(h3.ServletFunc(worker.Start, worker.Stop))
+func (a *App) AddServlet(s Servlet) {
+	a.addServlet(s)
+}
+
+// RegisterErr 注册应用组件，在路由冲突时返回错误而不是 panic
+//
+// 与 Register 相同，但组件的路由与已注册的路由冲突时（例如两个组件使用了相同的前缀），
+// 返回包含组件前缀和冲突模式的错误，便于定位发生冲突的组件。
+// 注册的组件数量超过 Options.MaxComponents 时，返回的错误包装了 ErrTooManyComponents。
+// 返回错误时组件不会被注册。
+//
+// 参数:
+//   - c: 要注册的应用组件
+//
+// 返回:
+//   - error: 路由冲突、无效或组件数量超过限制时返回错误
+func (a *App) RegisterErr(c Component) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			if e, ok := v.(error); ok {
+				err = fmt.Errorf("h3: register component %q: %w", c.Prefix(), e)
+				return
+			}
+			err = fmt.Errorf("h3: register component %q: %v", c.Prefix(), v)
+		}
+	}()
+
+	a.Register(c)
+	return nil
+}
+
+// ErrTooManyComponents 在注册的组件数量超过 Options.MaxComponents 时使用
+var ErrTooManyComponents = errors.New("h3: too many components")
+
+// checkComponents 在再注册 n 个组件会超过 Options.MaxComponents 时触发 panic
+func (a *App) checkComponents(n int) {
+	if limit := a.opts.MaxComponents; limit > 0 && len(a.comps)+n > limit {
+		panic(fmt.Errorf("%w: limit is %d", ErrTooManyComponents, limit))
+	}
+}
+
+// RegisterVersioned 将应用组件注册到带版本号的路径前缀下
+//
+// 组件被挂载到 "/{version}" + c.Prefix()，例如版本 "v1" 和前缀 "/users"
+// 对应 "/v1/users"。版本号保存在请求上下文中，处理器可以通过 APIVersion 读取。
+//
+// 同一个组件实例可以注册到多个版本下；如果它实现了 Servlet 接口，只会启动和停止一次。
+//
+// 参数:
+//   - version: 版本号，例如 "v1"
+//   - c: 要注册的应用组件
+//
+// 示例:
+//
+//	users := NewUsersComponent()
+//	app.RegisterVersioned("v1", users)
+//	app.RegisterVersioned("v2", users)
+func (a *App) RegisterVersioned(version string, c Component) {
+	version = strings.Trim(version, "/")
+	if version == "" {
+		panic(errors.New("h3: invalid version"))
 	}
+	a.checkComponents(1)
+
+	// 在独立的路由器中注入版本号，再将组件路由挂载到其根路径
+	vm := NewMux()
+	vm.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), apiVersionKey{}, version)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	vm.Mount("/", c.Mux())
+
+	prefix := strings.TrimSuffix(c.Prefix(), "/")
+	a.mux.Mount("/"+version+prefix, vm)
+
+	_, isServ := c.(Servlet)
+	if isServ {
+		a.addServlet(c.(Servlet))
+	}
+	a.comps = append(a.comps, ComponentInfo{Prefix: "/" + version + prefix, Version: version, Servlet: isServ})
+}
+
+// apiVersionKey 请求上下文中 API 版本号的键
+type apiVersionKey struct{}
+
+// APIVersion 返回通过 RegisterVersioned 注册的组件所处理请求的版本号
+//
+// 如果请求不属于带版本号的组件，返回空字符串。
+func APIVersion(r *http.Request) string {
+	v, _ := r.Context().Value(apiVersionKey{}).(string)
+	return v
+}
+
+// addServlet 添加服务组件，同一个实例只添加一次
+func (a *App) addServlet(serv Servlet) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if reflect.TypeOf(serv).Comparable() {
+		for _, s := range a.servs {
+			if reflect.TypeOf(s).Comparable() && s == serv {
+				return
+			}
+		}
+	}
+	a.servs = append(a.servs, serv)
+	a.states = append(a.states, StateRegistered)
 }
 
 // Handler 根据请求查找匹配的处理器和模式
@@ -196,112 +532,583 @@ func (a *App) HandleFunc(pattern string, handler func(http.ResponseWriter, *http
 //
 // 这使得 App 本身可以作为一个 http.Handler 使用，
 // 可以嵌套在其他 HTTP 服务器或中间件中。
+// RequestTimeout、ErrorResponseWriter 和 PanicHandler 等请求级配置同样生效。
 //
 // 参数:
 //   - w: HTTP 响应写入器
 //   - r: HTTP 请求
 func (a *App) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	a.mux.ServeHTTP(w, r)
+	if h := a.root.Load(); h != nil {
+		(*h).ServeHTTP(w, r)
+		return
+	}
+	a.handler(a.mux).ServeHTTP(w, r)
+}
+
+// SwapMux 原子地替换应用分发请求使用的路由器
+//
+// 替换可以在服务期间进行，不会中断任何连接：之后到达的请求由 m 处理，
+// 已经开始处理的请求继续使用原来的路由器直到完成。
+// 适用于在不重启服务的情况下切换功能开关、重新加载路由或中间件配置。
+// RequestTimeout、ErrorResponseWriter 和 PanicHandler 等请求级配置对 m 同样生效。
+//
+// 替换之后，Use、Handle、Register 和 Routes 等方法作用于 m。
+// 这些方法不是并发安全的，不应与 SwapMux 同时调用；
+// 通常在新的路由器上完成全部注册后再一次性替换。
+// 监听地址、TLS 和连接超时等监听级配置不受影响，修改它们需要重启应用。
+//
+// 参数:
+//   - m: 新的路由器
+//
+// 返回:
+//   - Mux: 被替换的路由器
+//
+// 示例:
+//
+//	next := h3.NewMux()
+//	next.Use(h3.RequestID())
+//	next.HandleFunc("GET /search", newSearch)
+//	app.SwapMux(next)
+func (a *App) SwapMux(m Mux) Mux {
+	if m == nil {
+		panic(errors.New("h3: nil mux"))
+	}
+	old := a.mux
+	a.mux = m
+	h := a.handler(m)
+	a.root.Store(&h)
+	return old
+}
+
+// handler 返回以 m 为路由器的根处理器
+//
+// 根据配置在路由器外层包装请求超时和错误响应格式。
+func (a *App) handler(m Mux) http.Handler {
+	var handler http.Handler = m
+	if a.opts.ErrorResponseWriter != nil {
+		handler = errorResponseWriter(handler, a.opts.ErrorResponseWriter)
+	}
+	if a.opts.PanicHandler != nil {
+		handler = panicHandler(handler, a.opts.PanicHandler)
+	}
+	if a.opts.RequestTimeout > 0 {
+		handler = requestTimeout(handler, a.opts.RequestTimeout)
+	}
+	return handler
 }
 
 // Start 启动 HTTP 应用(非阻塞)
 //
 // 此方法会按顺序执行以下操作:
-//  1. 验证监听地址格式
+//  1. 验证并绑定所有监听地址（Options.Addr 和 AddListener 添加的地址）
 //  2. 启动所有注册的 Servlet 组件（调用 Start 方法）
-//  3. 启动 HTTP 服务器（在后台 goroutine 中）
-//  4. 设置优雅关闭处理（在后台 goroutine 中等待 Stop 信号）
+//  3. 对每个监听器调用 Options.OnListen
+//  4. 为每个监听地址启动 HTTP 服务器（在后台 goroutine 中）
+//  5. 设置优雅关闭处理（在后台 goroutine 中等待 Stop 信号）
 //
 // 如果任何 Servlet 的 Start 方法返回错误，整个启动过程会失败。
+// 如果 ctx 在调用时已经结束，直接返回其错误，不会绑定任何监听地址。
+// 所有监听地址共享同一个路由器和 Servlet 组件，Servlet 只启动一次。
 //
 // 参数:
 //   - ctx: 用于 Servlet 启动的上下文
 //
 // 返回:
-//   - error: 地址无效或 Servlet 启动失败时返回错误
+//   - error: 上下文已结束、地址无效、绑定失败或 Servlet 启动失败时返回错误
 func (a *App) Start(ctx context.Context) error {
 	opts := a.opts
+	begin := time.Now()
 
-	// 验证监听地址格式
-	if _, _, err := net.SplitHostPort(opts.Addr); err != nil {
+	// 上下文已结束时不再启动，避免 Servlet 启动失败而 HTTP 服务器仍然运行
+	if err := ctx.Err(); err != nil {
 		return err
 	}
 
+	// 开始绑定之后 SetAddr 不能再修改 Options.Addr；启动失败时允许修改后重试
+	a.mu.Lock()
+	a.binding = true
+	a.mu.Unlock()
+	ok := false
+	defer func() {
+		if !ok {
+			a.mu.Lock()
+			a.binding = false
+			a.mu.Unlock()
+		}
+	}()
+
+	specs := append([]listener{{addr: opts.Addr}}, a.lns...)
+
+	// 验证监听地址格式
+	for _, spec := range specs {
+		if _, _, err := net.SplitHostPort(spec.addr); err != nil {
+			return err
+		}
+	}
+
+	// 绑定所有监听地址
+	lns := make([]net.Listener, 0, len(specs))
+	bound := make([]net.Listener, 0, len(specs)) // 传给 OnListen 的监听器，不含内部的包装
+	closeAll := func() {
+		for _, ln := range lns {
+			_ = ln.Close()
+		}
+	}
+	for _, spec := range specs {
+		ln, err := net.Listen("tcp", spec.addr)
+		if err != nil {
+			closeAll()
+			return err
+		}
+		if opts.WrapListener != nil {
+			ln = opts.WrapListener(ln)
+		}
+		bound = append(bound, ln)
+		if opts.WaitHijacked {
+			ln = &trackListener{Listener: ln, app: a}
+		}
+		lns = append(lns, ln)
+	}
+
+	// 绑定 HTTP/3 的 UDP 监听
+	var pc net.PacketConn
+	if opts.EnableHTTP3 {
+		if opts.HTTP3Server == nil {
+			closeAll()
+			return errors.New("h3: EnableHTTP3 requires Options.HTTP3Server")
+		}
+
+		// 使用 TCP 实际绑定的端口，Addr 的端口为 0 时两者一致，Alt-Svc 通告的端口才正确
+		host, _, _ := net.SplitHostPort(opts.Addr)
+		_, port, _ := net.SplitHostPort(lns[0].Addr().String())
+
+		var err error
+		pc, err = net.ListenPacket("udp", net.JoinHostPort(host, port))
+		if err != nil {
+			closeAll()
+			return err
+		}
+
+		closeTCP := closeAll
+		closeAll = func() {
+			closeTCP()
+			_ = pc.Close()
+		}
+	}
+
 	// 启动所有 Servlet 组件
-	for i, serv := range a.servs {
-		if err := serv.Start(ctx); err != nil {
-			// 如果启动失败，则逆序停止已启动的 Servlet 组件
-			for j := i - 1; j >= 0; j-- {
-				stopErr := a.servs[j].Stop()
-				if stopErr != nil {
-					log.Println(stopErr)
+	a.mu.Lock()
+	a.addr = lns[0].Addr()
+	a.mu.Unlock()
+
+	if err := a.startServlets(ctx); err != nil {
+		closeAll()
+		return err
+	}
+
+	// 监听地址和 Servlet 都已就绪，之后的步骤不会失败，在开始服务之前通知调用方
+	if opts.OnListen != nil {
+		for _, ln := range bound {
+			if err := opts.OnListen(ln); err != nil {
+				if stopErr := a.stopServlets(); stopErr != nil {
+					err = errors.Join(err, stopErr)
 				}
+				closeAll()
+				return err
 			}
-			return err
 		}
 	}
 
+	ok = true
+	a.started.Store(true)
+
 	lctx, cancel := context.WithCancel(context.Background())
 
-	server := &http.Server{
-		Addr:                         opts.Addr,
-		Handler:                      a.mux,
-		DisableGeneralOptionsHandler: opts.DisableGeneralOptionsHandler,
-		TLSConfig:                    opts.TLSConfig,
-		ReadTimeout:                  opts.ReadTimeout,
-		ReadHeaderTimeout:            opts.ReadHeaderTimeout,
-		WriteTimeout:                 opts.WriteTimeout,
-		IdleTimeout:                  opts.IdleTimeout,
-		MaxHeaderBytes:               opts.MaxHeaderBytes,
-		TLSNextProto:                 opts.TLSNextProto,
-		ConnState:                    opts.ConnState,
-		ErrorLog:                     opts.ErrorLog,
-		BaseContext:                  func(net.Listener) context.Context { return lctx },
-		HTTP2:                        opts.HTTP2,
-		Protocols:                    opts.Protocols,
+	bctx := lctx
+	for k, v := range opts.BaseContextValues {
+		bctx = context.WithValue(bctx, k, v)
+	}
+
+	root := a.handler(a.mux)
+	a.root.Store(&root)
+
+	var handler http.Handler = http.HandlerFunc(a.ServeHTTP)
+	if pc != nil {
+		handler = altSvc(handler, a.altSvc(pc))
+	}
+
+	servers := make([]*http.Server, len(specs))
+	for i, spec := range specs {
+		tlsConfig := opts.TLSConfig
+		if spec.tls != nil {
+			tlsConfig = a.tlsConfig(spec.tls)
+		}
+
+		servers[i] = &http.Server{
+			Addr:                         spec.addr,
+			Handler:                      handler,
+			DisableGeneralOptionsHandler: opts.DisableGeneralOptionsHandler,
+			TLSConfig:                    tlsConfig,
+			ReadTimeout:                  opts.ReadTimeout,
+			ReadHeaderTimeout:            opts.ReadHeaderTimeout,
+			WriteTimeout:                 opts.WriteTimeout,
+			IdleTimeout:                  opts.IdleTimeout,
+			MaxHeaderBytes:               opts.MaxHeaderBytes,
+			TLSNextProto:                 opts.TLSNextProto,
+			ConnState:                    a.connState,
+			ErrorLog:                     opts.ErrorLog,
+			BaseContext:                  func(net.Listener) context.Context { return bctx },
+			HTTP2:                        opts.HTTP2,
+			Protocols:                    opts.Protocols,
+		}
 	}
 
 	// 优雅关闭处理
-	go func() {
+	a.wg.Go(func() {
 		defer cancel()
-		exit := <-a.exit
+		stop := <-a.exit
 
 		// 逆序停止所有 Servlet 组件
-		for i := len(a.servs) - 1; i >= 0; i-- {
-			err := a.servs[i].Stop()
-			if err != nil {
-				log.Println(err)
-			}
+		stopStart := time.Now()
+		if err := a.stopServlets(); err != nil {
+			log.Println(err)
 		}
 
-		// 关闭 HTTP 服务器并返回结果
-		exit <- server.Shutdown(lctx)
-	}()
+		a.mu.Lock()
+		for _, f := range a.onShutdown {
+			go f()
+		}
+		a.mu.Unlock()
 
-	go func() {
-		err := server.ListenAndServe()
-		if err != nil && err != http.ErrServerClosed {
-			log.Panicln(err)
+		// 关闭所有 HTTP 服务器并返回结果
+		errs := make([]error, len(servers)+2)
+		var wg sync.WaitGroup
+		for i, server := range servers {
+			wg.Go(func() {
+				errs[i] = server.Shutdown(lctx)
+			})
+		}
+		if pc != nil {
+			wg.Go(func() {
+				errs[len(servers)] = opts.HTTP3Server.Shutdown(lctx)
+			})
+		}
+		if opts.DrainProgress != nil {
+			drained := make(chan struct{})
+			go a.reportDrain(stop.ctx, drained)
+			wg.Wait()
+			close(drained)
+		} else {
+			wg.Wait()
 		}
-	}()
 
+		if opts.WaitHijacked {
+			errs[len(servers)+1] = a.waitHijacked(stop.ctx)
+		}
+		err := errors.Join(errs...)
+		a.emit(ServerStopped, opts.Addr, stopStart, err)
+		stop.done <- err
+	})
+
+	if pc != nil {
+		a.wg.Go(func() {
+			err := opts.HTTP3Server.Serve(pc, http.HandlerFunc(a.ServeHTTP))
+			if err != nil && err != http.ErrServerClosed {
+				log.Panicln(err)
+			}
+		})
+	}
+
+	for i, server := range servers {
+		ln, spec := lns[i], specs[i]
+		a.wg.Go(func() {
+			var err error
+			if spec.tls != nil {
+				err = server.ServeTLS(ln, "", "")
+			} else {
+				err = server.Serve(ln)
+			}
+			if err != nil && err != http.ErrServerClosed {
+				log.Panicln(err)
+			}
+		})
+	}
+
+	a.emit(ServerStarted, opts.Addr, begin, nil)
 	return nil
 }
 
+// altSvc 返回启用 HTTP/3 时通告的 Alt-Svc 头的值
+func (a *App) altSvc(pc net.PacketConn) string {
+	if a.opts.AltSvc != "" {
+		return a.opts.AltSvc
+	}
+	_, port, _ := net.SplitHostPort(pc.LocalAddr().String())
+	return fmt.Sprintf(`h3=":%s"; ma=86400`, port)
+}
+
+// ReloadTLS 重新加载 TLS 证书
+//
+// 证书被原子地替换，之后的 TLS 握手使用新证书，已建立的连接不受影响。
+// 这适用于在不重启应用的情况下轮换证书。
+//
+// 加载的证书应用于所有通过 AddListener 添加的 TLS 监听地址，
+// 优先于其 tls.Config 中的 Certificates 和 GetCertificate。
+// 可以在 Start 之前调用以提供初始证书，此时 AddListener 的
+// tls.Config 可以不包含证书。
+//
+// 参数:
+//   - certFile: PEM 编码的证书文件路径
+//   - keyFile: PEM 编码的私钥文件路径
+//
+// 返回:
+//   - error: 读取或解析证书失败时返回错误，此时继续使用原证书
+func (a *App) ReloadTLS(certFile, keyFile string) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	a.cert.Store(&cert)
+	return nil
+}
+
+// tlsConfig 返回监听地址实际使用的 TLS 配置
+//
+// 返回的配置是 cfg 的副本，其 GetCertificate 优先返回 ReloadTLS 加载的证书。
+func (a *App) tlsConfig(cfg *tls.Config) *tls.Config {
+	cfg = cfg.Clone()
+
+	getCertificate := cfg.GetCertificate
+	cfg.GetCertificate = func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		if cert := a.cert.Load(); cert != nil {
+			return cert, nil
+		}
+		if getCertificate != nil {
+			return getCertificate(hello)
+		}
+		// 返回 nil 时使用 Certificates 中的证书
+		return nil, nil
+	}
+
+	return cfg
+}
+
+// connState 跟踪连接状态变化，维护空闲连接集合
+//
+// 连接在空闲状态下被关闭时调用 Options.OnIdleClose，
+// 随后调用用户配置的 Options.ConnState。
+func (a *App) connState(conn net.Conn, state http.ConnState) {
+	if state == http.StateHijacked {
+		a.trackHijacked(conn)
+	}
+
+	a.mu.Lock()
+	_, wasIdle := a.idle[conn]
+	switch state {
+	case http.StateNew:
+		a.conns++
+	case http.StateClosed, http.StateHijacked:
+		a.conns--
+	}
+	switch state {
+	case http.StateIdle:
+		if a.idle == nil {
+			a.idle = make(map[net.Conn]struct{})
+		}
+		a.idle[conn] = struct{}{}
+	default:
+		delete(a.idle, conn)
+	}
+	a.mu.Unlock()
+
+	if state == http.StateClosed && wasIdle && a.opts.OnIdleClose != nil {
+		a.opts.OnIdleClose(conn)
+	}
+
+	if a.opts.ConnState != nil {
+		a.opts.ConnState(conn, state)
+	}
+}
+
+// IdleConnCount 返回当前处于空闲状态的 keep-alive 连接数量
+//
+// 统计覆盖所有监听地址上的连接，可用于容量规划和监控。
+func (a *App) IdleConnCount() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.idle)
+}
+
+// drainProgressInterval Options.DrainProgress 的调用间隔
+const drainProgressInterval = 100 * time.Millisecond
+
+// reportDrain 周期性地以剩余连接数调用 Options.DrainProgress，直到 done 关闭或 ctx 结束
+func (a *App) reportDrain(ctx context.Context, done <-chan struct{}) {
+	t := time.NewTicker(drainProgressInterval)
+	defer t.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		a.mu.Lock()
+		remaining := a.conns
+		a.mu.Unlock()
+		a.opts.DrainProgress(remaining)
+	}
+}
+
+// Drain 将应用切换到排空状态
+//
+// 排空状态下 ReadinessHandler 返回 503，负载均衡器据此停止向本实例转发新请求，
+// 而应用仍然正常处理所有请求，进行中的请求可以正常完成。
+// Drain 本身不会停止服务，通常在一段等待时间后再调用 Stop。
+//
+// 示例:
+//
+//	app.Drain()
+//	time.Sleep(10 * time.Second) // 等待负载均衡器摘除实例
+//	_ = app.Stop(ctx)
+func (a *App) Drain() {
+	a.draining.Store(true)
+}
+
+// Draining 返回应用是否处于排空状态
+func (a *App) Draining() bool {
+	return a.draining.Load()
+}
+
+// ReadinessHandler 返回反映应用就绪状态的处理器
+//
+// 正常情况下返回 200 OK，以下情况返回 503 Service Unavailable:
+//   - 调用了 Drain
+//   - 任何实现了 ReadyChecker 的 Servlet 报告未就绪
+//
+// 示例:
+//
+//	mux.Handle("GET /readyz", app.ReadinessHandler())
+func (a *App) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.draining.Load() {
+			http.Error(w, "draining", http.StatusServiceUnavailable)
+			return
+		}
+		a.mu.Lock()
+		servs := slices.Clone(a.servs)
+		a.mu.Unlock()
+
+		for _, serv := range servs {
+			if !servletReady(serv) {
+				http.Error(w, "not ready", http.StatusServiceUnavailable)
+				return
+			}
+		}
+		w.Write([]byte("ok"))
+	})
+}
+
 // Stop 优雅停止 HTTP 应用
 //
 // 此方法会按顺序执行以下操作:
 //  1. 发送关闭信号
 //  2. 逆序停止所有 Servlet 组件（调用 Stop 方法）
-//  3. 优雅关闭 HTTP 服务器（等待现有连接完成）
+//  3. 调用 RegisterOnShutdown 注册的函数
+//  4. 优雅关闭 HTTP 服务器（等待现有连接完成，期间通过 Options.DrainProgress 报告进度）
+//  5. 如果启用了 Options.WaitHijacked，等待被接管的连接关闭
 //
 // 参数:
 //   - ctx: 用于控制关闭超时的上下文
 //
+// Start 返回之后调用 Stop 总是会等待关闭完成：如果 HTTP 服务器还没有开始接受连接，
+// 它们会直接以关闭状态退出，不会出现启动和关闭的竞争。
+// 应用没有启动或已经停止时，Stop 立即返回 ErrNotStarted。
+//
 // 返回:
-//   - error: 关闭过程中的错误
+//   - error: 关闭过程中的错误；应用未启动时返回 ErrNotStarted；
+//     ctx 在关闭开始之前结束时返回 ctx.Err()
 func (a *App) Stop(ctx context.Context) error {
-	exit := make(chan error)
-	a.exit <- exit
-	return <-exit
+	if !a.started.CompareAndSwap(true, false) {
+		return ErrNotStarted
+	}
+
+	done := make(chan error)
+	select {
+	case a.exit <- stopRequest{ctx: ctx, done: done}:
+	case <-ctx.Done():
+		// 关闭没有开始，应用仍在运行，允许之后再次调用 Stop
+		a.started.Store(true)
+		return ctx.Err()
+	}
+	return <-done
+}
+
+// ErrNotStarted 在应用没有启动或已经停止时由 Stop 返回
+var ErrNotStarted = errors.New("h3: app not started")
+
+// stopRequest Stop 发送给关闭 goroutine 的请求
+type stopRequest struct {
+	ctx  context.Context // 控制关闭超时的上下文
+	done chan error      // 接收关闭结果
+}
+
+// Wait 阻塞直到应用的后台 goroutine 全部退出
+//
+// Stop 返回时 HTTP 服务器已经关闭，但服务 goroutine 和关闭 goroutine
+// 可能仍在退出过程中。Wait 可以在测试清理或进程退出前调用，
+// 确保不会遗留 goroutine。
+//
+// 如果应用从未启动，Wait 立即返回。
+//
+// 示例:
+//
+//	_ = app.Stop(ctx)
+//	app.Wait()
+func (a *App) Wait() {
+	a.wg.Wait()
+}
+
+// requestTimeout 为每个请求的上下文设置截止时间
+//
+// 协议升级和事件流请求会被跳过，因为它们的生命周期通常远长于普通请求，
+// 截止时间到达后取消上下文会中断连接。
+func requestTimeout(next http.Handler, timeout time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isLongLived(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// errorResponseWriter 将错误响应写出函数注入请求上下文，供路由器在未匹配时使用
+func errorResponseWriter(next http.Handler, fn func(http.ResponseWriter, *http.Request, int)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), errorWriterKey{}, fn)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// panicHandler 将 panic 报告函数注入请求上下文，供 Recoverer 在恢复 panic 时使用
+func panicHandler(next http.Handler, fn func(*http.Request, any, []byte)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), panicHandlerKey{}, fn)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// isLongLived 判断请求是否为协议升级或事件流等长连接请求
+func isLongLived(r *http.Request) bool {
+	if r.Header.Get("Upgrade") != "" {
+		return true
+	}
+	return strings.Contains(r.Header.Get("Accept"), "text/event-stream")
 }
@@ -2,11 +2,28 @@ package h3
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
 	"errors"
 	"fmt"
 	"io"
+	"log"
+	"math/big"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -754,3 +771,1462 @@ func TestAppServletWithContext(t *testing.T) {
 		t.Fatalf("Stop failed: %v", err)
 	}
 }
+
+func TestAppRequestTimeout(t *testing.T) {
+	mux := NewMux()
+
+	mux.HandleFunc("GET /deadline", func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("ok"))
+	})
+
+	// 模拟一个耗时的数据库查询
+	mux.HandleFunc("GET /slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+			w.Write([]byte("finished"))
+		case <-r.Context().Done():
+			w.WriteHeader(http.StatusGatewayTimeout)
+			w.Write([]byte(r.Context().Err().Error()))
+		}
+	})
+
+	app := New(mux, Options{Addr: ":8097", RequestTimeout: 50 * time.Millisecond})
+	ctx := context.Background()
+
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() { _ = app.Stop(ctx) }()
+
+	time.Sleep(100 * time.Millisecond)
+
+	resp, err := http.Get("http://localhost:8097/deadline")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("request context has no deadline, status = %d", resp.StatusCode)
+	}
+
+	start := time.Now()
+	resp, err = http.Get("http://localhost:8097/slow")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusGatewayTimeout {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusGatewayTimeout)
+	}
+
+	if string(body) != context.DeadlineExceeded.Error() {
+		t.Errorf("body = %q, want %q", string(body), context.DeadlineExceeded.Error())
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("slow request took %v, want it cancelled early", elapsed)
+	}
+}
+
+func TestRequestTimeoutSkipsLongLived(t *testing.T) {
+	handler := requestTimeout(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); ok {
+			w.Header().Set("X-Deadline", "true")
+		}
+	}), time.Second)
+
+	tests := []struct {
+		name     string
+		header   string
+		value    string
+		deadline bool
+	}{
+		{"plain", "", "", true},
+		{"websocket", "Upgrade", "websocket", false},
+		{"sse", "Accept", "text/event-stream", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("X-Deadline") == "true"; got != tt.deadline {
+				t.Errorf("deadline = %v, want %v", got, tt.deadline)
+			}
+		})
+	}
+}
+
+func TestAppWait(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	mux := NewMux()
+	app := New(mux, Options{Addr: ":8098"})
+	ctx := context.Background()
+
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	if err := app.Stop(ctx); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		app.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Wait did not return after Stop")
+	}
+
+	// 等待调用 Wait 的辅助 goroutine 自身退出
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if after := runtime.NumGoroutine(); after > before {
+		t.Errorf("goroutines after Wait = %d, want <= %d", after, before)
+	}
+}
+
+func TestAppWaitWithoutStart(t *testing.T) {
+	app := New(NewMux(), Options{Addr: ":8099"})
+
+	done := make(chan struct{})
+	go func() {
+		app.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Wait should return immediately when app was never started")
+	}
+}
+
+func TestAppErrorResponseWriter(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("users"))
+	})
+
+	api := NewMux()
+	api.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	mux.Mount("/api", api)
+
+	app := New(mux, Options{
+		ErrorResponseWriter: func(w http.ResponseWriter, r *http.Request, status int) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			fmt.Fprintf(w, `{"status":%d,"error":%q}`, status, http.StatusText(status))
+		},
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		body   string
+		allow  string
+	}{
+		{"not found", "GET", "/missing", http.StatusNotFound, `{"status":404,"error":"Not Found"}`, ""},
+		{"method not allowed", "DELETE", "/users", http.StatusMethodNotAllowed, `{"status":405,"error":"Method Not Allowed"}`, "GET, HEAD"},
+		{"mounted not found", "GET", "/api/missing", http.StatusNotFound, `{"status":404,"error":"Not Found"}`, ""},
+		{"matched", "GET", "/users", http.StatusOK, "users", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			app.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+
+			if rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+
+			if tt.status != http.StatusOK {
+				if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+					t.Errorf("Content-Type = %q, want %q", ct, "application/json")
+				}
+			}
+
+			if got := rec.Header().Get("Allow"); got != tt.allow {
+				t.Errorf("Allow = %q, want %q", got, tt.allow)
+			}
+		})
+	}
+}
+
+func TestAppErrorResponseWriterDefault(t *testing.T) {
+	app := New(NewMux())
+
+	req := httptest.NewRequest("GET", "/missing", nil)
+	rec := httptest.NewRecorder()
+
+	app.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+
+	if rec.Body.String() != "404 page not found\n" {
+		t.Errorf("body = %q, want %q", rec.Body.String(), "404 page not found\n")
+	}
+}
+
+// newTestCertificate 生成用于测试的自签名证书，返回 PEM 编码的证书和私钥
+func newTestCertificate(t *testing.T, commonName string) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed: %v", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey failed: %v", err)
+	}
+
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return certPEM, keyPEM
+}
+
+func TestAppAddListener(t *testing.T) {
+	certPEM, keyPEM := newTestCertificate(t, "h3-test")
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("X509KeyPair failed: %v", err)
+	}
+
+	mux := NewMux()
+	mux.HandleFunc("GET /test", func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil {
+			w.Write([]byte("tls"))
+			return
+		}
+		w.Write([]byte("plain"))
+	})
+	mux.HandleFunc("GET /slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+
+	app := New(mux, Options{Addr: ":8100"})
+	app.AddListener(":8101", &tls.Config{Certificates: []tls.Certificate{cert}})
+
+	starts := 0
+	app.AddServlet(ServletFunc(func(context.Context) error {
+		starts++
+		return nil
+	}, nil))
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(certPEM)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	defer client.CloseIdleConnections()
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"http://localhost:8100/test", "plain"},
+		{"https://localhost:8101/test", "tls"},
+	}
+
+	for _, tt := range tests {
+		resp, err := client.Get(tt.url)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", tt.url, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if string(body) != tt.want {
+			t.Errorf("GET %s body = %q, want %q", tt.url, string(body), tt.want)
+		}
+	}
+
+	if starts != 1 {
+		t.Errorf("servlet started %d times, want 1", starts)
+	}
+
+	// 两个监听地址上的慢请求都应在 Stop 时完成
+	var wg sync.WaitGroup
+	for _, url := range []string{"http://localhost:8100/slow", "https://localhost:8101/slow"} {
+		wg.Go(func() {
+			resp, err := client.Get(url)
+			if err != nil {
+				t.Errorf("GET %s failed: %v", url, err)
+				return
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if string(body) != "done" {
+				t.Errorf("GET %s body = %q, want %q", url, string(body), "done")
+			}
+		})
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	if err := app.Stop(ctx); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	wg.Wait()
+
+	for _, url := range []string{"http://localhost:8100/test", "https://localhost:8101/test"} {
+		if _, err := client.Get(url); err == nil {
+			t.Errorf("expected error when connecting to stopped listener %s", url)
+		}
+	}
+}
+
+func TestAppAddListenerBindError(t *testing.T) {
+	app := New(NewMux(), Options{Addr: ":8102"})
+	app.AddListener(":8102", nil)
+
+	servlet := newMockServlet()
+	app.AddServlet(servlet)
+
+	if err := app.Start(context.Background()); err == nil {
+		t.Fatal("Start should fail when a listener cannot bind")
+	}
+
+	if servlet.wasStartCalled() {
+		t.Error("servlet should not start when binding fails")
+	}
+
+	// 失败后第一个地址应已释放
+	ln, err := net.Listen("tcp", ":8102")
+	if err != nil {
+		t.Fatalf("address not released after failed Start: %v", err)
+	}
+	ln.Close()
+}
+
+func TestAppIdleConnCount(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("GET /test", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	closed := make(chan net.Conn, 2)
+	var states []http.ConnState
+	var mu sync.Mutex
+
+	app := New(mux, Options{
+		Addr:        ":8103",
+		IdleTimeout: 300 * time.Millisecond,
+		OnIdleClose: func(c net.Conn) { closed <- c },
+		ConnState: func(c net.Conn, s http.ConnState) {
+			mu.Lock()
+			states = append(states, s)
+			mu.Unlock()
+		},
+	})
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() { _ = app.Stop(ctx) }()
+
+	time.Sleep(100 * time.Millisecond)
+
+	// 两个独立的 keep-alive 连接
+	for range 2 {
+		client := &http.Client{Transport: &http.Transport{}}
+		resp, err := client.Get("http://localhost:8103/test")
+		if err != nil {
+			t.Fatalf("GET failed: %v", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+
+	if got := app.IdleConnCount(); got != 2 {
+		t.Errorf("IdleConnCount = %d, want 2", got)
+	}
+
+	// 等待 IdleTimeout 回收空闲连接
+	for i := range 2 {
+		select {
+		case <-closed:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("OnIdleClose called %d times, want 2", i)
+		}
+	}
+
+	if got := app.IdleConnCount(); got != 0 {
+		t.Errorf("IdleConnCount after idle timeout = %d, want 0", got)
+	}
+
+	// 用户配置的 ConnState 仍然会被调用
+	mu.Lock()
+	defer mu.Unlock()
+	if len(states) == 0 {
+		t.Error("user ConnState was not called")
+	}
+}
+
+func TestAppDrainProgress(t *testing.T) {
+	entered := make(chan struct{}, 2)
+	release := make(chan struct{}, 2)
+	mux := NewMux()
+	mux.HandleFunc("GET /slow", func(w http.ResponseWriter, r *http.Request) {
+		entered <- struct{}{}
+		<-release
+		w.Write([]byte("ok"))
+	})
+
+	reports := make(chan int, 100)
+	app := New(mux, Options{
+		Addr:          ":8122",
+		DrainProgress: func(remaining int) { reports <- remaining },
+	})
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+
+	// 两个独立连接上的进行中请求
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Go(func() {
+			client := &http.Client{Transport: &http.Transport{}}
+			resp, err := client.Get("http://localhost:8122/slow")
+			if err != nil {
+				t.Errorf("GET failed: %v", err)
+				return
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		})
+	}
+	<-entered
+	<-entered
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- app.Stop(ctx) }()
+
+	var got []int
+	waitFor := func(want int) {
+		t.Helper()
+		for {
+			select {
+			case n := <-reports:
+				got = append(got, n)
+				if n == want {
+					return
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatalf("no report of %d remaining connections, got %v", want, got)
+			}
+		}
+	}
+
+	waitFor(2)
+	release <- struct{}{}
+	waitFor(1)
+	release <- struct{}{}
+
+	if err := <-stopped; err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	wg.Wait()
+
+	for i := 1; i < len(got); i++ {
+		if got[i] > got[i-1] {
+			t.Errorf("remaining counts increased: %v", got)
+			break
+		}
+	}
+
+	// 关闭完成后不再报告
+	time.Sleep(3 * drainProgressInterval)
+	for len(reports) > 0 {
+		<-reports
+	}
+	time.Sleep(2 * drainProgressInterval)
+	if len(reports) != 0 {
+		t.Errorf("DrainProgress called after Stop returned")
+	}
+}
+
+func TestAppSwapMux(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	oldMux := NewMux()
+	oldMux.HandleFunc("GET /version", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("slow") {
+			close(entered)
+			<-release
+		}
+		w.Write([]byte("old"))
+	})
+
+	app := New(oldMux, Options{Addr: ":8123"})
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() { _ = app.Stop(ctx) }()
+	time.Sleep(50 * time.Millisecond)
+
+	get := func(path string) string {
+		resp, err := http.Get("http://localhost:8123" + path)
+		if err != nil {
+			t.Errorf("GET %s failed: %v", path, err)
+			return ""
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	inflight := make(chan string, 1)
+	go func() { inflight <- get("/version?slow") }()
+	<-entered
+
+	newMux := NewMux()
+	newMux.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Swapped", "1")
+			next.ServeHTTP(w, r)
+		})
+	})
+	newMux.HandleFunc("GET /version", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("new"))
+	})
+	if old := app.SwapMux(newMux); old != oldMux {
+		t.Error("SwapMux did not return the previous mux")
+	}
+
+	if got := get("/version"); got != "new" {
+		t.Errorf("request after swap = %q, want %q", got, "new")
+	}
+
+	close(release)
+	if got := <-inflight; got != "old" {
+		t.Errorf("in-flight request = %q, want %q", got, "old")
+	}
+
+	// 直接调用 ServeHTTP 同样使用新的路由器
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest("GET", "/version", nil))
+	if rec.Body.String() != "new" || rec.Header().Get("X-Swapped") != "1" {
+		t.Errorf("ServeHTTP after swap = %q, want new handler and middleware", rec.Body.String())
+	}
+
+	// 之后的注册作用于新的路由器
+	app.HandleFunc("GET /extra", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("extra"))
+	})
+	if got := get("/extra"); got != "extra" {
+		t.Errorf("route registered after swap = %q, want %q", got, "extra")
+	}
+}
+
+func TestAppReloadTLS(t *testing.T) {
+	dir := t.TempDir()
+	writeCert := func(name string) (certFile, keyFile string) {
+		certPEM, keyPEM := newTestCertificate(t, name)
+		certFile = filepath.Join(dir, name+".crt")
+		keyFile = filepath.Join(dir, name+".key")
+		if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+		return certFile, keyFile
+	}
+
+	firstCert, firstKey := writeCert("first")
+	secondCert, secondKey := writeCert("second")
+
+	mux := NewMux()
+	mux.HandleFunc("GET /test", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	app := New(mux, Options{Addr: ":8104"})
+	app.AddListener(":8105", &tls.Config{})
+
+	if err := app.ReloadTLS(firstCert, firstKey); err != nil {
+		t.Fatalf("ReloadTLS failed: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() { _ = app.Stop(ctx) }()
+
+	time.Sleep(100 * time.Millisecond)
+
+	peerName := func() string {
+		t.Helper()
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			DisableKeepAlives: true,
+		}}
+		resp, err := client.Get("https://localhost:8105/test")
+		if err != nil {
+			t.Fatalf("GET failed: %v", err)
+		}
+		defer resp.Body.Close()
+		return resp.TLS.PeerCertificates[0].Subject.CommonName
+	}
+
+	if got := peerName(); got != "first" {
+		t.Errorf("certificate = %q, want %q", got, "first")
+	}
+
+	if err := app.ReloadTLS(secondCert, secondKey); err != nil {
+		t.Fatalf("ReloadTLS failed: %v", err)
+	}
+
+	if got := peerName(); got != "second" {
+		t.Errorf("certificate after reload = %q, want %q", got, "second")
+	}
+
+	// 加载失败时继续使用原证书
+	if err := app.ReloadTLS(filepath.Join(dir, "missing.crt"), secondKey); err == nil {
+		t.Error("ReloadTLS should fail for missing certificate file")
+	}
+
+	if got := peerName(); got != "second" {
+		t.Errorf("certificate after failed reload = %q, want %q", got, "second")
+	}
+}
+
+func TestAppStartCancelledContext(t *testing.T) {
+	app := New(NewMux(), Options{Addr: ":8106"})
+
+	servlet := newMockServlet()
+	app.AddServlet(servlet)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := app.Start(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Start error = %v, want %v", err, context.Canceled)
+	}
+
+	if servlet.wasStartCalled() {
+		t.Error("servlet should not start with a cancelled context")
+	}
+
+	// 不应创建监听
+	ln, err := net.Listen("tcp", ":8106")
+	if err != nil {
+		t.Fatalf("listener was created despite cancelled context: %v", err)
+	}
+	ln.Close()
+}
+
+func TestAppDrain(t *testing.T) {
+	mux := NewMux()
+	app := New(mux)
+
+	mux.Handle("GET /readyz", app.ReadinessHandler())
+	mux.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("users"))
+	})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		app.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec
+	}
+
+	if rec := get("/readyz"); rec.Code != http.StatusOK {
+		t.Errorf("readiness status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	if app.Draining() {
+		t.Error("app should not be draining before Drain")
+	}
+
+	app.Drain()
+
+	if !app.Draining() {
+		t.Error("app should be draining after Drain")
+	}
+
+	if rec := get("/readyz"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("readiness status after Drain = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	// 其他路由在排空期间仍然正常服务
+	rec := get("/users")
+	if rec.Code != http.StatusOK || rec.Body.String() != "users" {
+		t.Errorf("GET /users during drain = %d %q, want %d %q", rec.Code, rec.Body.String(), http.StatusOK, "users")
+	}
+}
+
+// countingListener 统计 Accept 次数的监听器包装
+type countingListener struct {
+	net.Listener
+	accepts atomic.Int32
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err == nil {
+		l.accepts.Add(1)
+	}
+	return c, err
+}
+
+func TestAppWrapListener(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("GET /test", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	var mu sync.Mutex
+	var wrapped []*countingListener
+
+	app := New(mux, Options{
+		Addr: ":8111",
+		WrapListener: func(ln net.Listener) net.Listener {
+			cl := &countingListener{Listener: ln}
+			mu.Lock()
+			wrapped = append(wrapped, cl)
+			mu.Unlock()
+			return cl
+		},
+	})
+	app.AddListener(":8112", nil)
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() { _ = app.Stop(ctx) }()
+
+	if len(wrapped) != 2 {
+		t.Fatalf("WrapListener called %d times, want 2", len(wrapped))
+	}
+
+	// 每个请求使用新连接
+	for range 3 {
+		client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+		resp, err := client.Get("http://localhost:8111/test")
+		if err != nil {
+			t.Fatalf("GET failed: %v", err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	if got := wrapped[0].accepts.Load(); got != 3 {
+		t.Errorf("accepts on :8111 = %d, want 3", got)
+	}
+	if got := wrapped[1].accepts.Load(); got != 0 {
+		t.Errorf("accepts on :8112 = %d, want 0", got)
+	}
+}
+
+func TestAppSetAddr(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("GET /test", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	app := New(mux, Options{Addr: ":8080"})
+
+	if err := app.SetAddr("invalid"); err == nil {
+		t.Error("SetAddr with invalid address should fail")
+	}
+
+	if err := app.SetAddr(":8113"); err != nil {
+		t.Fatalf("SetAddr failed: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() { _ = app.Stop(ctx) }()
+
+	resp, err := http.Get("http://localhost:8113/test")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if string(body) != "ok" {
+		t.Errorf("body = %q, want %q", body, "ok")
+	}
+
+	if err := app.SetAddr(":8114"); err == nil {
+		t.Error("SetAddr after Start should fail")
+	}
+}
+
+func TestAppBaseContextValues(t *testing.T) {
+	type configKey struct{}
+
+	mux := NewMux()
+	mux.HandleFunc("GET /test", func(w http.ResponseWriter, r *http.Request) {
+		name, _ := r.Context().Value(configKey{}).(string)
+		w.Write([]byte(name))
+	})
+
+	app := New(mux, Options{
+		Addr:              ":8117",
+		BaseContextValues: map[any]any{configKey{}: "production"},
+	})
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() { _ = app.Stop(ctx) }()
+
+	resp, err := http.Get("http://localhost:8117/test")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if string(body) != "production" {
+		t.Errorf("body = %q, want %q", body, "production")
+	}
+}
+
+func TestAppRegisterVersioned(t *testing.T) {
+	users := newMockServletComponent("/users")
+	users.Mux().HandleFunc("GET /{id}", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s", APIVersion(r), r.PathValue("id"))
+	})
+
+	var starts int
+	var mu sync.Mutex
+	app := New(NewMux(), Options{
+		Addr: ":8120",
+		LifecycleHook: func(ev LifecycleEvent) {
+			if ev.Type == ServletStarted {
+				mu.Lock()
+				starts++
+				mu.Unlock()
+			}
+		},
+	})
+	app.RegisterVersioned("v1", users)
+	app.RegisterVersioned("/v2/", users)
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/v1/users/42", http.StatusOK, "v1 42"},
+		{"/v2/users/42", http.StatusOK, "v2 42"},
+		{"/v3/users/42", http.StatusNotFound, "404 page not found\n"},
+		{"/users/42", http.StatusNotFound, "404 page not found\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			app.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+
+			if rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+		})
+	}
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() { _ = app.Stop(ctx) }()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if starts != 1 {
+		t.Errorf("servlet started %d times, want 1", starts)
+	}
+}
+
+func TestAppRegisterErr(t *testing.T) {
+	app := New(NewMux())
+
+	if err := app.RegisterErr(NewComponent("/users")); err != nil {
+		t.Fatalf("RegisterErr failed: %v", err)
+	}
+
+	servlet := newMockServletComponent("/users")
+	err := app.RegisterErr(servlet)
+	if err == nil {
+		t.Fatal("expected error for conflicting component")
+	}
+
+	if !strings.Contains(err.Error(), `"/users"`) || !strings.Contains(err.Error(), "conflicts") {
+		t.Errorf("error = %q, want it to name the component and the conflict", err)
+	}
+
+	// 注册失败的组件不会被启动
+	if len(app.servs) != 0 {
+		t.Errorf("servlets = %d, want 0", len(app.servs))
+	}
+}
+
+func TestAppMaxComponents(t *testing.T) {
+	app := New(NewMux(), Options{MaxComponents: 3})
+
+	for i := range 3 {
+		if err := app.RegisterErr(NewComponent(fmt.Sprintf("/c%d", i))); err != nil {
+			t.Fatalf("RegisterErr #%d = %v, want nil", i, err)
+		}
+	}
+
+	err := app.RegisterErr(NewComponent("/c3"))
+	if !errors.Is(err, ErrTooManyComponents) {
+		t.Fatalf("RegisterErr past limit = %v, want ErrTooManyComponents", err)
+	}
+	if !strings.Contains(err.Error(), `"/c3"`) {
+		t.Errorf("error = %q, want it to name the component", err)
+	}
+	if n := len(app.Components()); n != 3 {
+		t.Errorf("Components = %d, want 3", n)
+	}
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest("GET", "/c3/", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("rejected component routes were mounted: status %d", rec.Code)
+	}
+
+	for name, register := range map[string]func(){
+		"Register":          func() { app.Register(NewComponent("/x")) },
+		"RegisterVersioned": func() { app.RegisterVersioned("v1", NewComponent("/x")) },
+		"RegisterGroup":     func() { app.RegisterGroup("g", NewComponent("/x")) },
+	} {
+		func() {
+			defer func() {
+				if v, _ := recover().(error); !errors.Is(v, ErrTooManyComponents) {
+					t.Errorf("%s past limit: recovered %v, want ErrTooManyComponents", name, v)
+				}
+			}()
+			register()
+		}()
+	}
+
+	// 默认不限制
+	unlimited := New(NewMux())
+	for i := range 100 {
+		if err := unlimited.RegisterErr(NewComponent(fmt.Sprintf("/c%d", i))); err != nil {
+			t.Fatalf("RegisterErr #%d without limit = %v", i, err)
+		}
+	}
+}
+
+func TestAppAddServlet(t *testing.T) {
+	m := NewMux().(*mux)
+	app := New(m, Options{Addr: ":8121"})
+
+	var order []string
+	var mu sync.Mutex
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, event)
+	}
+
+	component := newMockServletComponent("/api")
+	worker := ServletFunc(
+		func(ctx context.Context) error { record("worker start"); return nil },
+		func() error { record("worker stop"); return nil },
+	)
+
+	app.Register(component)
+	app.AddServlet(worker)
+	app.AddServlet(worker)
+
+	// 没有注册额外的路由
+	if len(m.rts) != 1 {
+		t.Errorf("routes = %d, want 1", len(m.rts))
+	}
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if !component.wasStartCalled() {
+		t.Error("component servlet was not started")
+	}
+
+	if err := app.Stop(ctx); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if !component.wasStopCalled() {
+		t.Error("component servlet was not stopped")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"worker start", "worker stop"}
+	if len(order) != len(want) || order[0] != want[0] || order[1] != want[1] {
+		t.Errorf("worker events = %v, want %v", order, want)
+	}
+}
+
+// warmingServlet Start 立即返回、在后台预热的测试 Servlet
+type warmingServlet struct {
+	ready atomic.Bool
+}
+
+func (s *warmingServlet) Start(ctx context.Context) error { return nil }
+func (s *warmingServlet) Stop() error                     { return nil }
+func (s *warmingServlet) Ready() bool                     { return s.ready.Load() }
+
+func TestAppReadinessServlets(t *testing.T) {
+	mux := NewMux()
+	app := New(mux)
+
+	warm := &warmingServlet{}
+	app.AddServlet(warm)
+	comp := &warmingServlet{}
+	app.Register(ServletFromComponent(NewComponent("/jobs"), comp))
+	app.AddServlet(ServletFunc(nil, nil))
+
+	mux.Handle("GET /readyz", app.ReadinessHandler())
+
+	ready := func() int {
+		rec := httptest.NewRecorder()
+		app.ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+		return rec.Code
+	}
+
+	if code := ready(); code != http.StatusServiceUnavailable {
+		t.Errorf("readiness while warming = %d, want %d", code, http.StatusServiceUnavailable)
+	}
+
+	warm.ready.Store(true)
+	if code := ready(); code != http.StatusServiceUnavailable {
+		t.Errorf("readiness with one servlet warming = %d, want %d", code, http.StatusServiceUnavailable)
+	}
+
+	comp.ready.Store(true)
+	if code := ready(); code != http.StatusOK {
+		t.Errorf("readiness after warm up = %d, want %d", code, http.StatusOK)
+	}
+
+	warm.ready.Store(false)
+	if code := ready(); code != http.StatusServiceUnavailable {
+		t.Errorf("readiness after servlet became unready = %d, want %d", code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestAppHTTP2Config(t *testing.T) {
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+
+	mux := NewMux()
+	mux.HandleFunc("GET /proto", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	})
+
+	app := New(mux, Options{
+		Addr:      "127.0.0.1:8122",
+		Protocols: &protocols,
+		HTTP2: &http.HTTP2Config{
+			MaxConcurrentStreams: 7,
+			MaxReadFrameSize:     1 << 15,
+		},
+	})
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer app.Stop(ctx)
+
+	// 使用 h2c 客户端确认请求通过 HTTP/2 处理
+	client := &http.Client{Transport: &http.Transport{Protocols: &protocols}}
+	defer client.CloseIdleConnections()
+
+	resp, err := client.Get("http://127.0.0.1:8122/proto")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "HTTP/2.0" {
+		t.Errorf("proto = %q, want %q", body, "HTTP/2.0")
+	}
+
+	// 读取服务器的 SETTINGS 帧，确认通告了配置的限制
+	settings := readH2Settings(t, "127.0.0.1:8122")
+	if got := settings[0x3]; got != 7 {
+		t.Errorf("SETTINGS_MAX_CONCURRENT_STREAMS = %d, want 7", got)
+	}
+	if got := settings[0x5]; got != 1<<15 {
+		t.Errorf("SETTINGS_MAX_FRAME_SIZE = %d, want %d", got, 1<<15)
+	}
+}
+
+// readH2Settings 以 h2c prior knowledge 方式连接服务器，返回服务器第一个 SETTINGS 帧中的参数
+func readH2Settings(t *testing.T, addr string) map[uint16]uint32 {
+	t.Helper()
+
+	conn, err := net.DialTimeout("tcp", addr, time.Second)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	// 连接前言和一个空的 SETTINGS 帧
+	preface := "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+	if _, err := conn.Write(append([]byte(preface), 0, 0, 0, 0x4, 0, 0, 0, 0, 0)); err != nil {
+		t.Fatalf("write preface failed: %v", err)
+	}
+
+	header := make([]byte, 9)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		t.Fatalf("read frame header failed: %v", err)
+	}
+	length := int(header[0])<<16 | int(header[1])<<8 | int(header[2])
+	if header[3] != 0x4 {
+		t.Fatalf("first frame type = %d, want SETTINGS", header[3])
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(conn, payload); err != nil {
+		t.Fatalf("read settings failed: %v", err)
+	}
+
+	settings := make(map[uint16]uint32)
+	for p := payload; len(p) >= 6; p = p[6:] {
+		id := uint16(p[0])<<8 | uint16(p[1])
+		settings[id] = uint32(p[2])<<24 | uint32(p[3])<<16 | uint32(p[4])<<8 | uint32(p[5])
+	}
+	return settings
+}
+
+func TestAppPanicHandler(t *testing.T) {
+	var gotPath string
+	var gotValue any
+	var gotStack []byte
+	var committedBefore bool
+
+	mux := NewMux()
+	mux.Recover()
+	mux.HandleFunc("GET /boom", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	var rw Response
+	mux.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw = NewResponse(w)
+			next.ServeHTTP(rw, r)
+		})
+	})
+
+	app := New(mux, Options{
+		PanicHandler: func(r *http.Request, recovered any, stack []byte) {
+			gotPath, gotValue, gotStack = r.URL.Path, recovered, stack
+			committedBefore = rw.Committed()
+		},
+	})
+
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest("GET", "/boom", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if gotPath != "/boom" || gotValue != "boom" {
+		t.Errorf("hook got path %q value %v, want %q %q", gotPath, gotValue, "/boom", "boom")
+	}
+	if !strings.Contains(string(gotStack), "goroutine") {
+		t.Errorf("stack should contain a goroutine trace, got %q", gotStack)
+	}
+	if committedBefore {
+		t.Error("PanicHandler should run before the 500 response is written")
+	}
+}
+
+func TestAppPanicHandlerPanics(t *testing.T) {
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(io.Discard)
+
+	mux := NewMux()
+	mux.Use(Recoverer())
+	mux.HandleFunc("GET /boom", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	app := New(mux, Options{
+		PanicHandler: func(r *http.Request, recovered any, stack []byte) {
+			panic("reporter down")
+		},
+	})
+
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest("GET", "/boom", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+	if !strings.Contains(rec.Body.String(), `"code":"internal_server_error"`) {
+		t.Errorf("body = %q, want JSON error envelope", rec.Body.String())
+	}
+}
+
+func TestAppStopImmediatelyAfterStart(t *testing.T) {
+	for i := range 20 {
+		app := New(NewMux(), Options{Addr: "127.0.0.1:8123"})
+
+		if err := app.Start(context.Background()); err != nil {
+			t.Fatalf("iteration %d: Start failed: %v", i, err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := app.Stop(ctx)
+		cancel()
+		if err != nil {
+			t.Fatalf("iteration %d: Stop failed: %v", i, err)
+		}
+
+		done := make(chan struct{})
+		go func() {
+			app.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("iteration %d: app goroutines did not exit", i)
+		}
+	}
+
+	// 端口已经释放
+	ln, err := net.Listen("tcp", "127.0.0.1:8123")
+	if err != nil {
+		t.Fatalf("port still in use after Stop: %v", err)
+	}
+	ln.Close()
+}
+
+func TestAppStopNotStarted(t *testing.T) {
+	app := New(NewMux(), Options{Addr: "127.0.0.1:8123"})
+
+	if err := app.Stop(context.Background()); !errors.Is(err, ErrNotStarted) {
+		t.Errorf("Stop before Start = %v, want ErrNotStarted", err)
+	}
+
+	if err := app.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := app.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+
+	if err := app.Stop(context.Background()); !errors.Is(err, ErrNotStarted) {
+		t.Errorf("second Stop = %v, want ErrNotStarted", err)
+	}
+}
+
+func TestAppOnListen(t *testing.T) {
+	var hooked []string
+	servlet := newMockServlet()
+	app := New(NewMux(), Options{
+		Addr: "127.0.0.1:8126",
+		OnListen: func(ln net.Listener) error {
+			// Servlet 在 OnListen 之前已经启动
+			if !servlet.startCalled {
+				t.Error("OnListen called before servlets started")
+			}
+			hooked = append(hooked, ln.Addr().String())
+			return nil
+		},
+	})
+	app.AddListener("127.0.0.1:8127", nil)
+	app.AddServlet(servlet)
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer app.Stop(ctx)
+
+	want := []string{"127.0.0.1:8126", "127.0.0.1:8127"}
+	if !slices.Equal(hooked, want) {
+		t.Errorf("OnListen addrs = %v, want %v", hooked, want)
+	}
+	if app.Addr().String() != hooked[0] {
+		t.Errorf("Addr() = %v, want %s", app.Addr(), hooked[0])
+	}
+}
+
+func TestAppOnListenError(t *testing.T) {
+	hookErr := errors.New("pid file not writable")
+	servlet := newMockServlet()
+	app := New(NewMux(), Options{
+		Addr:     "127.0.0.1:8126",
+		OnListen: func(ln net.Listener) error { return hookErr },
+	})
+	app.AddServlet(servlet)
+
+	ctx := context.Background()
+	if err := app.Start(ctx); !errors.Is(err, hookErr) {
+		t.Fatalf("Start error = %v, want %v", err, hookErr)
+	}
+	if !servlet.startCalled || !servlet.stopCalled {
+		t.Errorf("servlet started %v, stopped %v; want it stopped after OnListen fails", servlet.startCalled, servlet.stopCalled)
+	}
+	if err := app.Stop(ctx); !errors.Is(err, ErrNotStarted) {
+		t.Errorf("Stop error = %v, want ErrNotStarted", err)
+	}
+
+	// 监听器已关闭，地址可以再次绑定
+	ln, err := net.Listen("tcp", "127.0.0.1:8126")
+	if err != nil {
+		t.Fatalf("address still in use after failed Start: %v", err)
+	}
+	ln.Close()
+}
+
+func TestAppAddServletConcurrent(t *testing.T) {
+	app := New(NewMux())
+	shared := newMockServlet()
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Go(func() {
+			app.AddServlet(shared)
+			app.AddServlet(newMockServlet())
+			app.Servlets()
+		})
+	}
+	wg.Wait()
+
+	// 相同的 Servlet 只添加一次
+	if got := len(app.Servlets()); got != 21 {
+		t.Errorf("servlets = %d, want 21", got)
+	}
+}
+
+func TestAppReadinessConcurrentAddServlet(t *testing.T) {
+	app := New(NewMux())
+	ready := app.ReadinessHandler()
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() { app.AddServlet(newMockServlet()) })
+		wg.Go(func() {
+			rec := httptest.NewRecorder()
+			ready.ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+			if rec.Code != http.StatusOK {
+				t.Errorf("status = %d, want 200", rec.Code)
+			}
+		})
+	}
+	wg.Wait()
+}
+
+func TestAppSetAddrLifecycle(t *testing.T) {
+	// 启动失败之后可以修改地址后重试
+	app := New(NewMux(), Options{Addr: "127.0.0.1:0"})
+	app.AddServlet(ServletFunc(func(context.Context) error { return errors.New("not yet") }, nil))
+	if err := app.Start(context.Background()); err == nil {
+		t.Fatal("Start should fail")
+	}
+	if err := app.SetAddr("127.0.0.1:0"); err != nil {
+		t.Errorf("SetAddr after failed Start: %v", err)
+	}
+
+	// Start 与 SetAddr 并发时不会竞争，停止之后仍然不能修改
+	app = New(NewMux(), Options{Addr: "127.0.0.1:0"})
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	wg.Go(func() { _ = app.SetAddr("127.0.0.1:0") })
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	wg.Wait()
+	if err := app.Stop(ctx); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if err := app.SetAddr("127.0.0.1:0"); err == nil {
+		t.Error("SetAddr after Stop should fail")
+	}
+}
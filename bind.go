@@ -0,0 +1,144 @@
+package h3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+)
+
+// BindConfig JSON 请求体绑定的配置
+type BindConfig struct {
+	// MaxBodySize 请求体的最大字节数，超出时返回 413。
+	// 如果为零，使用 1MB。
+	MaxBodySize int64
+
+	// AllowUnknownFields 如果为 true，忽略目标结构体中不存在的字段；
+	// 默认拒绝未知字段并返回 400。
+	AllowUnknownFields bool
+}
+
+// BindError 请求体绑定失败的错误，携带对应的 HTTP 状态码
+//
+// 可能的状态码:
+//   - 400 Bad Request: JSON 格式错误、类型不匹配、包含未知字段或多个 JSON 值
+//   - 413 Request Entity Too Large: 请求体超过 MaxBodySize
+//   - 415 Unsupported Media Type: Content-Type 不是 application/json
+type BindError struct {
+	Status int   // 对应的 HTTP 状态码
+	Err    error // 原始错误
+}
+
+func (e *BindError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *BindError) Unwrap() error {
+	return e.Err
+}
+
+// BindJSON 将 JSON 请求体解码到 dst
+//
+// 要求 Content-Type 为 application/json（允许带 charset 等参数），
+// 请求体大小受 MaxBodySize 限制，且只能包含一个 JSON 值。
+// 失败时返回 *BindError，可以通过其 Status 字段写出对应的错误响应。
+//
+// 示例:
+//
+//	var req CreateUserRequest
+//	if err := h3.BindJSON(r, &req); err != nil {
+//		var be *h3.BindError
+//		errors.As(err, &be)
+//		http.Error(w, be.Error(), be.Status)
+//		return
+//	}
+func BindJSON[T any](r *http.Request, dst *T, config ...BindConfig) error {
+	var cfg BindConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = 1 << 20
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return &BindError{
+			Status: http.StatusUnsupportedMediaType,
+			Err:    errors.New("h3: content type must be application/json"),
+		}
+	}
+
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, cfg.MaxBodySize))
+	if !cfg.AllowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+
+	if err := dec.Decode(dst); err != nil {
+		return bindError(err)
+	}
+
+	// 请求体只能包含一个 JSON 值
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		if err == nil {
+			err = errors.New("h3: request body must contain a single JSON value")
+		}
+		return bindError(err)
+	}
+
+	return nil
+}
+
+// bindError 将解码错误转换为 *BindError
+func bindError(err error) *BindError {
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		return &BindError{Status: http.StatusRequestEntityTooLarge, Err: err}
+	}
+	if err == io.EOF {
+		err = errors.New("h3: request body must not be empty")
+	}
+	return &BindError{Status: http.StatusBadRequest, Err: fmt.Errorf("h3: invalid JSON body: %w", err)}
+}
+
+// boundKey 请求上下文中已绑定请求体的键，每个类型参数对应不同的键
+type boundKey[T any] struct{}
+
+// RequireJSON 创建要求并绑定 JSON 请求体的中间件
+//
+// 中间件使用 BindJSON 将请求体解码为 T，成功后保存在请求上下文中，
+// 处理器通过 Bound 获取；失败时按 BindError 的状态码写出错误响应，不再调用处理器。
+//
+// 示例:
+//
+//	mux.Handle("POST /users", h3.RequireJSON[CreateUserRequest]()(http.HandlerFunc(
+//		func(w http.ResponseWriter, r *http.Request) {
+//			req := h3.Bound[CreateUserRequest](r)
+//			// ...
+//		})))
+func RequireJSON[T any](config ...BindConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			dst := new(T)
+			if err := BindJSON(r, dst, config...); err != nil {
+				be := err.(*BindError)
+				http.Error(w, be.Error(), be.Status)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), boundKey[T]{}, dst)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Bound 返回 RequireJSON 绑定的请求体
+//
+// 如果请求没有经过对应类型的 RequireJSON 中间件，返回 nil。
+func Bound[T any](r *http.Request) *T {
+	v, _ := r.Context().Value(boundKey[T]{}).(*T)
+	return v
+}
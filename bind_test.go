@@ -0,0 +1,117 @@
+package h3
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type bindUser struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func TestBindJSON(t *testing.T) {
+	req := httptest.NewRequest("POST", "/users", strings.NewReader(`{"name":"alice","age":30}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+
+	var u bindUser
+	if err := BindJSON(req, &u); err != nil {
+		t.Fatalf("BindJSON failed: %v", err)
+	}
+
+	if u.Name != "alice" || u.Age != 30 {
+		t.Errorf("bound = %+v, want {alice 30}", u)
+	}
+}
+
+func TestBindJSONAllowUnknownFields(t *testing.T) {
+	req := httptest.NewRequest("POST", "/users", strings.NewReader(`{"name":"alice","role":"admin"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	var u bindUser
+	if err := BindJSON(req, &u, BindConfig{AllowUnknownFields: true}); err != nil {
+		t.Fatalf("BindJSON failed: %v", err)
+	}
+
+	if u.Name != "alice" {
+		t.Errorf("Name = %q, want %q", u.Name, "alice")
+	}
+}
+
+func TestRequireJSON(t *testing.T) {
+	mux := NewMux()
+	mux.Handle("POST /users", RequireJSON[bindUser](BindConfig{MaxBodySize: 64})(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			u := Bound[bindUser](r)
+			if u == nil {
+				t.Error("Bound returned nil")
+				return
+			}
+			w.Write([]byte(u.Name))
+		})))
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		want        string
+	}{
+		{"valid", "application/json", `{"name":"alice","age":30}`, http.StatusOK, "alice"},
+		{"malformed", "application/json", `{"name":`, http.StatusBadRequest, ""},
+		{"wrong type", "application/json", `{"name":42}`, http.StatusBadRequest, ""},
+		{"unknown field", "application/json", `{"name":"alice","role":"admin"}`, http.StatusBadRequest, ""},
+		{"multiple values", "application/json", `{"name":"a"}{"name":"b"}`, http.StatusBadRequest, ""},
+		{"empty", "application/json", ``, http.StatusBadRequest, ""},
+		{"oversized", "application/json", `{"name":"` + strings.Repeat("x", 100) + `"}`, http.StatusRequestEntityTooLarge, ""},
+		{"content type", "text/plain", `{"name":"alice"}`, http.StatusUnsupportedMediaType, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/users", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d (body %q)", rec.Code, tt.status, rec.Body.String())
+			}
+
+			if tt.want != "" && rec.Body.String() != tt.want {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestBindError(t *testing.T) {
+	req := httptest.NewRequest("POST", "/users", strings.NewReader(`not json`))
+	req.Header.Set("Content-Type", "application/json")
+
+	var u bindUser
+	err := BindJSON(req, &u)
+
+	var be *BindError
+	if !errors.As(err, &be) {
+		t.Fatalf("error = %T, want *BindError", err)
+	}
+
+	if be.Status != http.StatusBadRequest {
+		t.Errorf("Status = %d, want %d", be.Status, http.StatusBadRequest)
+	}
+
+	if be.Unwrap() == nil {
+		t.Error("Unwrap should return the underlying error")
+	}
+}
+
+func TestBoundWithoutMiddleware(t *testing.T) {
+	if u := Bound[bindUser](httptest.NewRequest("GET", "/", nil)); u != nil {
+		t.Errorf("Bound = %+v, want nil", u)
+	}
+}
@@ -0,0 +1,173 @@
+package h3
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// BodyLoggerConfig 请求/响应体日志中间件的配置
+type BodyLoggerConfig struct {
+	// Logger 用于输出日志，如果为 nil，使用 slog.Default()
+	Logger *slog.Logger
+
+	// Level 日志级别，默认为 slog.LevelDebug
+	Level slog.Level
+
+	// MaxBodySize 记录的请求体和响应体的最大字节数，超出部分会被截断。
+	// 如果为零，使用 4KB。
+	MaxBodySize int
+
+	// Redact 可选地在记录前处理请求体和响应体，用于脱敏密码、令牌等敏感字段。
+	// 传入的是截断后的内容，返回值会被写入日志。
+	Redact func(body []byte) []byte
+}
+
+// BodyLogger 创建记录请求体和响应体的中间件
+//
+// 中间件会在不消耗请求体的前提下读取其前 MaxBodySize 字节，
+// 并在响应写出时同步捕获响应体，请求结束后通过 slog 输出一条日志。
+// 处理器仍然可以读取完整的请求体。
+//
+// 此中间件主要用于调试，记录请求体和响应体会带来额外的内存开销。
+//
+// 示例:
+//
+//	mux.Use(h3.BodyLogger(h3.BodyLoggerConfig{
+//		Redact: h3.RedactJSON("password", "token"),
+//	}))
+func BodyLogger(cfg BodyLoggerConfig) func(http.Handler) http.Handler {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	limit := cfg.MaxBodySize
+	if limit <= 0 {
+		limit = 4 << 10
+	}
+
+	redact := cfg.Redact
+	if redact == nil {
+		redact = func(body []byte) []byte { return body }
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var reqBody []byte
+			reqTruncated := false
+
+			if r.Body != nil && r.Body != http.NoBody {
+				buf, err := io.ReadAll(io.LimitReader(r.Body, int64(limit)+1))
+				if len(buf) > limit {
+					reqBody, reqTruncated = buf[:limit], true
+				} else {
+					reqBody = buf
+				}
+
+				// 将已读取的部分放回请求体，保证处理器可以读取完整内容
+				body := io.MultiReader(bytes.NewReader(buf), r.Body)
+				if err != nil {
+					body = io.MultiReader(bytes.NewReader(buf), errReader{err})
+				}
+				r.Body = io.NopCloser(body)
+			}
+
+			rw := &bodyCapture{Response: NewResponse(w), limit: limit}
+			next.ServeHTTP(rw, r)
+
+			logger.LogAttrs(r.Context(), cfg.Level, "http body",
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", rw.Status()),
+				slog.String("request_body", string(redact(reqBody))),
+				slog.Bool("request_truncated", reqTruncated),
+				slog.String("response_body", string(redact(rw.buf.Bytes()))),
+				slog.Bool("response_truncated", rw.truncated),
+			)
+		})
+	}
+}
+
+// RedactJSON 返回一个脱敏函数，将 JSON 中指定名称的字段值替换为 "[REDACTED]"
+//
+// 字段名不区分大小写，嵌套对象和数组中的字段同样会被处理。
+// 如果内容不是合法的 JSON（包括超过 MaxBodySize 而被截断的 JSON），
+// 无法确定敏感字段的位置，返回 RedactedBody 占位符而不是原始内容；空内容原样返回。
+func RedactJSON(fields ...string) func(body []byte) []byte {
+	names := make(map[string]struct{}, len(fields))
+	for _, f := range fields {
+		names[strings.ToLower(f)] = struct{}{}
+	}
+
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			for k, child := range v {
+				if _, ok := names[strings.ToLower(k)]; ok {
+					v[k] = "[REDACTED]"
+					continue
+				}
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+
+	return func(body []byte) []byte {
+		if len(bytes.TrimSpace(body)) == 0 {
+			return body
+		}
+		var v any
+		if err := json.Unmarshal(body, &v); err != nil {
+			return []byte(RedactedBody)
+		}
+		walk(v)
+		out, err := json.Marshal(v)
+		if err != nil {
+			return []byte(RedactedBody)
+		}
+		return out
+	}
+}
+
+// RedactedBody RedactJSON 无法解析内容时记录的占位符
+const RedactedBody = "[REDACTED: unparseable body]"
+
+// bodyCapture 在写出响应的同时捕获响应体的前 limit 字节
+type bodyCapture struct {
+	Response
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (c *bodyCapture) Write(p []byte) (int, error) {
+	if room := c.limit - c.buf.Len(); room > 0 {
+		if len(p) > room {
+			c.buf.Write(p[:room])
+			c.truncated = true
+		} else {
+			c.buf.Write(p)
+		}
+	} else if len(p) > 0 {
+		c.truncated = true
+	}
+	return c.Response.Write(p)
+}
+
+// errReader 始终返回指定错误的 io.Reader
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,184 @@
+package h3
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestBodyLogger(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+func decodeBodyLog(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+	var entry map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("invalid log entry %q: %v", buf.String(), err)
+	}
+	return entry
+}
+
+func TestBodyLogger(t *testing.T) {
+	var logs bytes.Buffer
+
+	mux := NewMux()
+	mux.Use(BodyLogger(BodyLoggerConfig{Logger: newTestBodyLogger(&logs)}))
+
+	var received string
+	mux.HandleFunc("POST /echo", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	})
+
+	req := httptest.NewRequest("POST", "/echo", strings.NewReader("hello"))
+	rec := httptest.NewRecorder()
+
+	mux.ServeHTTP(rec, req)
+
+	if received != "hello" {
+		t.Errorf("handler received %q, want %q", received, "hello")
+	}
+
+	if rec.Body.String() != "created" {
+		t.Errorf("body = %q, want %q", rec.Body.String(), "created")
+	}
+
+	entry := decodeBodyLog(t, &logs)
+	if entry["request_body"] != "hello" {
+		t.Errorf("request_body = %v, want %q", entry["request_body"], "hello")
+	}
+	if entry["response_body"] != "created" {
+		t.Errorf("response_body = %v, want %q", entry["response_body"], "created")
+	}
+	if entry["status"] != float64(http.StatusCreated) {
+		t.Errorf("status = %v, want %d", entry["status"], http.StatusCreated)
+	}
+}
+
+func TestBodyLoggerTruncate(t *testing.T) {
+	var logs bytes.Buffer
+
+	mux := NewMux()
+	mux.Use(BodyLogger(BodyLoggerConfig{
+		Logger:      newTestBodyLogger(&logs),
+		MaxBodySize: 4,
+	}))
+
+	var received string
+	mux.HandleFunc("POST /echo", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		w.Write(body)
+	})
+
+	req := httptest.NewRequest("POST", "/echo", strings.NewReader("0123456789"))
+	rec := httptest.NewRecorder()
+
+	mux.ServeHTTP(rec, req)
+
+	// 处理器仍然可以读取完整的请求体
+	if received != "0123456789" {
+		t.Errorf("handler received %q, want %q", received, "0123456789")
+	}
+
+	if rec.Body.String() != "0123456789" {
+		t.Errorf("body = %q, want %q", rec.Body.String(), "0123456789")
+	}
+
+	entry := decodeBodyLog(t, &logs)
+	if entry["request_body"] != "0123" || entry["request_truncated"] != true {
+		t.Errorf("request_body = %v (truncated %v), want %q (truncated)", entry["request_body"], entry["request_truncated"], "0123")
+	}
+	if entry["response_body"] != "0123" || entry["response_truncated"] != true {
+		t.Errorf("response_body = %v (truncated %v), want %q (truncated)", entry["response_body"], entry["response_truncated"], "0123")
+	}
+}
+
+func TestBodyLoggerRedact(t *testing.T) {
+	var logs bytes.Buffer
+
+	mux := NewMux()
+	mux.Use(BodyLogger(BodyLoggerConfig{
+		Logger: newTestBodyLogger(&logs),
+		Redact: RedactJSON("password", "token"),
+	}))
+
+	var received string
+	mux.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		w.Write([]byte(`{"user":{"name":"alice","Token":"abc"}}`))
+	})
+
+	input := `{"name":"alice","password":"secret"}`
+	req := httptest.NewRequest("POST", "/login", strings.NewReader(input))
+	rec := httptest.NewRecorder()
+
+	mux.ServeHTTP(rec, req)
+
+	if received != input {
+		t.Errorf("handler received %q, want %q", received, input)
+	}
+
+	entry := decodeBodyLog(t, &logs)
+	if want := `{"name":"alice","password":"[REDACTED]"}`; entry["request_body"] != want {
+		t.Errorf("request_body = %v, want %q", entry["request_body"], want)
+	}
+	if want := `{"user":{"Token":"[REDACTED]","name":"alice"}}`; entry["response_body"] != want {
+		t.Errorf("response_body = %v, want %q", entry["response_body"], want)
+	}
+	if strings.Contains(logs.String(), "secret") || strings.Contains(logs.String(), "abc") {
+		t.Errorf("log contains sensitive data: %s", logs.String())
+	}
+}
+
+func TestRedactJSONInvalid(t *testing.T) {
+	redact := RedactJSON("password")
+
+	for _, input := range []string{`{"password":"sec`, `password=secret`} {
+		if got := string(redact([]byte(input))); got != RedactedBody {
+			t.Errorf("redact(%q) = %q, want %q", input, got, RedactedBody)
+		}
+	}
+	if got := redact(nil); len(got) != 0 {
+		t.Errorf("redact(nil) = %q, want empty", got)
+	}
+}
+
+func TestBodyLoggerRedactTruncated(t *testing.T) {
+	var logs bytes.Buffer
+
+	mux := NewMux()
+	mux.Use(BodyLogger(BodyLoggerConfig{
+		Logger:      newTestBodyLogger(&logs),
+		MaxBodySize: 32,
+		Redact:      RedactJSON("password", "token"),
+	}))
+	mux.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte(`{"token":"abc"`))
+	})
+
+	input := `{"name":"alice","note":"` + strings.Repeat("x", 64) + `","password":"secret"}`
+	req := httptest.NewRequest("POST", "/login", strings.NewReader(input))
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	entry := decodeBodyLog(t, &logs)
+	if entry["request_body"] != RedactedBody || entry["request_truncated"] != true {
+		t.Errorf("request_body = %v (truncated %v), want %q", entry["request_body"], entry["request_truncated"], RedactedBody)
+	}
+	if entry["response_body"] != RedactedBody {
+		t.Errorf("response_body = %v, want %q", entry["response_body"], RedactedBody)
+	}
+	if strings.Contains(logs.String(), "secret") || strings.Contains(logs.String(), "abc") || strings.Contains(logs.String(), "alice") {
+		t.Errorf("log contains sensitive data: %s", logs.String())
+	}
+}
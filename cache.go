@@ -0,0 +1,226 @@
+package h3
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachedResponse 缓存的响应
+type CachedResponse struct {
+	Status int         // HTTP 状态码
+	Header http.Header // 响应头
+	Body   []byte      // 响应体
+
+	// RequestHeader 响应的 Vary 头列出的请求头在保存时的值，
+	// 只有这些请求头都相同的请求才会命中缓存
+	RequestHeader http.Header
+
+	// RequestHash 产生该响应的请求体的摘要，
+	// Idempotency 用它拒绝以相同的键发送不同内容的请求
+	RequestHash string
+}
+
+// CacheStore 响应缓存的存储接口
+//
+// 实现可以基于内存、Redis 等，需要保证并发安全。
+// 存储出错时实现应当按未命中处理，而不是中断请求。
+type CacheStore interface {
+	// Get 返回缓存的响应，不存在或已过期时返回 false
+	Get(ctx context.Context, key string) (*CachedResponse, bool)
+
+	// Set 保存响应，ttl 之后过期
+	Set(ctx context.Context, key string, resp *CachedResponse, ttl time.Duration)
+}
+
+// cacheMaxBodySize Cache 保存的响应体的最大字节数，更大的响应不会被缓存
+const cacheMaxBodySize = 1 << 20
+
+// Cache 创建缓存响应的中间件
+//
+// 缓存命中时直接回放保存的状态码、响应头和响应体，不再调用处理器；
+// 未命中时调用处理器并捕获其响应，满足条件的响应会被保存 ttl 时长。
+//
+// 缓存按共享缓存的规则工作，保存的响应会回放给其他客户端:
+//   - 只缓存 GET 和 HEAD 请求
+//   - 只缓存 200 OK 响应，响应体超过 1MB 时不保存
+//   - 请求带有 Cache-Control: no-store 时跳过缓存
+//   - 响应带有 Cache-Control: no-store 或 private，或者设置了 Set-Cookie 时不保存
+//   - 响应带有 Vary 头时，只有所列请求头的值与保存时相同的请求才会命中，Vary: * 的响应不保存
+//
+// keyFunc 为 nil 时使用方法、主机和请求 URI 作为键，并且跳过带有
+// Authorization 或 Cookie 头的请求，避免一个用户的个性化响应被回放给其他用户。
+// 需要缓存已认证请求时，由 keyFunc 负责在键中区分用户。
+//
+// 参数:
+//   - ttl: 缓存有效期
+//   - store: 缓存存储
+//   - keyFunc: 计算缓存键的函数，为 nil 时使用方法、主机和请求 URI
+//
+// 示例:
+//
+//	store := h3.NewMemoryCacheStore()
+//	mux.Handle("GET /reports", h3.Cache(5*time.Second, store, nil)(reportsHandler))
+func Cache(ttl time.Duration, store CacheStore, keyFunc func(*http.Request) string) func(http.Handler) http.Handler {
+	shared := keyFunc == nil
+	if keyFunc == nil {
+		keyFunc = requestKey
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if (r.Method != http.MethodGet && r.Method != http.MethodHead) || noStore(r.Header) ||
+				(shared && hasCredentials(r)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := keyFunc(r)
+			if cached, ok := store.Get(r.Context(), key); ok && varyMatches(cached, r) {
+				replay(w, cached)
+				return
+			}
+
+			rw := &bodyCapture{Response: NewResponse(w), limit: cacheMaxBodySize}
+			next.ServeHTTP(rw, r)
+
+			if rw.Status() != http.StatusOK || rw.truncated || rw.Hijacked() || !cacheable(rw.Header()) {
+				return
+			}
+
+			store.Set(r.Context(), key, &CachedResponse{
+				Status:        rw.Status(),
+				Header:        rw.Header().Clone(),
+				Body:          rw.buf.Bytes(),
+				RequestHeader: varyHeader(rw.Header(), r),
+			}, ttl)
+		})
+	}
+}
+
+// requestKey 返回由请求方法、主机和请求 URI 组成的默认键
+func requestKey(r *http.Request) string {
+	return r.Method + " " + r.Host + r.URL.RequestURI()
+}
+
+// hasCredentials 判断请求是否携带了用户凭据
+func hasCredentials(r *http.Request) bool {
+	return r.Header.Get("Authorization") != "" || r.Header.Get("Cookie") != ""
+}
+
+// noStore 判断 Cache-Control 头是否包含 no-store 指令
+func noStore(h http.Header) bool {
+	return cacheControlHas(h, "no-store")
+}
+
+// cacheable 判断响应是否可以保存在共享缓存中并回放给其他客户端
+func cacheable(h http.Header) bool {
+	if noStore(h) || personalised(h) {
+		return false
+	}
+	return !headerContainsToken(h, "Vary", "*")
+}
+
+// personalised 判断响应是否只属于当前客户端（Cache-Control: private 或设置了 Cookie）
+func personalised(h http.Header) bool {
+	return cacheControlHas(h, "private") || len(h.Values("Set-Cookie")) > 0
+}
+
+// cacheControlHas 判断 Cache-Control 头是否包含指定指令，忽略指令的参数（例如 private="Set-Cookie"）
+func cacheControlHas(h http.Header, directive string) bool {
+	for _, v := range h.Values("Cache-Control") {
+		for d := range strings.SplitSeq(v, ",") {
+			name, _, _ := strings.Cut(d, "=")
+			if strings.EqualFold(strings.TrimSpace(name), directive) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// varyHeader 返回响应的 Vary 头列出的请求头的值
+func varyHeader(h http.Header, r *http.Request) http.Header {
+	var req http.Header
+	for _, v := range h.Values("Vary") {
+		for name := range strings.SplitSeq(v, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				if req == nil {
+					req = make(http.Header)
+				}
+				req[http.CanonicalHeaderKey(name)] = r.Header.Values(name)
+			}
+		}
+	}
+	return req
+}
+
+// varyMatches 判断请求的 Vary 请求头是否与缓存的响应保存时相同
+func varyMatches(cached *CachedResponse, r *http.Request) bool {
+	for name, values := range varyHeader(cached.Header, r) {
+		if !slices.Equal(values, cached.RequestHeader[name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// replay 回放缓存的响应
+func replay(w http.ResponseWriter, cached *CachedResponse) {
+	h := w.Header()
+	for k, v := range cached.Header {
+		h[k] = append([]string(nil), v...)
+	}
+	w.WriteHeader(cached.Status)
+	w.Write(cached.Body)
+}
+
+// NewMemoryCacheStore 创建基于内存的缓存存储
+//
+// 过期的条目在读取时惰性删除，适用于单实例部署和测试。
+func NewMemoryCacheStore() CacheStore {
+	return &memoryCacheStore{entries: make(map[string]memoryCacheEntry)}
+}
+
+// memoryCacheStore 基于内存的 CacheStore 实现
+type memoryCacheStore struct {
+	mu      sync.RWMutex
+	entries map[string]memoryCacheEntry
+}
+
+// memoryCacheEntry 内存缓存条目
+type memoryCacheEntry struct {
+	resp    *CachedResponse
+	expires time.Time
+}
+
+func (s *memoryCacheStore) Get(ctx context.Context, key string) (*CachedResponse, bool) {
+	s.mu.RLock()
+	entry, ok := s.entries[key]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	if time.Now().After(entry.expires) {
+		s.mu.Lock()
+		// 再次检查，避免删除其他请求刚写入的新条目
+		if e, ok := s.entries[key]; ok && e.expires == entry.expires {
+			delete(s.entries, key)
+		}
+		s.mu.Unlock()
+		return nil, false
+	}
+
+	return entry.resp, true
+}
+
+func (s *memoryCacheStore) Set(ctx context.Context, key string, resp *CachedResponse, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = memoryCacheEntry{resp: resp, expires: time.Now().Add(ttl)}
+}
@@ -0,0 +1,257 @@
+package h3
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	calls := 0
+	handler := Cache(time.Minute, NewMemoryCacheStore(), nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("X-Call", fmt.Sprint(calls))
+		w.Write([]byte("report"))
+	}))
+
+	for i := range 3 {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/reports?page=1", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Errorf("request %d status = %d, want %d", i, rec.Code, http.StatusOK)
+		}
+		if rec.Body.String() != "report" {
+			t.Errorf("request %d body = %q, want %q", i, rec.Body.String(), "report")
+		}
+		if got := rec.Header().Get("X-Call"); got != "1" {
+			t.Errorf("request %d X-Call = %q, want %q", i, got, "1")
+		}
+		if got := rec.Header().Get("Content-Type"); got != "text/plain" {
+			t.Errorf("request %d Content-Type = %q, want %q", i, got, "text/plain")
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+
+	// 不同的查询参数使用不同的缓存键
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/reports?page=2", nil))
+	if calls != 2 {
+		t.Errorf("handler called %d times, want 2", calls)
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	calls := 0
+	handler := Cache(50*time.Millisecond, NewMemoryCacheStore(), nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte("ok"))
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if calls != 1 {
+		t.Fatalf("handler called %d times before expiry, want 1", calls)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if calls != 2 {
+		t.Errorf("handler called %d times after expiry, want 2", calls)
+	}
+}
+
+func TestCacheNotCacheable(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		reqCC   string
+		respCC  string
+		status  int
+		wantHit bool
+	}{
+		{"post", "POST", "", "", http.StatusOK, false},
+		{"request no-store", "GET", "no-cache, no-store", "", http.StatusOK, false},
+		{"response no-store", "GET", "", "no-store", http.StatusOK, false},
+		{"error status", "GET", "", "", http.StatusInternalServerError, false},
+		{"head", "HEAD", "", "", http.StatusOK, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			handler := Cache(time.Minute, NewMemoryCacheStore(), nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				if tt.respCC != "" {
+					w.Header().Set("Cache-Control", tt.respCC)
+				}
+				w.WriteHeader(tt.status)
+			}))
+
+			for range 2 {
+				req := httptest.NewRequest(tt.method, "/", nil)
+				if tt.reqCC != "" {
+					req.Header.Set("Cache-Control", tt.reqCC)
+				}
+				handler.ServeHTTP(httptest.NewRecorder(), req)
+			}
+
+			want := 2
+			if tt.wantHit {
+				want = 1
+			}
+			if calls != want {
+				t.Errorf("handler called %d times, want %d", calls, want)
+			}
+		})
+	}
+}
+
+func TestCacheServeContent(t *testing.T) {
+	calls := 0
+	modtime := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	handler := Cache(time.Minute, NewMemoryCacheStore(), nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.ServeContent(w, r, "report.txt", modtime, strings.NewReader("report body"))
+	}))
+
+	for i := range 2 {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/report", nil))
+
+		if rec.Code != http.StatusOK || rec.Body.String() != "report body" {
+			t.Errorf("request %d = %d %q, want 200 %q", i, rec.Code, rec.Body.String(), "report body")
+		}
+	}
+	if calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+}
+
+func TestCacheServeFile(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "report.csv")
+	if err := os.WriteFile(name, []byte("a,b\n1,2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	handler := Cache(time.Minute, NewMemoryCacheStore(), nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		ServeFile(w, r, name)
+	}))
+
+	var etag string
+	for i := range 2 {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/report.csv", nil))
+
+		if rec.Code != http.StatusOK || rec.Body.String() != "a,b\n1,2\n" {
+			t.Errorf("request %d = %d %q, want 200 %q", i, rec.Code, rec.Body.String(), "a,b\n1,2\n")
+		}
+		if i == 0 {
+			etag = rec.Header().Get("ETag")
+		} else if got := rec.Header().Get("ETag"); got == "" || got != etag {
+			t.Errorf("replayed ETag = %q, want %q", got, etag)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("handler called %d times, want 1", calls)
+	}
+}
+
+func TestCacheShared(t *testing.T) {
+	tests := []struct {
+		name    string
+		reqHdr  map[string]string
+		respHdr map[string]string
+		size    int
+		keyFunc func(*http.Request) string
+		wantHit bool
+	}{
+		{"authorization", map[string]string{"Authorization": "Bearer alice"}, nil, 0, nil, false},
+		{"cookie", map[string]string{"Cookie": "session=alice"}, nil, 0, nil, false},
+		{"custom key with credentials", map[string]string{"Authorization": "Bearer alice"}, nil, 0,
+			func(r *http.Request) string { return r.Header.Get("Authorization") + " " + r.URL.Path }, true},
+		{"private", nil, map[string]string{"Cache-Control": "private, max-age=60"}, 0, nil, false},
+		{"private with fields", nil, map[string]string{"Cache-Control": `private="X-User"`}, 0, nil, false},
+		{"set-cookie", nil, map[string]string{"Set-Cookie": "session=new"}, 0, nil, false},
+		{"vary star", nil, map[string]string{"Vary": "*"}, 0, nil, false},
+		{"too large", nil, nil, cacheMaxBodySize + 1, nil, false},
+		{"public", nil, map[string]string{"Cache-Control": "public, max-age=60"}, 0, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			handler := Cache(time.Minute, NewMemoryCacheStore(), tt.keyFunc)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				for k, v := range tt.respHdr {
+					w.Header().Set(k, v)
+				}
+				w.Write([]byte(strings.Repeat("x", tt.size)))
+			}))
+
+			for range 2 {
+				req := httptest.NewRequest("GET", "/", nil)
+				for k, v := range tt.reqHdr {
+					req.Header.Set(k, v)
+				}
+				handler.ServeHTTP(httptest.NewRecorder(), req)
+			}
+
+			want := 2
+			if tt.wantHit {
+				want = 1
+			}
+			if calls != want {
+				t.Errorf("handler called %d times, want %d", calls, want)
+			}
+		})
+	}
+}
+
+func TestCacheVary(t *testing.T) {
+	calls := 0
+	handler := Cache(time.Minute, NewMemoryCacheStore(), nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Vary", "Accept-Language")
+		w.Write([]byte("lang=" + r.Header.Get("Accept-Language")))
+	}))
+
+	get := func(lang string) string {
+		req := httptest.NewRequest("GET", "/greeting", nil)
+		if lang != "" {
+			req.Header.Set("Accept-Language", lang)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Body.String()
+	}
+
+	for _, tt := range []struct {
+		lang, body string
+		calls      int
+	}{
+		{"en", "lang=en", 1},
+		{"en", "lang=en", 1},
+		{"fr", "lang=fr", 2},
+		{"fr", "lang=fr", 2},
+		{"", "lang=", 3},
+	} {
+		if got := get(tt.lang); got != tt.body {
+			t.Errorf("Accept-Language %q: body = %q, want %q", tt.lang, got, tt.body)
+		}
+		if calls != tt.calls {
+			t.Errorf("Accept-Language %q: handler called %d times, want %d", tt.lang, calls, tt.calls)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package h3
+
+import (
+	"errors"
+	"hash/fnv"
+	"net/http"
+)
+
+// Canary 返回按比例将流量分配到金丝雀处理器的处理器
+//
+// 分流基于 keyFunc 返回的键的哈希值，因此同一个键的所有请求总是落在同一个处理器上，
+// 不同的键之间按 percentage 的比例分配。键为空的请求交给 stable。
+//
+// keyFunc 为 nil 时，依次使用名为 "session" 的 Cookie 和 RequestIDFromContext 作为键。
+// 请求 ID 在每个请求上都不同，只能提供按请求的分流；需要按用户稳定分流时，
+// 应当提供基于会话或用户 ID 的 keyFunc。
+//
+// 参数:
+//   - percentage: 分配到 canary 的流量百分比，取值范围为 0 到 100，超出范围时触发 panic
+//   - canary: 金丝雀处理器
+//   - stable: 稳定版本的处理器
+//   - keyFunc: 返回分流键的函数
+//
+// 示例:
+//
+//	mux.Handle("/api/", h3.Canary(5, canaryAPI, stableAPI, func(r *http.Request) string {
+//		return r.Header.Get("X-User-ID")
+//	}))
+func Canary(percentage float64, canary, stable http.Handler, keyFunc func(*http.Request) string) http.Handler {
+	if !(percentage >= 0 && percentage <= 100) {
+		panic(errors.New("h3: canary percentage must be between 0 and 100"))
+	}
+	if keyFunc == nil {
+		keyFunc = canaryKey
+	}
+
+	// 以万分之一为粒度划分桶
+	threshold := uint32(percentage * 100)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := keyFunc(r)
+		if key == "" {
+			stable.ServeHTTP(w, r)
+			return
+		}
+
+		h := fnv.New32a()
+		h.Write([]byte(key))
+		if h.Sum32()%10000 < threshold {
+			canary.ServeHTTP(w, r)
+			return
+		}
+		stable.ServeHTTP(w, r)
+	})
+}
+
+// canaryKey 默认的分流键：会话 Cookie，其次是请求 ID
+func canaryKey(r *http.Request) string {
+	if c, err := r.Cookie("session"); err == nil && c.Value != "" {
+		return c.Value
+	}
+	return RequestIDFromContext(r.Context())
+}
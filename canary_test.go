@@ -0,0 +1,108 @@
+package h3
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// canaryHandlers 返回写出 "canary" 和 "stable" 的两个处理器
+func canaryHandlers() (canary, stable http.Handler) {
+	canary = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("canary")) })
+	stable = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("stable")) })
+	return
+}
+
+func TestCanarySplit(t *testing.T) {
+	canary, stable := canaryHandlers()
+	h := Canary(20, canary, stable, func(r *http.Request) string {
+		return r.Header.Get("X-User-ID")
+	})
+
+	const n = 20000
+	hits := 0
+	for i := range n {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-User-ID", "user-"+strconv.Itoa(i))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Body.String() == "canary" {
+			hits++
+		}
+	}
+
+	ratio := float64(hits) / n
+	if math.Abs(ratio-0.20) > 0.02 {
+		t.Errorf("canary ratio = %.3f, want about 0.20", ratio)
+	}
+}
+
+func TestCanaryStablePerKey(t *testing.T) {
+	canary, stable := canaryHandlers()
+	h := Canary(50, canary, stable, nil)
+
+	for i := range 50 {
+		session := "session-" + strconv.Itoa(i)
+
+		var first string
+		for j := range 10 {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.AddCookie(&http.Cookie{Name: "session", Value: session})
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if j == 0 {
+				first = rec.Body.String()
+			} else if rec.Body.String() != first {
+				t.Fatalf("session %q routed to %q, previously %q", session, rec.Body.String(), first)
+			}
+		}
+	}
+}
+
+func TestCanaryBounds(t *testing.T) {
+	canary, stable := canaryHandlers()
+
+	tests := []struct {
+		percentage float64
+		want       string
+	}{
+		{0, "stable"},
+		{100, "canary"},
+	}
+
+	for _, tt := range tests {
+		h := Canary(tt.percentage, canary, stable, func(r *http.Request) string { return r.URL.Path })
+		for i := range 100 {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest("GET", "/"+strconv.Itoa(i), nil))
+			if rec.Body.String() != tt.want {
+				t.Fatalf("percentage %v: got %q, want %q", tt.percentage, rec.Body.String(), tt.want)
+			}
+		}
+	}
+
+	// 没有键的请求交给 stable
+	h := Canary(100, canary, stable, nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Body.String() != "stable" {
+		t.Errorf("request without key routed to %q, want %q", rec.Body.String(), "stable")
+	}
+}
+
+func TestCanaryInvalidPercentage(t *testing.T) {
+	canary, stable := canaryHandlers()
+	for _, p := range []float64{-1, 101, math.NaN()} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic for percentage %v", p)
+				}
+			}()
+			Canary(p, canary, stable, nil)
+		}()
+	}
+}
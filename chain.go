@@ -0,0 +1,32 @@
+package h3
+
+import (
+	"net/http"
+	"slices"
+)
+
+// Chain 将多个中间件组合为一个中间件
+//
+// 组合后的执行顺序与依次调用 Use 相同，形成洋葱模型：
+// 第一个中间件在最外层，最后一个中间件紧邻处理器。
+// nil 中间件会被忽略，不传入任何中间件时返回的中间件原样返回处理器。
+//
+// 示例:
+//
+//	common := h3.Chain(h3.RequestID(), h3.Recoverer())
+//	mux.Use(common)
+//	// 等价于
+//	mux.Use(h3.RequestID())
+//	mux.Use(h3.Recoverer())
+func Chain(mw ...func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	mws := slices.Clone(mw)
+
+	return func(next http.Handler) http.Handler {
+		for i := len(mws) - 1; i >= 0; i-- {
+			if mws[i] != nil {
+				next = mws[i](next)
+			}
+		}
+		return next
+	}
+}
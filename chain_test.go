@@ -0,0 +1,77 @@
+package h3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+// tracer 返回在调用前后记录名称的中间件
+func tracer(trace *[]string, name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*trace = append(*trace, name+" before")
+			next.ServeHTTP(w, r)
+			*trace = append(*trace, name+" after")
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	var trace []string
+	h := Chain(tracer(&trace, "a"), tracer(&trace, "b"), nil, tracer(&trace, "c"))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			trace = append(trace, "handler")
+		}),
+	)
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	want := []string{"a before", "b before", "c before", "handler", "c after", "b after", "a after"}
+	if !slices.Equal(trace, want) {
+		t.Errorf("trace = %v, want %v", trace, want)
+	}
+}
+
+func TestChainEmpty(t *testing.T) {
+	called := false
+	h := Chain()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if !called {
+		t.Error("handler should be called by an empty chain")
+	}
+}
+
+func TestChainEquivalentToUse(t *testing.T) {
+	run := func(setup func(m Mux, trace *[]string)) []string {
+		var trace []string
+		m := NewMux()
+		setup(m, &trace)
+		m.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+			trace = append(trace, "handler")
+		})
+		m.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		return trace
+	}
+
+	sequential := run(func(m Mux, trace *[]string) {
+		m.Use(tracer(trace, "outer"))
+		m.Use(tracer(trace, "a"))
+		m.Use(tracer(trace, "b"))
+		m.Use(tracer(trace, "inner"))
+	})
+	chained := run(func(m Mux, trace *[]string) {
+		m.Use(tracer(trace, "outer"))
+		m.Use(Chain(tracer(trace, "a"), tracer(trace, "b")))
+		m.Use(tracer(trace, "inner"))
+	})
+
+	if !slices.Equal(sequential, chained) {
+		t.Errorf("chained trace = %v, want %v", chained, sequential)
+	}
+}
@@ -0,0 +1,100 @@
+package h3
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// CleanPathConfig 路径规范化中间件的配置
+type CleanPathConfig struct {
+	// RejectEncodedSlash 如果为 true，拒绝路径中包含编码斜杠（%2F，不区分大小写）的请求，
+	// 返回 400 Bad Request。编码斜杠在解码后会改变路径的层级结构，
+	// 可能被用来绕过基于前缀的路由或鉴权检查。
+	RejectEncodedSlash bool
+}
+
+// CleanPath 创建规范化请求路径的中间件
+//
+// 中间件在路由匹配之前使用 path.Clean 规范化 r.URL.Path：
+// 合并重复的斜杠，解析 "." 和 ".." 路径段。原路径的尾部斜杠会被保留，
+// 以免改变 "/static/" 等子树模式的匹配结果。
+//
+// 规范化只作用于路径的结构，不会改变路径段的内容，
+// 因此 {path...} 通配符仍然可以匹配包含斜杠的剩余路径。
+// 路径包含编码斜杠时，编码斜杠不被视为路径分隔符；
+// 如果解码后的路径包含 "." 或 ".." 路径段或重复的斜杠，返回 400 Bad Request。
+//
+// 示例:
+//
+//	mux.Use(h3.CleanPath(h3.CleanPathConfig{RejectEncodedSlash: true}))
+//	// "//a/../b" 按 "/b" 匹配路由
+//	// "/files/a%2Fb" 返回 400
+func CleanPath(config ...CleanPathConfig) func(http.Handler) http.Handler {
+	var cfg CleanPathConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if cfg.RejectEncodedSlash && strings.Contains(strings.ToLower(r.URL.RawPath), "%2f") {
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+
+			var p string
+			rp := r.URL.RawPath
+			if strings.Contains(strings.ToLower(rp), "%2f") {
+				// 编码斜杠是路径段的内容，只按编码路径中真实的斜杠规范化，并保留编码路径；
+				// 解码后出现的 "." 或 ".." 路径段无法安全地解析，直接拒绝，
+				// 避免 "/a%2F..%2Fadmin" 被当作 "/admin" 处理
+				rp = cleanPath(rp)
+				unescaped, err := url.PathUnescape(rp)
+				if err != nil || cleanPath(unescaped) != unescaped {
+					http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+					return
+				}
+				p = unescaped
+			} else {
+				p = cleanPath(r.URL.Path)
+				if rp != "" {
+					rp = cleanPath(rp)
+					// 规范化后的编码路径与解码路径不一致时，丢弃编码路径
+					if unescaped, err := url.PathUnescape(rp); err != nil || unescaped != p {
+						rp = ""
+					}
+				}
+			}
+
+			if p != r.URL.Path || rp != r.URL.RawPath {
+				r2 := new(http.Request)
+				*r2 = *r
+				r2.URL = new(url.URL)
+				*r2.URL = *r.URL
+				r2.URL.Path = p
+				r2.URL.RawPath = rp
+				r = r2
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// cleanPath 返回规范化后的路径，保留尾部斜杠
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	if p[0] != '/' {
+		p = "/" + p
+	}
+
+	np := path.Clean(p)
+	if p[len(p)-1] == '/' && np != "/" {
+		np += "/"
+	}
+	return np
+}
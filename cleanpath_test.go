@@ -0,0 +1,123 @@
+package h3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCleanPath(t *testing.T) {
+	mux := NewMux()
+	mux.Use(CleanPath())
+
+	mux.HandleFunc("GET /b", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("b"))
+	})
+	mux.HandleFunc("GET /static/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("static:" + r.URL.Path))
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"//a/../b", http.StatusOK, "b"},
+		{"/./b", http.StatusOK, "b"},
+		{"/static//css/../", http.StatusOK, "static:/static/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.URL.Path = tt.path
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+
+			if rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+		})
+	}
+}
+
+func TestCleanPathEncodedSlash(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.PathValue("path")))
+	}
+
+	tests := []struct {
+		name   string
+		reject bool
+		path   string
+		status int
+		body   string
+	}{
+		{"rejected lower", true, "/files/a%2fb", http.StatusBadRequest, "Bad Request\n"},
+		{"rejected upper", true, "/files/..%2F..%2Fadmin", http.StatusBadRequest, "Bad Request\n"},
+		{"allowed", false, "/files/a%2Fb", http.StatusOK, "a/b"},
+		{"wildcard", true, "/files/css/app.css", http.StatusOK, "css/app.css"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := NewMux()
+			mux.Use(CleanPath(CleanPathConfig{RejectEncodedSlash: tt.reject}))
+			mux.HandleFunc("GET /files/{path...}", handler)
+
+			req := httptest.NewRequest("GET", tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+
+			if rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+		})
+	}
+}
+
+func TestCleanPathEncodedTraversal(t *testing.T) {
+	mux := NewMux()
+	mux.Use(CleanPath())
+	mux.HandleFunc("GET /admin", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("admin"))
+	})
+	mux.HandleFunc("GET /files/{path...}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("files:" + r.PathValue("path")))
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/a%2F..%2Fadmin", http.StatusBadRequest, "Bad Request\n"},
+		{"/files/x%2F..%2F..%2Fadmin", http.StatusBadRequest, "Bad Request\n"},
+		{"/files/a%2F%2Fb", http.StatusBadRequest, "Bad Request\n"},
+		{"//files/./a%2Fb", http.StatusOK, "files:a/b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package h3
+
+import (
+	"crypto/x509"
+	"net/http"
+	"slices"
+)
+
+// ClientCertConfig RequireClientCert 中间件的配置
+type ClientCertConfig struct {
+	// Subjects 允许的客户端证书主题通用名（Subject CommonName），为空时不限制
+	Subjects []string
+
+	// Issuers 允许的签发者通用名（Issuer CommonName），为空时不限制
+	Issuers []string
+
+	// AllowUnverified 为 true 时接受未经 TLS 层验证的证书。
+	// 默认只接受 r.TLS.VerifiedChains 非空的证书，即服务器的 tls.Config.ClientAuth
+	// 为 VerifyClientCertIfGiven 或 RequireAndVerifyClientCert 并验证通过；
+	// 使用 RequestClientCert 时客户端可以提供任意自签名证书。
+	AllowUnverified bool
+}
+
+// RequireClientCert 创建要求客户端证书的中间件
+//
+// 适用于只有部分路由要求 mTLS 的场景：服务器的 tls.Config.ClientAuth 设置为
+// VerifyClientCertIfGiven，再通过 HandleWith 或 Chain 为需要的路由添加此中间件。
+//
+// 检查 r.TLS.PeerCertificates 中的叶子证书，错误响应通过 RenderJSONError 写出:
+//   - 403 Forbidden（client_certificate_required）: 明文 HTTP 请求，或客户端没有提供证书
+//   - 403 Forbidden（client_certificate_rejected）: 证书未经验证，或主题、签发者不在允许列表中
+//
+// 示例:
+//
+//	mux.HandleWith("POST /internal/deploy", deploy, h3.RequireClientCert(h3.ClientCertConfig{
+//		Subjects: []string{"ci.example.com"},
+//		Issuers:  []string{"Example Internal CA"},
+//	}))
+func RequireClientCert(config ...ClientCertConfig) func(http.Handler) http.Handler {
+	var cfg ClientCertConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+				RenderJSONError(w, r, &StatusError{
+					Status:  http.StatusForbidden,
+					Code:    "client_certificate_required",
+					Message: "client certificate required",
+				})
+				return
+			}
+
+			if msg := checkClientCert(cfg, r.TLS.PeerCertificates[0], len(r.TLS.VerifiedChains) > 0); msg != "" {
+				RenderJSONError(w, r, &StatusError{
+					Status:  http.StatusForbidden,
+					Code:    "client_certificate_rejected",
+					Message: msg,
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// checkClientCert 检查客户端证书是否满足配置，返回拒绝的原因，满足时返回空字符串
+func checkClientCert(cfg ClientCertConfig, cert *x509.Certificate, verified bool) string {
+	switch {
+	case !verified && !cfg.AllowUnverified:
+		return "client certificate not verified"
+	case len(cfg.Subjects) > 0 && !slices.Contains(cfg.Subjects, cert.Subject.CommonName):
+		return "client certificate subject not allowed"
+	case len(cfg.Issuers) > 0 && !slices.Contains(cfg.Issuers, cert.Issuer.CommonName):
+		return "client certificate issuer not allowed"
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package h3
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// clientCertState 返回携带指定客户端证书的 TLS 连接状态
+func clientCertState(subject, issuer string, verified bool) *tls.ConnectionState {
+	cert := &x509.Certificate{
+		Subject: pkix.Name{CommonName: subject},
+		Issuer:  pkix.Name{CommonName: issuer},
+	}
+	state := &tls.ConnectionState{HandshakeComplete: true, PeerCertificates: []*x509.Certificate{cert}}
+	if verified {
+		state.VerifiedChains = [][]*x509.Certificate{{cert}}
+	}
+	return state
+}
+
+func TestRequireClientCert(t *testing.T) {
+	mw := RequireClientCert(ClientCertConfig{
+		Subjects: []string{"ci.example.com"},
+		Issuers:  []string{"Example CA"},
+	})
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("deployed"))
+	}))
+
+	tests := []struct {
+		name   string
+		tls    *tls.ConnectionState
+		status int
+		code   string
+	}{
+		{"valid", clientCertState("ci.example.com", "Example CA", true), http.StatusOK, ""},
+		{"plaintext", nil, http.StatusForbidden, "client_certificate_required"},
+		{"missing cert", &tls.ConnectionState{HandshakeComplete: true}, http.StatusForbidden, "client_certificate_required"},
+		{"subject mismatch", clientCertState("laptop.example.com", "Example CA", true), http.StatusForbidden, "client_certificate_rejected"},
+		{"issuer mismatch", clientCertState("ci.example.com", "Other CA", true), http.StatusForbidden, "client_certificate_rejected"},
+		{"unverified", clientCertState("ci.example.com", "Example CA", false), http.StatusForbidden, "client_certificate_rejected"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/deploy", nil)
+			req.TLS = tt.tls
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.code == "" {
+				if rec.Body.String() != "deployed" {
+					t.Errorf("body = %q, want %q", rec.Body, "deployed")
+				}
+				return
+			}
+			var env errorEnvelope
+			if err := json.Unmarshal(rec.Body.Bytes(), &env); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if env.Code != tt.code {
+				t.Errorf("code = %q, want %q", env.Code, tt.code)
+			}
+		})
+	}
+}
+
+func TestRequireClientCertPerRoute(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("GET /public", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleWith("GET /internal", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		RequireClientCert(ClientCertConfig{AllowUnverified: true}))
+
+	for path, want := range map[string]int{"/public": http.StatusOK, "/internal": http.StatusForbidden} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.TLS = &tls.ConnectionState{HandshakeComplete: true}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("GET %s without cert = %d, want %d", path, rec.Code, want)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/internal", nil)
+	req.TLS = clientCertState("anyone", "self-signed", false)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("GET /internal with unverified cert and AllowUnverified = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
@@ -14,6 +14,17 @@ func NewComponent(prefix string) Component {
 	}
 }
 
+// ComponentFromMux 使用已有的路由器创建应用组件
+//
+// 与 NewComponent 不同，组件直接使用传入的路由器，
+// 路由器上已经注册的路由和中间件都会保留。
+func ComponentFromMux(prefix string, m Mux) Component {
+	return &component{
+		mux:    m,
+		prefix: prefix,
+	}
+}
+
 // component 应用组件的内部实现
 type component struct {
 	mux    Mux    // 组件路由器
@@ -196,3 +196,46 @@ func TestMultipleComponents(t *testing.T) {
 		})
 	}
 }
+
+func TestComponentFromMux(t *testing.T) {
+	m := NewMux()
+	m.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Prebuilt", "true")
+			next.ServeHTTP(w, r)
+		})
+	})
+	m.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("user " + r.PathValue("id")))
+	})
+
+	c := ComponentFromMux("/api", m)
+
+	if c.Mux() != m {
+		t.Error("Mux() should return the wrapped mux")
+	}
+
+	if c.Prefix() != "/api" {
+		t.Errorf("Prefix() = %q, want %q", c.Prefix(), "/api")
+	}
+
+	app := New(NewMux())
+	app.Register(c)
+
+	req := httptest.NewRequest("GET", "/api/users/42", nil)
+	rec := httptest.NewRecorder()
+
+	app.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	if rec.Body.String() != "user 42" {
+		t.Errorf("body = %q, want %q", rec.Body.String(), "user 42")
+	}
+
+	if got := rec.Header().Get("X-Prebuilt"); got != "true" {
+		t.Errorf("X-Prebuilt = %q, want %q", got, "true")
+	}
+}
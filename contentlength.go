@@ -0,0 +1,84 @@
+package h3
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// ContentLengthConfig RequireContentLength 中间件的配置
+type ContentLengthConfig struct {
+	// SpoolChunked 为 true 时，没有 Content-Length 的请求（例如分块传输）
+	// 会被完整读入内存，读取的字节数不超过 max，之后设置 r.ContentLength 并交给处理器；
+	// 超出 max 时返回 413。为 false 时这类请求返回 411。
+	SpoolChunked bool
+}
+
+// RequireContentLength 创建要求请求声明 Content-Length 的中间件
+//
+// 中间件在处理器读取请求体之前检查请求:
+//   - 411 Length Required: 请求没有 Content-Length（例如分块传输）
+//   - 413 Request Entity Too Large: Content-Length 超过 max
+//
+// 通过检查的请求体仍然被 http.MaxBytesReader 限制为 max 字节。
+// Content-Length 为 0 的请求（例如没有请求体的 GET）视为合法。
+//
+// 检查只使用请求头，不读取请求体。对于携带 Expect: 100-continue 的上传，
+// http.Server 只在处理器第一次读取请求体时才发送 100 Continue，
+// 因此被拒绝的客户端直接收到 411 或 413，不会发送请求体。
+// max 必须为正数，否则触发 panic。
+//
+// 参数:
+//   - max: 请求体的最大字节数
+//   - config: 可选配置
+//
+// 示例:
+//
+//	mux.Handle("PUT /objects/{key}", h3.RequireContentLength(100<<20)(putObject))
+func RequireContentLength(max int64, config ...ContentLengthConfig) func(http.Handler) http.Handler {
+	if max <= 0 {
+		panic(errors.New("h3: RequireContentLength max must be positive"))
+	}
+
+	var cfg ContentLengthConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength < 0 {
+				if !cfg.SpoolChunked {
+					http.Error(w, http.StatusText(http.StatusLengthRequired), http.StatusLengthRequired)
+					return
+				}
+
+				body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, max))
+				if err != nil {
+					var mbe *http.MaxBytesError
+					if errors.As(err, &mbe) {
+						http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+						return
+					}
+					http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+					return
+				}
+
+				r.Body = io.NopCloser(bytes.NewReader(body))
+				r.ContentLength = int64(len(body))
+				r.TransferEncoding = nil
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if r.ContentLength > max {
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, max)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package h3
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// echoLength 返回请求体长度和 r.ContentLength 的处理器
+func echoLength(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("reading body: %v", err)
+		}
+		w.Write([]byte(strconv.Itoa(len(body)) + "/" + strconv.FormatInt(r.ContentLength, 10)))
+	})
+}
+
+func TestRequireContentLength(t *testing.T) {
+	h := RequireContentLength(10)(echoLength(t))
+
+	tests := []struct {
+		name   string
+		body   string
+		length int64
+		status int
+		want   string
+	}{
+		{"valid", "hello", 5, http.StatusOK, "5/5"},
+		{"at limit", "0123456789", 10, http.StatusOK, "10/10"},
+		{"empty", "", 0, http.StatusOK, "0/0"},
+		{"missing", "hello", -1, http.StatusLengthRequired, ""},
+		{"over limit", "0123456789x", 11, http.StatusRequestEntityTooLarge, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			req.ContentLength = tt.length
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.want != "" && rec.Body.String() != tt.want {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireContentLengthSpoolChunked(t *testing.T) {
+	h := RequireContentLength(10, ContentLengthConfig{SpoolChunked: true})(echoLength(t))
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader("hello"))
+	req.ContentLength = -1
+	req.TransferEncoding = []string{"chunked"}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "5/5" {
+		t.Errorf("response = %d %q, want %d %q", rec.Code, rec.Body.String(), http.StatusOK, "5/5")
+	}
+
+	req = httptest.NewRequest("POST", "/", strings.NewReader("0123456789x"))
+	req.ContentLength = -1
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+}
+
+func TestRequireContentLengthChunkedOverHTTP(t *testing.T) {
+	srv := httptest.NewServer(RequireContentLength(1 << 10)(echoLength(t)))
+	defer srv.Close()
+
+	// io.Pipe 没有已知长度，客户端使用分块传输
+	pr, pw := io.Pipe()
+	go func() {
+		pw.Write([]byte("chunked"))
+		pw.Close()
+	}()
+
+	resp, err := http.Post(srv.URL, "text/plain", pr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusLengthRequired {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusLengthRequired)
+	}
+}
+
+func TestRequireContentLengthInvalidMax(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for non-positive max")
+		}
+	}()
+	RequireContentLength(0)
+}
+
+func TestExpectContinueRejectedBeforeBody(t *testing.T) {
+	var bodyRead atomic.Bool
+	auth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "Bearer ok" {
+				r = r.WithContext(WithPrincipal(r.Context(), scopedUser{"upload"}))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	mux := NewMux()
+	mux.Use(auth)
+	mux.Handle("PUT /upload", Chain(RequireScope("upload"), RequireContentLength(1<<20))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			bodyRead.Store(true)
+			n, _ := io.Copy(io.Discard, r.Body)
+			w.Write([]byte(strconv.FormatInt(n, 10)))
+		}),
+	))
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	tests := []struct {
+		name   string
+		auth   string
+		length int
+		status int
+	}{
+		{"unauthorized", "", 1024, http.StatusUnauthorized},
+		{"too large", "Bearer ok", 2 << 20, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+			// 只发送请求头，等待服务器的响应再决定是否发送请求体
+			req := "PUT /upload HTTP/1.1\r\nHost: example.com\r\nExpect: 100-continue\r\n" +
+				"Content-Length: " + strconv.Itoa(tt.length) + "\r\n"
+			if tt.auth != "" {
+				req += "Authorization: " + tt.auth + "\r\n"
+			}
+			conn.Write([]byte(req + "\r\n"))
+
+			resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+			if err != nil {
+				t.Fatalf("ReadResponse failed: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d (no 100 Continue)", resp.StatusCode, tt.status)
+			}
+			if bodyRead.Load() {
+				t.Error("handler should not read the body of a rejected upload")
+			}
+		})
+	}
+
+	// 通过检查的上传收到 100 Continue 后发送请求体
+	req, _ := http.NewRequest("PUT", srv.URL+"/upload", strings.NewReader("payload"))
+	req.Header.Set("Expect", "100-continue")
+	req.Header.Set("Authorization", "Bearer ok")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "7" {
+		t.Errorf("authorized upload = %d %q, want %d %q", resp.StatusCode, body, http.StatusOK, "7")
+	}
+}
@@ -0,0 +1,214 @@
+package h3
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig 跨域资源共享（CORS）策略
+//
+// 作为路由覆盖（Routes 的值）使用时，零值字段继承全局策略，
+// 非零字段覆盖全局策略中的对应字段。
+type CORSConfig struct {
+	// AllowOrigins 允许的来源，"*" 表示允许所有来源
+	AllowOrigins []string
+
+	// AllowMethods 允许的请求方法，默认为 GET、HEAD 和 POST
+	AllowMethods []string
+
+	// AllowHeaders 允许的请求头，"*" 表示允许所有请求头
+	AllowHeaders []string
+
+	// ExposeHeaders 允许浏览器脚本读取的响应头
+	ExposeHeaders []string
+
+	// AllowCredentials 是否允许携带 Cookie 等凭据，nil 表示不允许（路由覆盖中表示继承）。
+	// 使用指针以便路由覆盖可以显式关闭全局策略开启的凭据。
+	// 启用时 AllowOrigins 不能包含 "*"，否则任何网站都可以读取携带凭据的响应，CORS 会触发 panic。
+	AllowCredentials *bool
+
+	// MaxAge 浏览器缓存预检结果的时间，通过 Access-Control-Max-Age 返回。
+	// 为零时不发送该头，由浏览器使用默认值。
+	MaxAge time.Duration
+
+	// Routes 按路由模式覆盖全局策略，模式语法与 http.ServeMux 相同，
+	// 例如 "/admin/{path...}" 或 "DELETE /users/{id}"。
+	// 预检请求按 Access-Control-Request-Method 声明的方法匹配。
+	// 只在全局策略中生效，路由覆盖中的 Routes 会被忽略；无效的模式会触发 panic。
+	Routes map[string]CORSConfig
+}
+
+// merge 返回以 c 为基础、被 o 的非零字段覆盖后的策略
+func (c CORSConfig) merge(o CORSConfig) CORSConfig {
+	if o.AllowOrigins != nil {
+		c.AllowOrigins = o.AllowOrigins
+	}
+	if o.AllowMethods != nil {
+		c.AllowMethods = o.AllowMethods
+	}
+	if o.AllowHeaders != nil {
+		c.AllowHeaders = o.AllowHeaders
+	}
+	if o.ExposeHeaders != nil {
+		c.ExposeHeaders = o.ExposeHeaders
+	}
+	if o.AllowCredentials != nil {
+		c.AllowCredentials = o.AllowCredentials
+	}
+	if o.MaxAge != 0 {
+		c.MaxAge = o.MaxAge
+	}
+	c.Routes = nil
+	return c
+}
+
+// credentials 返回策略是否允许携带凭据
+func (c CORSConfig) credentials() bool {
+	return c.AllowCredentials != nil && *c.AllowCredentials
+}
+
+// validate 检查策略，允许凭据的同时允许所有来源时 panic
+func (c CORSConfig) validate() {
+	if c.credentials() && slices.Contains(c.AllowOrigins, "*") {
+		panic(errors.New(`h3: CORS AllowCredentials cannot be used with AllowOrigins "*"`))
+	}
+}
+
+// CORS 创建处理跨域资源共享的中间件
+//
+// 中间件应当作为全局中间件使用，以便在路由匹配之前响应预检请求：
+// 只注册了 GET 等方法的路由不会收到 OPTIONS 预检请求。
+// 需要不同策略的路由通过 Routes 声明，路由策略与全局策略合并，冲突时路由优先。
+//
+// 预检请求（带有 Origin 和 Access-Control-Request-Method 的 OPTIONS 请求）
+// 直接返回 204；来源、方法或请求头不被允许时返回 403。
+// 其他跨域请求在响应中添加 CORS 头后交给下一个处理器。
+//
+// 全局策略或任一路由策略在合并后同时允许凭据和所有来源（"*"）时，会触发 panic。
+//
+// 示例:
+//
+//	mux.Use(h3.CORS(h3.CORSConfig{
+//		AllowOrigins: []string{"*"},
+//		AllowMethods: []string{"GET", "POST", "PUT", "DELETE"},
+//		MaxAge:       10 * time.Minute,
+//		Routes: map[string]h3.CORSConfig{
+//			"/admin/{path...}": {AllowOrigins: []string{"https://admin.example.com"}},
+//		},
+//	}))
+func CORS(cfg CORSConfig) func(http.Handler) http.Handler {
+	if cfg.AllowMethods == nil {
+		cfg.AllowMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	}
+
+	// 使用独立的 ServeMux 匹配路由覆盖
+	routes := http.NewServeMux()
+	policies := make(map[string]CORSConfig, len(cfg.Routes))
+	for pattern, override := range cfg.Routes {
+		routes.Handle(pattern, http.NotFoundHandler())
+		policies[pattern] = cfg.merge(override)
+		policies[pattern].validate()
+	}
+	global := cfg.merge(CORSConfig{})
+	global.validate()
+
+	policy := func(r *http.Request, method string) CORSConfig {
+		if len(policies) == 0 {
+			return global
+		}
+		r2 := r.Clone(r.Context())
+		r2.Method = method
+		if _, pattern := routes.Handler(r2); pattern != "" {
+			return policies[pattern]
+		}
+		return global
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+
+			reqMethod := r.Header.Get("Access-Control-Request-Method")
+			if r.Method == http.MethodOptions && reqMethod != "" {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+
+				p := policy(r, reqMethod)
+				reqHeaders := r.Header.Get("Access-Control-Request-Headers")
+				if !corsOriginAllowed(p, origin) || !slices.Contains(p.AllowMethods, reqMethod) || !corsHeadersAllowed(p, reqHeaders) {
+					http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+					return
+				}
+
+				corsOrigin(h, p, origin)
+				h.Set("Access-Control-Allow-Methods", strings.Join(p.AllowMethods, ", "))
+				if reqHeaders != "" {
+					h.Set("Access-Control-Allow-Headers", reqHeaders)
+				}
+				if p.MaxAge > 0 {
+					h.Set("Access-Control-Max-Age", strconv.Itoa(int(p.MaxAge/time.Second)))
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			p := policy(r, r.Method)
+			if corsOriginAllowed(p, origin) {
+				corsOrigin(h, p, origin)
+				if len(p.ExposeHeaders) > 0 {
+					h.Set("Access-Control-Expose-Headers", strings.Join(p.ExposeHeaders, ", "))
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// corsOrigin 设置 Access-Control-Allow-Origin 和 Access-Control-Allow-Credentials
+func corsOrigin(h http.Header, p CORSConfig, origin string) {
+	if p.credentials() {
+		// validate 保证此时 AllowOrigins 不包含 "*"，来源已经在允许列表中
+		h.Set("Access-Control-Allow-Origin", origin)
+		h.Set("Access-Control-Allow-Credentials", "true")
+		return
+	}
+	if slices.Contains(p.AllowOrigins, "*") {
+		h.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	h.Set("Access-Control-Allow-Origin", origin)
+}
+
+// corsOriginAllowed 返回策略是否允许指定来源
+func corsOriginAllowed(p CORSConfig, origin string) bool {
+	return slices.Contains(p.AllowOrigins, "*") || slices.Contains(p.AllowOrigins, origin)
+}
+
+// corsHeadersAllowed 返回策略是否允许预检请求声明的所有请求头
+func corsHeadersAllowed(p CORSConfig, requested string) bool {
+	if slices.Contains(p.AllowHeaders, "*") {
+		return true
+	}
+	for name := range strings.SplitSeq(requested, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !slices.ContainsFunc(p.AllowHeaders, func(h string) bool { return strings.EqualFold(h, name) }) {
+			return false
+		}
+	}
+	return true
+}
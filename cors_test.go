@@ -0,0 +1,180 @@
+package h3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newCORSMux() Mux {
+	credentials := true
+	mux := NewMux()
+	mux.Use(CORS(CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{"GET", "POST", "DELETE"},
+		AllowHeaders: []string{"Content-Type", "Authorization"},
+		MaxAge:       10 * time.Minute,
+		Routes: map[string]CORSConfig{
+			"/admin/{path...}": {
+				AllowOrigins:     []string{"https://admin.example.com"},
+				AllowMethods:     []string{"GET"},
+				AllowCredentials: &credentials,
+				MaxAge:           time.Minute,
+			},
+		},
+	}))
+	mux.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("users"))
+	})
+	mux.HandleFunc("GET /admin/stats", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("stats"))
+	})
+	return mux
+}
+
+func TestCORSPreflight(t *testing.T) {
+	mux := newCORSMux()
+
+	tests := []struct {
+		name        string
+		path        string
+		origin      string
+		method      string
+		headers     string
+		status      int
+		allowOrigin string
+		methods     string
+		maxAge      string
+		credentials string
+	}{
+		{"global", "/users", "https://app.example.com", "DELETE", "content-type", http.StatusNoContent, "*", "GET, POST, DELETE", "600", ""},
+		{"route override", "/admin/stats", "https://admin.example.com", "GET", "Authorization", http.StatusNoContent, "https://admin.example.com", "GET", "60", "true"},
+		{"route origin denied", "/admin/stats", "https://app.example.com", "GET", "", http.StatusForbidden, "", "", "", ""},
+		{"route method denied", "/admin/stats", "https://admin.example.com", "DELETE", "", http.StatusForbidden, "", "", "", ""},
+		{"header denied", "/users", "https://app.example.com", "GET", "X-Secret", http.StatusForbidden, "", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("OPTIONS", tt.path, nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", tt.method)
+			if tt.headers != "" {
+				req.Header.Set("Access-Control-Request-Headers", tt.headers)
+			}
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+
+			h := rec.Header()
+			if got := h.Get("Access-Control-Allow-Origin"); got != tt.allowOrigin {
+				t.Errorf("Allow-Origin = %q, want %q", got, tt.allowOrigin)
+			}
+			if got := h.Get("Access-Control-Allow-Methods"); got != tt.methods {
+				t.Errorf("Allow-Methods = %q, want %q", got, tt.methods)
+			}
+			if got := h.Get("Access-Control-Max-Age"); got != tt.maxAge {
+				t.Errorf("Max-Age = %q, want %q", got, tt.maxAge)
+			}
+			if got := h.Get("Access-Control-Allow-Credentials"); got != tt.credentials {
+				t.Errorf("Allow-Credentials = %q, want %q", got, tt.credentials)
+			}
+			if tt.status == http.StatusNoContent && h.Get("Access-Control-Allow-Headers") != tt.headers {
+				t.Errorf("Allow-Headers = %q, want %q", h.Get("Access-Control-Allow-Headers"), tt.headers)
+			}
+		})
+	}
+}
+
+func TestCORSRequest(t *testing.T) {
+	mux := newCORSMux()
+
+	tests := []struct {
+		name        string
+		path        string
+		origin      string
+		body        string
+		allowOrigin string
+	}{
+		{"global", "/users", "https://app.example.com", "users", "*"},
+		{"route override", "/admin/stats", "https://admin.example.com", "stats", "https://admin.example.com"},
+		{"route origin denied", "/admin/stats", "https://app.example.com", "stats", ""},
+		{"same origin", "/users", "", "users", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+
+			if got := rec.Header().Get("Access-Control-Allow-Origin"); got != tt.allowOrigin {
+				t.Errorf("Allow-Origin = %q, want %q", got, tt.allowOrigin)
+			}
+		})
+	}
+}
+
+func TestCORSCredentials(t *testing.T) {
+	on, off := true, false
+
+	// 路由覆盖可以关闭全局策略开启的凭据
+	mux := NewMux()
+	mux.Use(CORS(CORSConfig{
+		AllowOrigins:     []string{"https://app.example.com"},
+		AllowCredentials: &on,
+		Routes: map[string]CORSConfig{
+			"/public/{path...}": {AllowCredentials: &off},
+		},
+	}))
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {})
+
+	for path, want := range map[string]string{"/account": "true", "/public/logo.png": ""} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Origin", "https://app.example.com")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("Access-Control-Allow-Credentials"); got != want {
+			t.Errorf("%s: Allow-Credentials = %q, want %q", path, got, want)
+		}
+		if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+			t.Errorf("%s: Allow-Origin = %q", path, got)
+		}
+	}
+}
+
+func TestCORSCredentialsWildcardPanic(t *testing.T) {
+	on := true
+	configs := map[string]CORSConfig{
+		"global": {AllowOrigins: []string{"*"}, AllowCredentials: &on},
+		"route": {
+			AllowOrigins: []string{"*"},
+			Routes:       map[string]CORSConfig{"/account": {AllowCredentials: &on}},
+		},
+	}
+
+	for name, cfg := range configs {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Error("expected panic")
+				}
+			}()
+			CORS(cfg)
+		})
+	}
+}
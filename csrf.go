@@ -0,0 +1,127 @@
+package h3
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+)
+
+// CSRFConfig CSRF 防护中间件的配置
+type CSRFConfig struct {
+	// CookieName 保存令牌的 Cookie 名称，默认为 "_csrf"
+	CookieName string
+
+	// HeaderName 提交令牌的请求头名称，默认为 "X-CSRF-Token"
+	HeaderName string
+
+	// FieldName 提交令牌的表单字段名称，默认为 "csrf_token"
+	FieldName string
+
+	// Path Cookie 的路径，默认为 "/"
+	Path string
+
+	// MaxAge Cookie 的有效期（秒），为零时为会话 Cookie
+	MaxAge int
+
+	// Secure 是否只通过 HTTPS 发送 Cookie
+	Secure bool
+
+	// SameSite Cookie 的 SameSite 属性，默认为 http.SameSiteLaxMode
+	SameSite http.SameSite
+}
+
+// csrfKey CSRF 令牌的 context key
+type csrfKey struct{}
+
+// CSRF 创建基于双重提交 Cookie 的 CSRF 防护中间件
+//
+// 对于安全方法（GET、HEAD、OPTIONS、TRACE），如果请求没有携带有效的令牌 Cookie，
+// 中间件会生成新的随机令牌并写入 Cookie。令牌同时保存在请求 context 中，
+// 可以通过 CSRFToken 取出并渲染到表单的隐藏字段中。
+//
+// 对于其他方法，中间件从请求头 HeaderName 或表单字段 FieldName 读取提交的令牌，
+// 使用常量时间比较与 Cookie 中的令牌校验，缺失或不一致时返回 403 Forbidden。
+//
+// 示例:
+//
+//	mux.Use(h3.CSRF(h3.CSRFConfig{Secure: true}))
+//
+//	// 模板中
+//	<input type="hidden" name="csrf_token" value="{{ .CSRFToken }}">
+func CSRF(cfg CSRFConfig) func(http.Handler) http.Handler {
+	if cfg.CookieName == "" {
+		cfg.CookieName = "_csrf"
+	}
+	if cfg.HeaderName == "" {
+		cfg.HeaderName = "X-CSRF-Token"
+	}
+	if cfg.FieldName == "" {
+		cfg.FieldName = "csrf_token"
+	}
+	if cfg.Path == "" {
+		cfg.Path = "/"
+	}
+	if cfg.SameSite == 0 {
+		cfg.SameSite = http.SameSiteLaxMode
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var token string
+			if c, err := r.Cookie(cfg.CookieName); err == nil && validCSRFToken(c.Value) {
+				token = c.Value
+			}
+
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+				if token == "" {
+					token = newCSRFToken()
+					http.SetCookie(w, &http.Cookie{
+						Name:     cfg.CookieName,
+						Value:    token,
+						Path:     cfg.Path,
+						MaxAge:   cfg.MaxAge,
+						Secure:   cfg.Secure,
+						HttpOnly: true,
+						SameSite: cfg.SameSite,
+					})
+				}
+			default:
+				sent := r.Header.Get(cfg.HeaderName)
+				if sent == "" {
+					sent = r.PostFormValue(cfg.FieldName)
+				}
+				if token == "" || subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+					http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+					return
+				}
+			}
+
+			ctx := context.WithValue(r.Context(), csrfKey{}, token)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// CSRFToken 返回当前请求的 CSRF 令牌
+//
+// 如果请求没有经过 CSRF 中间件，返回空字符串。
+func CSRFToken(r *http.Request) string {
+	token, _ := r.Context().Value(csrfKey{}).(string)
+	return token
+}
+
+// newCSRFToken 生成 32 字节的随机令牌
+func newCSRFToken() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// validCSRFToken 检查令牌的格式是否由 newCSRFToken 生成
+func validCSRFToken(token string) bool {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	return err == nil && len(b) == 32
+}
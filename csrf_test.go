@@ -0,0 +1,108 @@
+package h3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func newCSRFMux(cfg CSRFConfig) Mux {
+	mux := NewMux()
+	mux.Use(CSRF(cfg))
+	mux.HandleFunc("GET /form", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(CSRFToken(r)))
+	})
+	mux.HandleFunc("POST /form", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	return mux
+}
+
+// issueCSRFToken 发送 GET 请求，返回签发的 Cookie 和页面中的令牌
+func issueCSRFToken(t *testing.T, mux Mux) (*http.Cookie, string) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/form", nil))
+
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("got %d cookies, want 1", len(cookies))
+	}
+	return cookies[0], rec.Body.String()
+}
+
+func TestCSRFIssue(t *testing.T) {
+	mux := newCSRFMux(CSRFConfig{Secure: true, SameSite: http.SameSiteStrictMode})
+
+	cookie, token := issueCSRFToken(t, mux)
+
+	if cookie.Name != "_csrf" {
+		t.Errorf("cookie name = %q, want %q", cookie.Name, "_csrf")
+	}
+	if cookie.Value == "" || cookie.Value != token {
+		t.Errorf("cookie value = %q, token = %q", cookie.Value, token)
+	}
+	if !cookie.Secure || !cookie.HttpOnly || cookie.SameSite != http.SameSiteStrictMode {
+		t.Errorf("cookie attributes = %+v", cookie)
+	}
+
+	// 已携带有效 Cookie 时不重新签发
+	req := httptest.NewRequest("GET", "/form", nil)
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Set-Cookie"); got != "" {
+		t.Errorf("Set-Cookie = %q, want empty", got)
+	}
+	if rec.Body.String() != token {
+		t.Errorf("token = %q, want %q", rec.Body.String(), token)
+	}
+}
+
+func TestCSRFValidate(t *testing.T) {
+	mux := newCSRFMux(CSRFConfig{})
+	cookie, token := issueCSRFToken(t, mux)
+
+	tests := []struct {
+		name   string
+		cookie bool
+		field  string
+		header string
+		status int
+	}{
+		{"form field", true, token, "", http.StatusOK},
+		{"header", true, "", token, http.StatusOK},
+		{"missing token", true, "", "", http.StatusForbidden},
+		{"invalid token", true, "invalid", "", http.StatusForbidden},
+		{"missing cookie", false, token, "", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := url.Values{}
+			if tt.field != "" {
+				form.Set("csrf_token", tt.field)
+			}
+
+			req := httptest.NewRequest("POST", "/form", strings.NewReader(form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tt.header != "" {
+				req.Header.Set("X-CSRF-Token", tt.header)
+			}
+			if tt.cookie {
+				req.AddCookie(cookie)
+			}
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+		})
+	}
+}
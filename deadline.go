@@ -0,0 +1,110 @@
+package h3
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// StatusClientClosedRequest 客户端在服务器完成响应之前关闭了连接
+//
+// 这是 nginx 使用的非标准状态码，不会真正发送给客户端，只用于日志和监控。
+const StatusClientClosedRequest = 499
+
+// ShouldContinue 返回处理器是否应该继续处理请求
+//
+// 当客户端断开连接或请求上下文的截止时间已过时返回 false，
+// 执行耗时操作的处理器可以据此提前结束。
+//
+// 示例:
+//
+//	for _, item := range items {
+//		if !h3.ShouldContinue(r) {
+//			return
+//		}
+//		process(item)
+//	}
+func ShouldContinue(r *http.Request) bool {
+	return r.Context().Err() == nil
+}
+
+// Deadline 返回请求上下文的截止时间
+//
+// 没有设置截止时间时 ok 为 false。截止时间通常来自 Options.RequestTimeout
+// 或其他设置了超时的中间件。
+func Deadline(r *http.Request) (deadline time.Time, ok bool) {
+	return r.Context().Deadline()
+}
+
+// AbortOnDisconnect 创建在客户端断开连接时记录 499 状态码的中间件
+//
+// 如果处理器返回时请求上下文因客户端断开而被取消，且响应尚未提交，
+// 中间件以 StatusClientClosedRequest 调用 WriteHeader，
+// 外层的日志或监控中间件通过 Response.Status 即可区分客户端主动断开的请求。
+// 因超时而结束的请求不受影响。
+//
+// 示例:
+//
+//	mux.Use(logger)
+//	mux.Use(h3.AbortOnDisconnect())
+func AbortOnDisconnect() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := NewResponse(w)
+			next.ServeHTTP(rw, r)
+
+			if errors.Is(r.Context().Err(), context.Canceled) && !rw.Committed() && !rw.Hijacked() {
+				rw.WriteHeader(StatusClientClosedRequest)
+			}
+		})
+	}
+}
+
+// Timeout 创建为请求设置截止时间的中间件
+//
+// 处理器收到的上下文派生自 r.Context()，因此客户端断开连接时（http.Server 会取消 r.Context()）
+// 会立即被取消，而不必等到超时，耗时的下游调用可以尽早中止。
+// 处理器需要观察 r.Context() 并在取消后返回；中间件不会在另一个 goroutine 中运行处理器。
+//
+// 处理器返回且响应尚未提交时，按上下文结束的原因写出状态码：
+//   - 客户端断开: StatusClientClosedRequest (499)，只用于日志和监控，客户端不会收到
+//   - 超时: 503 Service Unavailable，外层截止时间（如 Options.RequestTimeout）先到期时同样如此
+//
+// 与 Options.RequestTimeout 相同，协议升级和事件流等长连接请求不受此限制。
+// d 必须大于零，否则触发 panic。
+//
+// 示例:
+//
+//	mux.Use(logger)
+//	mux.Use(h3.Timeout(5 * time.Second))
+func Timeout(d time.Duration) func(http.Handler) http.Handler {
+	if d <= 0 {
+		panic(errors.New("h3: timeout must be positive"))
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isLongLived(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			rw := NewResponse(w)
+			next.ServeHTTP(rw, r.WithContext(ctx))
+			if rw.Committed() || rw.Hijacked() {
+				return
+			}
+
+			switch {
+			case errors.Is(r.Context().Err(), context.Canceled):
+				rw.WriteHeader(StatusClientClosedRequest)
+			case errors.Is(ctx.Err(), context.DeadlineExceeded):
+				http.Error(rw, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package h3
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestShouldContinue(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+
+	if !ShouldContinue(req) {
+		t.Error("ShouldContinue = false for an active request")
+	}
+
+	if _, ok := Deadline(req); ok {
+		t.Error("Deadline reported a deadline for a request without one")
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	cancel()
+
+	if ShouldContinue(req.WithContext(ctx)) {
+		t.Error("ShouldContinue = true for a cancelled request")
+	}
+}
+
+func TestDeadline(t *testing.T) {
+	want := time.Now().Add(-time.Second)
+	ctx, cancel := context.WithDeadline(context.Background(), want)
+	defer cancel()
+
+	req := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+
+	got, ok := Deadline(req)
+	if !ok || !got.Equal(want) {
+		t.Errorf("Deadline = %v, %v, want %v, true", got, ok, want)
+	}
+
+	if ShouldContinue(req) {
+		t.Error("ShouldContinue = true after the deadline passed")
+	}
+}
+
+func TestAbortOnDisconnect(t *testing.T) {
+	var status int
+	logger := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := NewResponse(w)
+			next.ServeHTTP(rw, r)
+			status = rw.Status()
+		})
+	}
+
+	mux := NewMux()
+	mux.Use(logger)
+	mux.Use(AbortOnDisconnect())
+	mux.HandleFunc("GET /work", func(w http.ResponseWriter, r *http.Request) {
+		if !ShouldContinue(r) {
+			return
+		}
+		w.Write([]byte("done"))
+	})
+
+	tests := []struct {
+		name   string
+		ctx    func() (context.Context, context.CancelFunc)
+		status int
+	}{
+		{"active", func() (context.Context, context.CancelFunc) {
+			return context.WithCancel(context.Background())
+		}, http.StatusOK},
+		{"disconnected", func() (context.Context, context.CancelFunc) {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			return ctx, cancel
+		}, StatusClientClosedRequest},
+		{"timed out", func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(context.Background(), -time.Second)
+		}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			req := httptest.NewRequest("GET", "/work", nil).WithContext(ctx)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if status != tt.status {
+				t.Errorf("logged status = %d, want %d", status, tt.status)
+			}
+		})
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	t.Run("timeout", func(t *testing.T) {
+		h := Timeout(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+		}
+	})
+
+	t.Run("outer deadline", func(t *testing.T) {
+		h := requestTimeout(Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		})), 20*time.Millisecond)
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+		}
+	})
+
+	t.Run("fast handler", func(t *testing.T) {
+		h := Timeout(time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := Deadline(r); !ok {
+				t.Error("handler context has no deadline")
+			}
+			w.Write([]byte("ok"))
+		}))
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		if rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+			t.Errorf("response = %d %q, want 200 %q", rec.Code, rec.Body, "ok")
+		}
+	})
+
+	t.Run("client disconnect", func(t *testing.T) {
+		started := make(chan struct{})
+		observed := make(chan time.Duration, 1)
+		status := make(chan int, 1)
+
+		logger := func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				rw := NewResponse(w)
+				next.ServeHTTP(rw, r)
+				status <- rw.Status()
+			})
+		}
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			begin := time.Now()
+			close(started)
+			<-r.Context().Done()
+			observed <- time.Since(begin)
+		})
+
+		srv := httptest.NewServer(logger(Timeout(time.Minute)(handler)))
+		defer srv.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+		go func() {
+			<-started
+			cancel()
+		}()
+		if _, err := http.DefaultClient.Do(req); err == nil {
+			t.Fatal("expected cancelled request to fail")
+		}
+
+		select {
+		case d := <-observed:
+			if d > 5*time.Second {
+				t.Errorf("handler observed cancellation after %v", d)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("handler context was not cancelled on client disconnect")
+		}
+		if got := <-status; got != StatusClientClosedRequest {
+			t.Errorf("logged status = %d, want %d", got, StatusClientClosedRequest)
+		}
+	})
+}
+
+func TestTimeoutInvalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for non-positive timeout")
+		}
+	}()
+	Timeout(0)
+}
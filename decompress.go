@@ -0,0 +1,138 @@
+package h3
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrDecompressionRatio 请求体解压后的大小与压缩大小之比超过了 DecompressConfig.MaxRatio
+//
+// 处理器读取请求体时收到此错误，通常意味着请求是压缩炸弹（zip bomb）。
+var ErrDecompressionRatio = errors.New("h3: request body decompression ratio exceeded")
+
+// decompressRatioFloor 解压后不足此字节数的请求体不检查压缩比
+//
+// 小请求体即使压缩比很高也不会造成危害，而高度重复的小 JSON 文档的压缩比可能相当高。
+const decompressRatioFloor = 64 << 10
+
+// DecompressSink 接收请求体解压指标的接收器
+//
+// 实现通常记录压缩比的直方图，以发现压缩炸弹的尝试。
+// ObserveDecompression 会被并发调用，实现需要保证并发安全。
+type DecompressSink interface {
+	// ObserveDecompression 记录一次请求读取的压缩字节数和解压后的字节数
+	//
+	// pattern 为匹配的路由模式，没有匹配的路由时为空字符串。
+	// 压缩比为 float64(decompressed) / float64(compressed)。
+	ObserveDecompression(pattern string, compressed, decompressed int64)
+}
+
+// DecompressConfig Decompress 中间件的配置
+type DecompressConfig struct {
+	// MaxRatio 解压后字节数与压缩字节数之比的上限，超过时读取请求体返回 ErrDecompressionRatio，
+	// 处理器返回后如果响应尚未提交，返回 400 Bad Request。
+	// 解压后不足 64 KiB 的请求体不检查。零值表示不限制。
+	MaxRatio float64
+
+	// Sink 可选地接收每个压缩请求的解压指标
+	Sink DecompressSink
+}
+
+// Decompress 创建解压请求体的中间件
+//
+// 支持 Content-Encoding 为 gzip（或 x-gzip）和 deflate（zlib 格式）的请求体。
+// 处理器读取到的是解压后的数据，请求的 Content-Encoding 和 Content-Length 被移除，
+// r.ContentLength 设置为 -1。没有 Content-Encoding 或为 identity 的请求原样传递。
+//
+// 响应:
+//   - 415 Unsupported Media Type: 不支持的编码，或使用了多个编码
+//   - 400 Bad Request: 压缩数据的头部无效，或压缩比超过 MaxRatio
+//
+// 压缩比在读取过程中持续检查，超过 MaxRatio 时立即停止解压，
+// 不会将压缩炸弹完整展开到内存中。
+//
+// 路由模式取自路由器设置的 r.Pattern，要求与 SizeMetrics 相同。
+//
+// 示例:
+//
+//	mux.Use(h3.Decompress(h3.DecompressConfig{MaxRatio: 100, Sink: metrics}))
+func Decompress(config ...DecompressConfig) func(http.Handler) http.Handler {
+	var cfg DecompressConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+			if encoding == "" || encoding == "identity" || r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			src := &countingBody{ReadCloser: r.Body}
+			var dec io.ReadCloser
+			var err error
+			switch encoding {
+			case "gzip", "x-gzip":
+				dec, err = gzip.NewReader(src)
+			case "deflate":
+				dec, err = zlib.NewReader(src)
+			default:
+				http.Error(w, http.StatusText(http.StatusUnsupportedMediaType), http.StatusUnsupportedMediaType)
+				return
+			}
+			if err != nil {
+				http.Error(w, "invalid compressed body", http.StatusBadRequest)
+				return
+			}
+
+			body := &decompressBody{dec: dec, src: src, maxRatio: cfg.MaxRatio}
+			r.Body = body
+			r.ContentLength = -1
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+
+			rw := NewResponse(w)
+			next.ServeHTTP(rw, r)
+
+			if cfg.Sink != nil {
+				cfg.Sink.ObserveDecompression(r.Pattern, src.n, body.n)
+			}
+			if body.exceeded && !rw.Committed() && !rw.Hijacked() {
+				http.Error(rw, ErrDecompressionRatio.Error(), http.StatusBadRequest)
+			}
+		})
+	}
+}
+
+// decompressBody 统计解压后字节数并检查压缩比的请求体
+type decompressBody struct {
+	dec      io.ReadCloser // 解压器
+	src      *countingBody // 原始的压缩请求体
+	n        int64         // 解压后已读取的字节数
+	maxRatio float64       // 压缩比上限，零表示不限制
+	exceeded bool          // 压缩比是否已超过上限
+}
+
+func (b *decompressBody) Read(p []byte) (int, error) {
+	if b.exceeded {
+		return 0, ErrDecompressionRatio
+	}
+
+	n, err := b.dec.Read(p)
+	b.n += int64(n)
+	if b.maxRatio > 0 && b.n > decompressRatioFloor && float64(b.n) > b.maxRatio*float64(b.src.n) {
+		b.exceeded = true
+		return 0, ErrDecompressionRatio
+	}
+	return n, err
+}
+
+func (b *decompressBody) Close() error {
+	return errors.Join(b.dec.Close(), b.src.Close())
+}
@@ -0,0 +1,157 @@
+package h3
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// decompressObservation 一次 ObserveDecompression 调用
+type decompressObservation struct {
+	pattern                  string
+	compressed, decompressed int64
+}
+
+// fakeDecompressSink 记录所有观测值的 DecompressSink
+type fakeDecompressSink struct {
+	mu  sync.Mutex
+	obs []decompressObservation
+}
+
+func (s *fakeDecompressSink) ObserveDecompression(pattern string, compressed, decompressed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.obs = append(s.obs, decompressObservation{pattern, compressed, decompressed})
+}
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	zw.Close()
+	return buf.Bytes()
+}
+
+func newDecompressMux(sink DecompressSink, maxRatio float64) Mux {
+	mux := NewMux()
+	mux.Use(Decompress(DecompressConfig{MaxRatio: maxRatio, Sink: sink}))
+	mux.HandleFunc("POST /upload/{name}", func(w http.ResponseWriter, r *http.Request) {
+		n, err := io.Copy(io.Discard, r.Body)
+		if errors.Is(err, ErrDecompressionRatio) {
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(r.Header.Get("Content-Encoding") + ":" + strings.Repeat("x", int(min(n, 3)))))
+	})
+	return mux
+}
+
+func TestDecompress(t *testing.T) {
+	sink := &fakeDecompressSink{}
+	mux := newDecompressMux(sink, 100)
+
+	// 普通 JSON 的压缩比远低于上限
+	var doc bytes.Buffer
+	for i := range 5000 {
+		doc.WriteString(`{"id":` + strings.Repeat("1", i%7+1) + `,"name":"user"},`)
+	}
+	compressed := gzipBytes(t, doc.Bytes())
+
+	req := httptest.NewRequest("POST", "/upload/a", bytes.NewReader(compressed))
+	req.Header.Set("Content-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || rec.Body.String() != ":xxx" {
+		t.Fatalf("response = %d %q, want 200 %q", rec.Code, rec.Body, ":xxx")
+	}
+	want := decompressObservation{"POST /upload/{name}", int64(len(compressed)), int64(doc.Len())}
+	if len(sink.obs) != 1 || sink.obs[0] != want {
+		t.Fatalf("observations = %+v, want [%+v]", sink.obs, want)
+	}
+	ratio := float64(sink.obs[0].decompressed) / float64(sink.obs[0].compressed)
+	if wantRatio := float64(doc.Len()) / float64(len(compressed)); ratio != wantRatio || ratio >= 100 {
+		t.Errorf("ratio = %.2f, want %.2f (< 100)", ratio, wantRatio)
+	}
+}
+
+func TestDecompressRatioExceeded(t *testing.T) {
+	sink := &fakeDecompressSink{}
+	mux := newDecompressMux(sink, 100)
+
+	// 10 MiB 的零字节压缩后只有约 10 KiB
+	bomb := gzipBytes(t, make([]byte, 10<<20))
+
+	req := httptest.NewRequest("POST", "/upload/bomb", bytes.NewReader(bomb))
+	req.Header.Set("Content-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if len(sink.obs) != 1 {
+		t.Fatalf("observations = %d, want 1", len(sink.obs))
+	}
+	obs := sink.obs[0]
+	if obs.decompressed >= 10<<20 {
+		t.Errorf("decompressed %d bytes, want decompression stopped early", obs.decompressed)
+	}
+	if ratio := float64(obs.decompressed) / float64(obs.compressed); ratio <= 100 {
+		t.Errorf("recorded ratio = %.2f, want > 100", ratio)
+	}
+}
+
+func TestDecompressEncodings(t *testing.T) {
+	mux := newDecompressMux(nil, 0)
+
+	var deflated bytes.Buffer
+	zw := zlib.NewWriter(&deflated)
+	zw.Write([]byte("hello"))
+	zw.Close()
+
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+		status   int
+		want     string
+	}{
+		{"identity", "", []byte("hello"), http.StatusOK, ":xxx"},
+		{"x-gzip", "x-gzip", gzipBytes(t, []byte("hello")), http.StatusOK, ":xxx"},
+		{"deflate", "deflate", deflated.Bytes(), http.StatusOK, ":xxx"},
+		{"unsupported", "br", []byte("hello"), http.StatusUnsupportedMediaType, ""},
+		{"invalid", "gzip", []byte("not gzip"), http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/upload/a", bytes.NewReader(tt.body))
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.want != "" && rec.Body.String() != tt.want {
+				t.Errorf("body = %q, want %q", rec.Body, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package h3
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// NormalizeEncoding 创建规范化 Accept-Encoding 请求头的中间件
+//
+// 中间件解析 Accept-Encoding，只保留 supported 中列出的编码，
+// 按质量值从高到低重写请求头，例如 "br;q=0.5, zstd, GZIP, *;q=0.1"
+// 在支持 gzip 和 br 时重写为 "gzip, br;q=0.5"。规则如下:
+//   - 编码名称不区分大小写，重写后使用 supported 中的写法
+//   - q=0 的编码被排除；质量值相同的编码保持客户端的顺序
+//   - "*" 展开为没有显式列出的支持编码，使用 "*" 的质量值
+//   - 没有剩余的编码时重写为 "identity"，而不是删除该请求头：
+//     没有 Accept-Encoding 表示接受任何编码，删除会让拒绝压缩的客户端收到压缩的响应
+//   - 没有该请求头的请求不做处理
+//
+// 应当放在压缩中间件之前，使其只看到自己能够处理的编码。
+//
+// 示例:
+//
+//	mux.Use(h3.NormalizeEncoding("gzip", "br"))
+//	mux.Use(compressMiddleware)
+func NormalizeEncoding(supported ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if values := r.Header.Values("Accept-Encoding"); len(values) > 0 {
+				normalized := normalizeEncoding(strings.Join(values, ","), supported)
+				if normalized == "" {
+					normalized = "identity"
+				}
+				r.Header.Set("Accept-Encoding", normalized)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// acceptedEncoding Accept-Encoding 中的一个编码及其质量值
+type acceptedEncoding struct {
+	name string
+	q    float64
+}
+
+// normalizeEncoding 将 Accept-Encoding 的值过滤为支持的编码并按质量值排序
+func normalizeEncoding(header string, supported []string) string {
+	var accepted []acceptedEncoding
+	explicit := make(map[string]bool)
+	wildcard := -1.0
+
+	for part := range strings.SplitSeq(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		q := 1.0
+		for param := range strings.SplitSeq(params, ";") {
+			k, v, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.EqualFold(strings.TrimSpace(k), "q") {
+				if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil && f >= 0 && f <= 1 {
+					q = f
+				} else {
+					q = 0
+				}
+			}
+		}
+
+		if name == "*" {
+			wildcard = q
+			continue
+		}
+
+		i := slices.IndexFunc(supported, func(s string) bool { return strings.EqualFold(s, name) })
+		if i < 0 || explicit[supported[i]] {
+			continue
+		}
+		explicit[supported[i]] = true
+		if q > 0 {
+			accepted = append(accepted, acceptedEncoding{name: supported[i], q: q})
+		}
+	}
+
+	if wildcard > 0 {
+		for _, s := range supported {
+			if !explicit[s] {
+				explicit[s] = true
+				accepted = append(accepted, acceptedEncoding{name: s, q: wildcard})
+			}
+		}
+	}
+
+	slices.SortStableFunc(accepted, func(a, b acceptedEncoding) int {
+		return cmp.Compare(b.q, a.q)
+	})
+
+	parts := make([]string, len(accepted))
+	for i, e := range accepted {
+		parts[i] = e.name
+		if e.q < 1 {
+			parts[i] += ";q=" + strconv.FormatFloat(e.q, 'f', -1, 64)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
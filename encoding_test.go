@@ -0,0 +1,53 @@
+package h3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNormalizeEncoding(t *testing.T) {
+	tests := []struct {
+		name   string
+		header []string
+		want   string
+		absent bool
+	}{
+		{"mixed", []string{"br;q=0.5, zstd, GZIP, *;q=0.1"}, "gzip, br;q=0.5", false},
+		{"quality order", []string{"gzip;q=0.2, br;q=0.8"}, "br;q=0.8, gzip;q=0.2", false},
+		{"multiple headers", []string{"compress", "br"}, "br", false},
+		{"wildcard", []string{"*"}, "gzip, br", false},
+		{"wildcard with exclusion", []string{"gzip;q=0, *;q=0.3"}, "br;q=0.3", false},
+		{"weird tokens", []string{" , ;q=1, gzip;q=abc, br ; Q=0.7 "}, "br;q=0.7", false},
+		{"unsupported only", []string{"compress, deflate"}, "identity", false},
+		{"identity only", []string{"identity"}, "identity", false},
+		{"all excluded", []string{"gzip;q=0, *;q=0"}, "identity", false},
+		{"empty", []string{""}, "identity", false},
+		{"missing", nil, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			h := NormalizeEncoding("gzip", "br")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header.Values("Accept-Encoding")
+			}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			for _, v := range tt.header {
+				req.Header.Add("Accept-Encoding", v)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			if tt.absent {
+				if len(got) != 0 {
+					t.Errorf("Accept-Encoding = %q, want absent", got)
+				}
+				return
+			}
+			if len(got) != 1 || got[0] != tt.want {
+				t.Errorf("Accept-Encoding = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package h3
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strings"
+)
+
+// StatusError 携带 HTTP 状态码的错误
+//
+// 处理器返回 StatusError 时，ErrorRenderer 使用其中的状态码、错误码和消息写出响应。
+// Code 为空时根据状态码生成，例如 404 对应 "not_found"；
+// Message 为空时使用状态码对应的标准文本。Err 只用于日志和 errors.Is/As，不会写入响应。
+type StatusError struct {
+	Status  int    // HTTP 状态码
+	Code    string // 机器可读的错误码
+	Message string // 返回给客户端的错误消息
+	Err     error  // 原始错误
+}
+
+func (e *StatusError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.Status)
+	}
+	if e.Err != nil {
+		return msg + ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorRenderer 将错误写出为 HTTP 响应
+//
+// HandleError 和 Recoverer 共用 ErrorRenderer，
+// 保证处理器返回的错误和恢复的 panic 以相同的格式返回给客户端。
+type ErrorRenderer func(w http.ResponseWriter, r *http.Request, err error)
+
+// errorEnvelope RenderJSONError 写出的 JSON 错误格式
+type errorEnvelope struct {
+	Status    int          `json:"status"`
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// RenderJSONError 以 JSON 格式写出错误，是默认的 ErrorRenderer
+//
+// 响应体格式为:
+//
+//	{"status": 404, "code": "not_found", "message": "user not found", "request_id": "..."}
+//
+// 状态码和消息的来源:
+//   - *StatusError: 使用其 Status、Code 和 Message
+//   - *BindError: 使用其 Status，消息为错误文本
+//   - *ValidationError: 422，errors 字段列出每个字段的错误
+//   - 其他错误: 500，消息为标准文本，不会暴露错误的内容
+//
+// request_id 来自 RequestIDFromContext，没有请求 ID 时省略。
+func RenderJSONError(w http.ResponseWriter, r *http.Request, err error) {
+	env := newErrorEnvelope(r, err)
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(env.Status)
+	_ = json.NewEncoder(w).Encode(env)
+}
+
+// newErrorEnvelope 根据错误的类型确定状态码、错误码和消息
+func newErrorEnvelope(r *http.Request, err error) errorEnvelope {
+	env := errorEnvelope{
+		Status:    http.StatusInternalServerError,
+		RequestID: RequestIDFromContext(r.Context()),
+	}
+
+	var se *StatusError
+	var be *BindError
+	var ve *ValidationError
+	switch {
+	case errors.As(err, &se):
+		env.Status, env.Code, env.Message = se.Status, se.Code, se.Message
+	case errors.As(err, &be):
+		env.Status, env.Message = be.Status, be.Error()
+	case errors.As(err, &ve):
+		env.Status, env.Errors = http.StatusUnprocessableEntity, ve.Errors
+	}
+	if env.Code == "" {
+		env.Code = statusCode(env.Status)
+	}
+	if env.Message == "" {
+		env.Message = http.StatusText(env.Status)
+	}
+	return env
+}
+
+// HandleError 将返回错误的处理函数适配为 http.Handler
+//
+// fn 返回非 nil 错误时，使用 render 写出错误响应；render 为空时使用 RenderJSONError。
+// 如果 fn 在返回错误之前已经提交了响应，错误只会被记录到日志。
+//
+// 示例:
+//
+//	mux.Handle("GET /users/{id}", h3.HandleError(func(w http.ResponseWriter, r *http.Request) error {
+//		user, ok := users[r.PathValue("id")]
+//		if !ok {
+//			return &h3.StatusError{Status: http.StatusNotFound, Message: "user not found"}
+//		}
+//		return json.NewEncoder(w).Encode(user)
+//	}))
+func HandleError(fn func(w http.ResponseWriter, r *http.Request) error, render ...ErrorRenderer) http.Handler {
+	renderer := errorRenderer(render)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := NewResponse(w)
+		if err := fn(rw, r); err != nil {
+			if rw.Committed() || rw.Hijacked() {
+				log.Printf("h3: error after response committed: %s %s: %v", r.Method, r.URL.Path, err)
+				return
+			}
+			renderer(rw, r, err)
+		}
+	})
+}
+
+// Recoverer 创建从 panic 中恢复的中间件
+//
+// 处理器 panic 时，中间件报告 panic 的值和调用栈，
+// 并使用 render 写出 500 错误响应；render 为空时使用 RenderJSONError，
+// 因此恢复的 panic 与 HandleError 处理的错误具有相同的响应格式。
+// 如果 panic 时响应已经提交，只记录日志。
+//
+// 通过 App 提供服务时，panic 交给 Options.PanicHandler 报告，未设置时记录到日志。
+//
+// http.ErrAbortHandler 会被重新抛出，由 http.Server 按约定静默中断连接。
+//
+// 示例:
+//
+//	mux.Use(h3.RequestID())
+//	mux.Use(h3.Recoverer())
+func Recoverer(render ...ErrorRenderer) func(http.Handler) http.Handler {
+	renderer := errorRenderer(render)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := NewResponse(w)
+
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if v == http.ErrAbortHandler {
+					panic(v)
+				}
+
+				reportPanic(r, v, debug.Stack())
+
+				if rw.Committed() || rw.Hijacked() {
+					return
+				}
+				renderer(rw, r, &StatusError{
+					Status: http.StatusInternalServerError,
+					Err:    fmt.Errorf("panic: %v", v),
+				})
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// panicHandlerKey 请求上下文中 Options.PanicHandler 的键
+type panicHandlerKey struct{}
+
+// reportPanic 将恢复的 panic 交给 Options.PanicHandler，未设置时记录到日志
+//
+// PanicHandler 自身的 panic 会被恢复并记录，不会影响错误响应的写出。
+func reportPanic(r *http.Request, v any, stack []byte) {
+	fn, _ := r.Context().Value(panicHandlerKey{}).(func(*http.Request, any, []byte))
+	if fn == nil {
+		log.Printf("h3: panic serving %s %s: %v\n%s", r.Method, r.URL.Path, v, stack)
+		return
+	}
+
+	defer func() {
+		if pv := recover(); pv != nil {
+			log.Printf("h3: panic in panic handler: %v (original panic: %v)\n%s", pv, v, stack)
+		}
+	}()
+	fn(r, v, stack)
+}
+
+// errorRenderer 返回第一个非 nil 的 ErrorRenderer，默认为 RenderJSONError
+func errorRenderer(render []ErrorRenderer) ErrorRenderer {
+	if len(render) > 0 && render[0] != nil {
+		return render[0]
+	}
+	return RenderJSONError
+}
+
+// statusCode 根据状态码的标准文本生成错误码，例如 404 对应 "not_found"
+func statusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "error"
+	}
+
+	var b strings.Builder
+	for _, c := range strings.ToLower(text) {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9':
+			b.WriteRune(c)
+		case c == ' ' || c == '-':
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,111 @@
+package h3
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestErrorEnvelope(t *testing.T) {
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(io.Discard)
+
+	mux := NewMux()
+	mux.Use(RequestID())
+	mux.Use(Recoverer())
+	mux.HandleFunc("GET /panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	mux.Handle("GET /error", HandleError(func(w http.ResponseWriter, r *http.Request) error {
+		return errors.New("database password leaked")
+	}))
+	mux.Handle("GET /status", HandleError(func(w http.ResponseWriter, r *http.Request) error {
+		return &StatusError{Status: http.StatusNotFound, Message: "user not found"}
+	}))
+
+	tests := []struct {
+		path    string
+		status  int
+		code    string
+		message string
+	}{
+		{"/panic", http.StatusInternalServerError, "internal_server_error", "Internal Server Error"},
+		{"/error", http.StatusInternalServerError, "internal_server_error", "Internal Server Error"},
+		{"/status", http.StatusNotFound, "not_found", "user not found"},
+	}
+
+	wantKeys := []string{"code", "message", "request_id", "status"}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req.Header.Set("X-Request-ID", "req-1")
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+
+			var env map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &env); err != nil {
+				t.Fatalf("invalid JSON %q: %v", rec.Body.String(), err)
+			}
+
+			if keys := slices.Sorted(maps.Keys(env)); !slices.Equal(keys, wantKeys) {
+				t.Errorf("keys = %v, want %v", keys, wantKeys)
+			}
+
+			if env["status"] != float64(tt.status) || env["code"] != tt.code || env["message"] != tt.message || env["request_id"] != "req-1" {
+				t.Errorf("envelope = %v", env)
+			}
+		})
+	}
+}
+
+func TestRecovererCommitted(t *testing.T) {
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(io.Discard)
+
+	mux := NewMux()
+	mux.Use(Recoverer())
+	mux.HandleFunc("GET /partial", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic("boom")
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/partial", nil))
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "partial" {
+		t.Errorf("response = %d %q, want %d %q", rec.Code, rec.Body.String(), http.StatusOK, "partial")
+	}
+}
+
+func TestHandleErrorCustomRenderer(t *testing.T) {
+	render := func(w http.ResponseWriter, r *http.Request, err error) {
+		http.Error(w, "custom: "+err.Error(), http.StatusTeapot)
+	}
+
+	handler := HandleError(func(w http.ResponseWriter, r *http.Request) error {
+		return errors.New("failed")
+	}, render)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+
+	if rec.Code != http.StatusTeapot || rec.Body.String() != "custom: failed\n" {
+		t.Errorf("response = %d %q", rec.Code, rec.Body.String())
+	}
+}
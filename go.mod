@@ -1,3 +1,5 @@
 module github.com/h3go/h3
 
 go 1.25.5
+
+require golang.org/x/sync v0.22.0
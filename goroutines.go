@@ -0,0 +1,97 @@
+package h3
+
+import (
+	"log"
+	"net/http"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// GoroutineConfig TrackGoroutines 中间件的配置
+type GoroutineConfig struct {
+	// Threshold 同一路由连续多少次请求之后 goroutine 数量都增长时发出警告。
+	// 如果为零，使用 5。
+	Threshold int
+
+	// Warn 发出警告的函数，参数为触发警告的请求、路由模式和连续增长期间累计增加的 goroutine 数量。
+	// 如果为 nil，通过 log 包记录。
+	Warn func(r *http.Request, pattern string, growth int)
+}
+
+// TrackGoroutines 创建检测处理器 goroutine 泄漏的诊断中间件
+//
+// 中间件记录每个请求前后 runtime.NumGoroutine 的差值，
+// 同一路由模式连续 Threshold 次请求都使 goroutine 数量增长时，
+// 说明处理器启动的 goroutine 可能在请求结束后仍未退出，此时调用 Warn 并重新计数。
+//
+// goroutine 数量是进程级的，为了减少干扰，只有在处理期间没有其他被跟踪的请求
+// 并发执行时才会计入；这只是帮助定位泄漏的诊断手段，既不保证发现所有泄漏，
+// 也可能因为其他后台 goroutine 产生误报。调用 runtime.NumGoroutine 的开销很小，
+// 但在高并发下大多数请求不会被计入，更适合在测试或预发布环境中使用。
+//
+// 示例:
+//
+//	mux.Use(h3.TrackGoroutines())
+func TrackGoroutines(config ...GoroutineConfig) func(http.Handler) http.Handler {
+	var cfg GoroutineConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Threshold <= 0 {
+		cfg.Threshold = 5
+	}
+	if cfg.Warn == nil {
+		cfg.Warn = func(r *http.Request, pattern string, growth int) {
+			log.Printf("h3: possible goroutine leak in %q: goroutines grew by %d over %d consecutive requests", pattern, growth, cfg.Threshold)
+		}
+	}
+
+	var inflight, starts atomic.Int64
+
+	type streak struct {
+		count, growth int
+	}
+	var mu sync.Mutex
+	streaks := make(map[string]*streak)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			alone := inflight.Add(1) == 1
+			seq := starts.Add(1)
+			before := runtime.NumGoroutine()
+
+			defer inflight.Add(-1)
+			next.ServeHTTP(w, r)
+
+			delta := runtime.NumGoroutine() - before
+			// 处理期间有其他请求开始时，差值不可信
+			if !alone || starts.Load() != seq {
+				return
+			}
+
+			mu.Lock()
+			s := streaks[r.Pattern]
+			if s == nil {
+				s = &streak{}
+				streaks[r.Pattern] = s
+			}
+			if delta <= 0 {
+				*s = streak{}
+				mu.Unlock()
+				return
+			}
+			s.count++
+			s.growth += delta
+			growth, fire := s.growth, s.count >= cfg.Threshold
+			if fire {
+				*s = streak{}
+			}
+			mu.Unlock()
+
+			if fire {
+				cfg.Warn(r, r.Pattern, growth)
+			}
+		})
+	}
+}
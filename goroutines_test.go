@@ -0,0 +1,57 @@
+package h3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTrackGoroutines(t *testing.T) {
+	type warning struct {
+		pattern string
+		growth  int
+	}
+	var warnings []warning
+
+	release := make(chan struct{})
+	defer close(release)
+
+	mux := NewMux()
+	mux.Use(TrackGoroutines(GoroutineConfig{
+		Threshold: 3,
+		Warn: func(r *http.Request, pattern string, growth int) {
+			warnings = append(warnings, warning{pattern, growth})
+		},
+	}))
+	mux.HandleFunc("GET /leak", func(w http.ResponseWriter, r *http.Request) {
+		go func() { <-release }()
+	})
+	mux.HandleFunc("GET /clean", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	serve := func(path string) {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	for range 10 {
+		serve("/clean")
+	}
+	if len(warnings) != 0 {
+		t.Fatalf("warnings for clean handler = %v, want none", warnings)
+	}
+
+	serve("/leak")
+	serve("/leak")
+	if len(warnings) != 0 {
+		t.Fatalf("warnings before threshold = %v, want none", warnings)
+	}
+
+	serve("/leak")
+	if len(warnings) != 1 {
+		t.Fatalf("warnings = %v, want 1", warnings)
+	}
+	if warnings[0] != (warning{"GET /leak", 3}) {
+		t.Errorf("warning = %+v, want %+v", warnings[0], warning{"GET /leak", 3})
+	}
+}
@@ -0,0 +1,118 @@
+package h3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"slices"
+)
+
+// RegisterGroup 注册一组作为整体启动和停止的应用组件
+//
+// 每个组件的路由与 Register 一样挂载到其前缀下。组件中实现了 Servlet 接口的部分
+// 被合并为一个名为 name 的 Servlet，在应用的 Servlet 列表中占据一个位置：
+//   - 启动时按组件顺序依次启动，任何一个失败时逆序停止组内已经启动的 Servlet，
+//     整个组视为启动失败，应用启动随之失败；返回的错误包含组名、启动错误和回滚时的 Stop 错误
+//   - 停止时组内的 Servlet 逆序停止，返回所有 Stop 错误的合并
+//   - 组内所有实现了 ReadyChecker 的 Servlet 都就绪时，组才视为就绪
+//   - 组内实现了 AddressAwareServlet 的 Servlet 都会收到监听地址
+//
+// 适用于多个组件共同组成一个逻辑子系统、需要原子地启动和停止的场景。
+// name 用于 App.Servlets 和生命周期事件，不能为空。
+//
+// 示例:
+//
+//	app.RegisterGroup("billing", invoices, payments, ledger)
+func (a *App) RegisterGroup(name string, components ...Component) {
+	if name == "" {
+		panic(errors.New("h3: invalid group name"))
+	}
+	a.checkComponents(len(components))
+
+	g := &servletGroup{name: name}
+	for _, c := range components {
+		a.mux.Mount(c.Prefix(), c.Mux())
+
+		s, isServ := c.(Servlet)
+		if isServ && !g.contains(s) {
+			g.servs = append(g.servs, s)
+		}
+		a.comps = append(a.comps, ComponentInfo{Prefix: c.Prefix(), Servlet: isServ})
+	}
+
+	if len(g.servs) > 0 {
+		a.addServlet(g)
+	}
+}
+
+// servletGroup 作为整体启动和停止的一组 Servlet
+type servletGroup struct {
+	name  string
+	servs []Servlet
+}
+
+// Name 返回组的名称
+func (g *servletGroup) Name() string {
+	return g.name
+}
+
+// Start 依次启动组内的 Servlet，失败时逆序停止已经启动的 Servlet
+//
+// 返回的错误合并了启动错误和回滚时的 Stop 错误，组内的 Servlet 停止失败时不会被掩盖。
+func (g *servletGroup) Start(ctx context.Context) error {
+	for i, s := range g.servs {
+		if err := s.Start(ctx); err != nil {
+			errs := []error{fmt.Errorf("h3: group %q: %w", g.name, err)}
+			for _, started := range slices.Backward(g.servs[:i]) {
+				if err := started.Stop(); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			return errors.Join(errs...)
+		}
+	}
+	return nil
+}
+
+// Stop 逆序停止组内的 Servlet，返回所有 Stop 错误的合并
+func (g *servletGroup) Stop() error {
+	var errs []error
+	for _, s := range slices.Backward(g.servs) {
+		if err := s.Stop(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Ready 组内所有 Servlet 都就绪时返回 true
+func (g *servletGroup) Ready() bool {
+	for _, s := range g.servs {
+		if !servletReady(s) {
+			return false
+		}
+	}
+	return true
+}
+
+// SetAddr 将监听地址传给组内实现了 AddressAwareServlet 的 Servlet
+func (g *servletGroup) SetAddr(addr net.Addr) {
+	for _, s := range g.servs {
+		servletSetAddr(s, addr)
+	}
+}
+
+// contains 判断 Servlet 实例是否已经在组内
+func (g *servletGroup) contains(s Servlet) bool {
+	if !reflect.TypeOf(s).Comparable() {
+		return false
+	}
+	for _, existing := range g.servs {
+		if reflect.TypeOf(existing).Comparable() && existing == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package h3
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// tracedComponent 创建在 trace 中记录 Start 和 Stop 调用的 Servlet 组件
+func tracedComponent(trace *[]string, name string, startErr error) Component {
+	c := NewComponent("/" + name)
+	c.Mux().HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(name))
+	})
+	return ServletFromComponent(c, ServletFunc(
+		func(ctx context.Context) error {
+			*trace = append(*trace, "start "+name)
+			return startErr
+		},
+		func() error {
+			*trace = append(*trace, "stop "+name)
+			return nil
+		},
+	))
+}
+
+func TestAppRegisterGroup(t *testing.T) {
+	var trace []string
+	app := New(NewMux())
+	app.RegisterGroup("billing",
+		tracedComponent(&trace, "invoices", nil),
+		NewComponent("/static"),
+		tracedComponent(&trace, "payments", nil),
+	)
+
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest("GET", "/payments/", nil))
+	if rec.Body.String() != "payments" {
+		t.Errorf("GET /payments/ = %q, want %q", rec.Body, "payments")
+	}
+	if got := len(app.Components()); got != 3 {
+		t.Errorf("len(Components) = %d, want 3", got)
+	}
+
+	servlets := app.Servlets()
+	if len(servlets) != 1 || servlets[0].Name != "billing" {
+		t.Fatalf("Servlets = %+v, want one group named billing", servlets)
+	}
+
+	if err := app.startServlets(context.Background()); err != nil {
+		t.Fatalf("startServlets = %v", err)
+	}
+	if err := app.stopServlets(); err != nil {
+		t.Fatalf("stopServlets = %v", err)
+	}
+
+	want := []string{"start invoices", "start payments", "stop payments", "stop invoices"}
+	if !slices.Equal(trace, want) {
+		t.Errorf("trace = %q, want %q", trace, want)
+	}
+}
+
+func TestAppRegisterGroupStartFailure(t *testing.T) {
+	var trace []string
+	errStart := errors.New("payments unavailable")
+
+	app := New(NewMux())
+	app.AddServlet(ServletFunc(
+		func(ctx context.Context) error { trace = append(trace, "start cache"); return nil },
+		func() error { trace = append(trace, "stop cache"); return nil },
+	))
+	app.RegisterGroup("billing",
+		tracedComponent(&trace, "invoices", nil),
+		tracedComponent(&trace, "payments", errStart),
+		tracedComponent(&trace, "ledger", nil),
+	)
+
+	if err := app.startServlets(context.Background()); !errors.Is(err, errStart) {
+		t.Fatalf("startServlets = %v, want %v", err, errStart)
+	}
+
+	// 组内已启动的 Servlet 被回滚，之后的 Servlet 没有启动，组外已启动的 Servlet 同样被停止
+	want := []string{"start cache", "start invoices", "start payments", "stop invoices", "stop cache"}
+	if !slices.Equal(trace, want) {
+		t.Errorf("trace = %q, want %q", trace, want)
+	}
+	if got := app.Servlets()[1].State; got != StateFailed {
+		t.Errorf("group state = %q, want %q", got, StateFailed)
+	}
+}
+
+func TestServletGroupRollbackError(t *testing.T) {
+	errStart := errors.New("ledger unavailable")
+	errStop := errors.New("invoices stuck")
+
+	g := &servletGroup{name: "billing", servs: []Servlet{
+		ServletFunc(func(ctx context.Context) error { return nil }, func() error { return errStop }),
+		ServletFunc(func(ctx context.Context) error { return errStart }, func() error { return nil }),
+	}}
+
+	err := g.Start(context.Background())
+	if !errors.Is(err, errStart) || !errors.Is(err, errStop) {
+		t.Fatalf("Start = %v, want both start and rollback errors", err)
+	}
+	if !strings.Contains(err.Error(), `group "billing"`) {
+		t.Errorf("Start = %q, want group name in error", err)
+	}
+}
+
+func TestAppRegisterGroupInvalidName(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for empty group name")
+		}
+	}()
+	New(NewMux()).RegisterGroup("", NewComponent("/a"))
+}
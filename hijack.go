@@ -0,0 +1,110 @@
+package h3
+
+import (
+	"context"
+	"net"
+	"sync"
+)
+
+// RegisterOnShutdown 注册在 Stop 时调用的函数
+//
+// 函数在 Servlet 停止之后、HTTP 服务器关闭之前，在各自的 goroutine 中调用，
+// 用于通知 WebSocket 等被接管的连接主动关闭。
+// http.Server.Shutdown 不会关闭或等待被接管的连接，
+// 配合 Options.WaitHijacked 可以让 Stop 等待这些连接关闭。
+//
+// 示例:
+//
+//	app := h3.New(mux, h3.Options{Addr: ":8080", WaitHijacked: true})
+//	app.RegisterOnShutdown(hub.CloseAll)
+func (a *App) RegisterOnShutdown(f func()) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.onShutdown = append(a.onShutdown, f)
+}
+
+// trackHijacked 记录被接管的连接，直到它被关闭
+//
+// conn 可能是 trackConn 本身，也可能是包装它的 *tls.Conn。
+func (a *App) trackHijacked(conn net.Conn) {
+	for {
+		switch c := conn.(type) {
+		case *trackConn:
+			a.mu.Lock()
+			if !c.closed {
+				if a.hijacked == nil {
+					a.hijacked = make(map[*trackConn]struct{})
+				}
+				a.hijacked[c] = struct{}{}
+			}
+			a.mu.Unlock()
+			return
+		case interface{ NetConn() net.Conn }:
+			conn = c.NetConn()
+		default:
+			return
+		}
+	}
+}
+
+// waitHijacked 等待所有被接管的连接关闭，或者 ctx 结束
+func (a *App) waitHijacked(ctx context.Context) error {
+	for {
+		a.mu.Lock()
+		n := len(a.hijacked)
+		if a.hijackCh == nil {
+			a.hijackCh = make(chan struct{})
+		}
+		ch := a.hijackCh
+		a.mu.Unlock()
+
+		if n == 0 {
+			return nil
+		}
+
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// trackListener 将接受的连接包装为 trackConn
+type trackListener struct {
+	net.Listener
+	app *App
+}
+
+func (l *trackListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &trackConn{Conn: c, app: l.app}, nil
+}
+
+// trackConn 在关闭时通知 App 的连接
+type trackConn struct {
+	net.Conn
+	app    *App
+	once   sync.Once
+	closed bool // 由 app.mu 保护
+}
+
+func (c *trackConn) Close() error {
+	c.once.Do(func() {
+		a := c.app
+		a.mu.Lock()
+		c.closed = true
+		if _, ok := a.hijacked[c]; ok {
+			delete(a.hijacked, c)
+			if a.hijackCh != nil {
+				close(a.hijackCh)
+				a.hijackCh = nil
+			}
+		}
+		a.mu.Unlock()
+	})
+	return c.Conn.Close()
+}
@@ -0,0 +1,81 @@
+package h3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// startHijackApp 启动一个接管 /ws 连接的应用，返回接管到的服务端连接
+func startHijackApp(t *testing.T, addr string) (*App, net.Conn) {
+	t.Helper()
+
+	hijacked := make(chan net.Conn, 1)
+	mux := NewMux()
+	mux.HandleFunc("GET /ws", func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Errorf("Hijack failed: %v", err)
+			return
+		}
+		hijacked <- conn
+	})
+
+	app := New(mux, Options{Addr: addr, WaitHijacked: true})
+	if err := app.Start(context.Background()); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	client, err := net.Dial("tcp", "localhost"+addr)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	fmt.Fprint(client, "GET /ws HTTP/1.1\r\nHost: localhost\r\n\r\n")
+
+	select {
+	case conn := <-hijacked:
+		return app, conn
+	case <-time.After(2 * time.Second):
+		t.Fatal("connection was not hijacked")
+		return nil, nil
+	}
+}
+
+func TestAppWaitHijacked(t *testing.T) {
+	app, conn := startHijackApp(t, ":8115")
+
+	// 关闭信号到达后，模拟 WebSocket 处理器稍后关闭连接
+	app.RegisterOnShutdown(func() {
+		time.Sleep(200 * time.Millisecond)
+		conn.Close()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	if err := app.Stop(ctx); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+
+	if d := time.Since(start); d < 200*time.Millisecond {
+		t.Errorf("Stop returned after %v, want it to wait for the hijacked connection", d)
+	}
+}
+
+func TestAppWaitHijackedTimeout(t *testing.T) {
+	app, conn := startHijackApp(t, ":8116")
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	if err := app.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
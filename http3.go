@@ -0,0 +1,49 @@
+package h3
+
+import (
+	"context"
+	"net"
+	"net/http"
+)
+
+// QUICServer HTTP/3 服务器接口
+//
+// 标准库不提供 HTTP/3 实现，h3 通过此接口接入第三方 QUIC 库，
+// 而不把它们作为强制依赖。启用 Options.EnableHTTP3 后，
+// App 会绑定 UDP 监听并在 Start 和 Stop 时管理服务器的生命周期。
+//
+// 示例（基于 quic-go）:
+//
+//	type quicServer struct {
+//		srv *http3.Server
+//	}
+//
+//	func (q *quicServer) Serve(conn net.PacketConn, handler http.Handler) error {
+//		q.srv.Handler = handler
+//		return q.srv.Serve(conn)
+//	}
+//
+//	func (q *quicServer) Shutdown(ctx context.Context) error {
+//		return q.srv.Shutdown(ctx)
+//	}
+type QUICServer interface {
+	// Serve 在给定的 UDP 连接上提供 HTTP/3 服务，直到服务器关闭
+	//
+	// 服务器正常关闭时应返回 nil 或 http.ErrServerClosed。
+	Serve(conn net.PacketConn, handler http.Handler) error
+
+	// Shutdown 优雅关闭服务器
+	Shutdown(ctx context.Context) error
+}
+
+// altSvc 为 TLS 连接上的响应添加 Alt-Svc 头，通告 HTTP/3 端点
+//
+// HTTP/3 总是基于 TLS，明文连接上的通告会被浏览器忽略，因此不添加。
+func altSvc(next http.Handler, value string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil {
+			w.Header().Set("Alt-Svc", value)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,201 @@
+package h3
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeQUICServer 用于测试的 QUICServer 实现
+type fakeQUICServer struct {
+	mu       sync.Mutex
+	conn     net.PacketConn
+	handler  http.Handler
+	started  chan struct{}
+	stopped  bool
+	shutdown chan struct{}
+}
+
+func newFakeQUICServer() *fakeQUICServer {
+	return &fakeQUICServer{
+		started:  make(chan struct{}),
+		shutdown: make(chan struct{}),
+	}
+}
+
+func (s *fakeQUICServer) Serve(conn net.PacketConn, handler http.Handler) error {
+	s.mu.Lock()
+	s.conn = conn
+	s.handler = handler
+	s.mu.Unlock()
+	close(s.started)
+
+	<-s.shutdown
+	return http.ErrServerClosed
+}
+
+func (s *fakeQUICServer) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopped = true
+	close(s.shutdown)
+	return s.conn.Close()
+}
+
+func TestAppHTTP3(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("GET /test", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	quic := newFakeQUICServer()
+	app := New(mux, Options{
+		Addr:        ":8107",
+		EnableHTTP3: true,
+		HTTP3Server: quic,
+	})
+	client := addTLSTestListener(t, app, ":8128")
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	select {
+	case <-quic.started:
+	case <-time.After(time.Second):
+		t.Fatal("QUIC server was not started")
+	}
+
+	if got := quic.conn.LocalAddr().String(); got != "[::]:8107" && got != "0.0.0.0:8107" {
+		t.Errorf("QUIC listener addr = %q, want port 8107", got)
+	}
+
+	// QUIC 服务器收到的处理器可以正常处理请求
+	rec := httptest.NewRecorder()
+	quic.handler.ServeHTTP(rec, httptest.NewRequest("GET", "/test", nil))
+	if rec.Body.String() != "ok" {
+		t.Errorf("QUIC handler body = %q, want %q", rec.Body.String(), "ok")
+	}
+
+	// 只在 TLS 连接上通告 HTTP/3
+	tests := []struct {
+		url, want string
+	}{
+		{"http://localhost:8107/test", ""},
+		{"https://localhost:8128/test", `h3=":8107"; ma=86400`},
+	}
+	for _, tt := range tests {
+		resp, err := client.Get(tt.url)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", tt.url, err)
+		}
+		resp.Body.Close()
+
+		if got := resp.Header.Get("Alt-Svc"); got != tt.want {
+			t.Errorf("GET %s Alt-Svc = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+
+	if err := app.Stop(ctx); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+
+	quic.mu.Lock()
+	defer quic.mu.Unlock()
+	if !quic.stopped {
+		t.Error("QUIC server was not shut down")
+	}
+}
+
+func TestAppHTTP3CustomAltSvc(t *testing.T) {
+	app := New(NewMux(), Options{
+		Addr:        ":8108",
+		EnableHTTP3: true,
+		HTTP3Server: newFakeQUICServer(),
+		AltSvc:      `h3=":443"; ma=3600`,
+	})
+	client := addTLSTestListener(t, app, ":8129")
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() { _ = app.Stop(ctx) }()
+
+	time.Sleep(100 * time.Millisecond)
+
+	resp, err := client.Get("https://localhost:8129/")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if got, want := resp.Header.Get("Alt-Svc"), `h3=":443"; ma=3600`; got != want {
+		t.Errorf("Alt-Svc = %q, want %q", got, want)
+	}
+}
+
+func TestAppHTTP3WithoutServer(t *testing.T) {
+	app := New(NewMux(), Options{Addr: ":8109", EnableHTTP3: true})
+
+	if err := app.Start(context.Background()); err == nil {
+		t.Fatal("Start should fail when EnableHTTP3 is set without HTTP3Server")
+	}
+
+	ln, err := net.Listen("tcp", ":8109")
+	if err != nil {
+		t.Fatalf("TCP listener not released after failed Start: %v", err)
+	}
+	ln.Close()
+}
+
+func TestAppHTTP3RandomPort(t *testing.T) {
+	quic := newFakeQUICServer()
+	app := New(NewMux(), Options{
+		Addr:        "127.0.0.1:0",
+		EnableHTTP3: true,
+		HTTP3Server: quic,
+	})
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() { _ = app.Stop(ctx) }()
+
+	select {
+	case <-quic.started:
+	case <-time.After(time.Second):
+		t.Fatal("QUIC server was not started")
+	}
+
+	// UDP 监听使用 TCP 实际绑定的端口
+	if got, want := quic.conn.LocalAddr().String(), app.Addr().String(); got != want {
+		t.Errorf("QUIC listener addr = %q, want %q", got, want)
+	}
+}
+
+// addTLSTestListener 为应用添加使用测试证书的 TLS 监听地址，返回信任该证书的客户端
+func addTLSTestListener(t *testing.T, app *App, addr string) *http.Client {
+	t.Helper()
+
+	certPEM, keyPEM := newTestCertificate(t, "localhost")
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("X509KeyPair failed: %v", err)
+	}
+	app.AddListener(addr, &tls.Config{Certificates: []tls.Certificate{cert}})
+
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(certPEM)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	t.Cleanup(client.CloseIdleConnections)
+	return client
+}
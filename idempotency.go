@@ -0,0 +1,216 @@
+package h3
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// IdempotencyStore 幂等键的存储接口
+//
+// 实现可以基于内存、Redis 等，需要保证并发安全，
+// 多实例部署时 Lock 必须在所有实例之间互斥。
+type IdempotencyStore interface {
+	// Lock 尝试获取 key 的执行权
+	//
+	// 如果 key 已经保存了响应，返回该响应；
+	// 否则如果没有其他请求正在执行，加锁并返回 acquired 为 true；
+	// 两者都不满足时表示同一个 key 的请求正在执行中。
+	Lock(ctx context.Context, key string) (resp *CachedResponse, acquired bool, err error)
+
+	// Complete 保存响应并释放锁，响应在 ttl 之后过期
+	Complete(ctx context.Context, key string, resp *CachedResponse, ttl time.Duration)
+
+	// Unlock 释放锁但不保存响应，之后相同 key 的请求会重新执行
+	Unlock(ctx context.Context, key string)
+}
+
+// IdempotencyConfig Idempotency 中间件的配置
+type IdempotencyConfig struct {
+	// Principal 返回标识调用方的字符串，作为幂等键的一部分，
+	// 使不同调用方发送相同的 Idempotency-Key 时互不影响。
+	// 如果为 nil，使用 Authorization 和 Cookie 头的 SHA-256 摘要；
+	// 会话 Cookie 之外还有经常变化的 Cookie 时，应当改为返回用户或会话 ID。
+	Principal func(*http.Request) string
+
+	// MaxBodySize 请求体和保存的响应体的最大字节数，如果为零，使用 1MB。
+	// 请求体超过时返回 413 Request Entity Too Large；
+	// 响应体超过时不保存，之后相同 key 的请求会重新执行。
+	MaxBodySize int
+}
+
+// Idempotency 创建基于 Idempotency-Key 请求头的幂等中间件
+//
+// 对于携带 Idempotency-Key 的 POST、PUT、PATCH 和 DELETE 请求:
+//   - 第一次请求正常执行，响应被捕获并保存 ttl 时长
+//   - 之后相同 key 的请求直接回放保存的响应，并带有 Idempotent-Replayed: true 头，不再执行处理器
+//   - 相同 key 但请求体不同的请求返回 422 Unprocessable Entity
+//   - 相同 key 的请求正在执行时，并发的重复请求返回 409 Conflict
+//
+// 5xx 响应和 panic 不会被保存，客户端可以使用相同的 key 重试。
+// key 按请求方法、路径和调用方（IdempotencyConfig.Principal）隔离，
+// 不同端点或不同调用方使用相同的 key 互不影响。
+// 请求体会被完整读入内存以计算摘要，处理器仍然可以读取完整的请求体。
+// 存储出错时返回 500。
+//
+// 示例:
+//
+//	store := h3.NewMemoryIdempotencyStore()
+//	mux.Handle("POST /payments", h3.Idempotency(store, 24*time.Hour)(createPayment))
+func Idempotency(store IdempotencyStore, ttl time.Duration, config ...IdempotencyConfig) func(http.Handler) http.Handler {
+	var cfg IdempotencyConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Principal == nil {
+		cfg.Principal = credentialDigest
+	}
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = 1 << 20
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			idemKey := r.Header.Get("Idempotency-Key")
+			if idemKey == "" || !idempotentMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var body []byte
+			if r.Body != nil && r.Body != http.NoBody {
+				var err error
+				body, err = io.ReadAll(http.MaxBytesReader(w, r.Body, int64(cfg.MaxBodySize)))
+				if err != nil {
+					code := http.StatusBadRequest
+					if _, ok := err.(*http.MaxBytesError); ok {
+						code = http.StatusRequestEntityTooLarge
+					}
+					http.Error(w, http.StatusText(code), code)
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+			sum := sha256.Sum256(body)
+			fingerprint := hex.EncodeToString(sum[:])
+
+			key := r.Method + " " + r.Host + r.URL.Path + " " + cfg.Principal(r) + " " + idemKey
+			ctx := r.Context()
+
+			stored, acquired, err := store.Lock(ctx, key)
+			switch {
+			case err != nil:
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			case stored != nil && stored.RequestHash != fingerprint:
+				http.Error(w, "idempotency key reused with a different request body", http.StatusUnprocessableEntity)
+				return
+			case stored != nil:
+				w.Header().Set("Idempotent-Replayed", "true")
+				replay(w, stored)
+				return
+			case !acquired:
+				http.Error(w, "request with the same idempotency key is in progress", http.StatusConflict)
+				return
+			}
+
+			completed := false
+			defer func() {
+				// 处理器 panic 或返回 5xx 时释放锁，允许重试
+				if !completed {
+					store.Unlock(context.WithoutCancel(ctx), key)
+				}
+			}()
+
+			rw := &bodyCapture{Response: NewResponse(w), limit: cfg.MaxBodySize}
+			next.ServeHTTP(rw, r)
+
+			if rw.Status() >= http.StatusInternalServerError || rw.Hijacked() || rw.truncated {
+				return
+			}
+
+			store.Complete(context.WithoutCancel(ctx), key, &CachedResponse{
+				Status:      rw.Status(),
+				Header:      rw.Header().Clone(),
+				Body:        rw.buf.Bytes(),
+				RequestHash: fingerprint,
+			}, ttl)
+			completed = true
+		})
+	}
+}
+
+// credentialDigest 返回请求的 Authorization 和 Cookie 头的 SHA-256 摘要，没有凭据时返回空字符串
+func credentialDigest(r *http.Request) string {
+	if !hasCredentials(r) {
+		return ""
+	}
+	h := sha256.New()
+	io.WriteString(h, r.Header.Get("Authorization"))
+	h.Write([]byte{0})
+	io.WriteString(h, strings.Join(r.Header.Values("Cookie"), "; "))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// idempotentMethod 判断请求方法是否需要幂等处理
+func idempotentMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// NewMemoryIdempotencyStore 创建基于内存的幂等键存储
+//
+// 过期的条目在读取时惰性删除，适用于单实例部署和测试。
+func NewMemoryIdempotencyStore() IdempotencyStore {
+	return &memoryIdempotencyStore{
+		locked:  make(map[string]struct{}),
+		entries: make(map[string]memoryCacheEntry),
+	}
+}
+
+// memoryIdempotencyStore 基于内存的 IdempotencyStore 实现
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	locked  map[string]struct{}
+	entries map[string]memoryCacheEntry
+}
+
+func (s *memoryIdempotencyStore) Lock(ctx context.Context, key string) (*CachedResponse, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[key]; ok {
+		if time.Now().Before(entry.expires) {
+			return entry.resp, false, nil
+		}
+		delete(s.entries, key)
+	}
+
+	if _, ok := s.locked[key]; ok {
+		return nil, false, nil
+	}
+	s.locked[key] = struct{}{}
+	return nil, true, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, key string, resp *CachedResponse, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[key] = memoryCacheEntry{resp: resp, expires: time.Now().Add(ttl)}
+	delete(s.locked, key)
+}
+
+func (s *memoryIdempotencyStore) Unlock(ctx context.Context, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.locked, key)
+}
@@ -0,0 +1,263 @@
+package h3
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIdempotency(t *testing.T) {
+	var calls atomic.Int32
+	mux := NewMux()
+	mux.Handle("POST /payments", Idempotency(NewMemoryIdempotencyStore(), time.Minute)(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			n := calls.Add(1)
+			w.Header().Set("X-Payment", fmt.Sprint(n))
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, "payment %d", n)
+		})))
+
+	post := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/payments", nil)
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// 第一次请求正常执行
+	first := post("key-1")
+	if first.Code != http.StatusCreated || first.Body.String() != "payment 1" {
+		t.Fatalf("first = %d %q, want %d %q", first.Code, first.Body.String(), http.StatusCreated, "payment 1")
+	}
+	if first.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("first response should not be marked as replayed")
+	}
+
+	// 重试回放保存的响应
+	replayed := post("key-1")
+	if replayed.Code != http.StatusCreated || replayed.Body.String() != "payment 1" || replayed.Header().Get("X-Payment") != "1" {
+		t.Errorf("replay = %d %q, want %d %q", replayed.Code, replayed.Body.String(), http.StatusCreated, "payment 1")
+	}
+	if replayed.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("replayed response should be marked as replayed")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("handler called %d times, want 1", got)
+	}
+
+	// 不同的 key 和没有 key 的请求都会执行
+	if rec := post("key-2"); rec.Body.String() != "payment 2" {
+		t.Errorf("new key body = %q, want %q", rec.Body.String(), "payment 2")
+	}
+	if rec := post(""); rec.Body.String() != "payment 3" {
+		t.Errorf("no key body = %q, want %q", rec.Body.String(), "payment 3")
+	}
+}
+
+func TestIdempotencyConcurrent(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	entered := make(chan struct{})
+
+	handler := Idempotency(NewMemoryIdempotencyStore(), time.Minute)(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			close(entered)
+			<-release
+			w.Write([]byte("done"))
+		}))
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/orders", nil)
+		req.Header.Set("Idempotency-Key", "same")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	var wg sync.WaitGroup
+	var first *httptest.ResponseRecorder
+	wg.Go(func() { first = serve() })
+
+	<-entered
+
+	// 第一个请求仍在执行，重复请求被拒绝
+	const duplicates = 5
+	codes := make([]int, duplicates)
+	var dup sync.WaitGroup
+	for i := range duplicates {
+		dup.Go(func() { codes[i] = serve().Code })
+	}
+	dup.Wait()
+
+	close(release)
+	wg.Wait()
+
+	for i, code := range codes {
+		if code != http.StatusConflict {
+			t.Errorf("duplicate %d status = %d, want %d", i, code, http.StatusConflict)
+		}
+	}
+
+	if first.Code != http.StatusOK || first.Body.String() != "done" {
+		t.Errorf("first = %d %q", first.Code, first.Body.String())
+	}
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("handler called %d times, want 1", got)
+	}
+}
+
+func TestIdempotencyServerError(t *testing.T) {
+	var calls atomic.Int32
+	handler := Idempotency(NewMemoryIdempotencyStore(), time.Minute)(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte("ok"))
+		}))
+
+	for _, want := range []int{http.StatusServiceUnavailable, http.StatusOK, http.StatusOK} {
+		req := httptest.NewRequest("POST", "/orders", nil)
+		req.Header.Set("Idempotency-Key", "retry")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != want {
+			t.Errorf("status = %d, want %d", rec.Code, want)
+		}
+	}
+
+	// 5xx 响应不会被保存，重试会再次执行，之后的成功响应被回放
+	if got := calls.Load(); got != 2 {
+		t.Errorf("handler called %d times, want 2", got)
+	}
+}
+
+func TestIdempotencyServeContent(t *testing.T) {
+	var calls atomic.Int32
+	handler := Idempotency(NewMemoryIdempotencyStore(), time.Minute)(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			http.ServeContent(w, r, "receipt.txt", time.Time{}, strings.NewReader("receipt"))
+		}))
+
+	for i := range 2 {
+		req := httptest.NewRequest("POST", "/receipts", nil)
+		req.Header.Set("Idempotency-Key", "k")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK || rec.Body.String() != "receipt" {
+			t.Errorf("request %d = %d %q, want 200 %q", i, rec.Code, rec.Body.String(), "receipt")
+		}
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("handler called %d times, want 1", got)
+	}
+}
+
+func TestIdempotencyProblem(t *testing.T) {
+	handler := Idempotency(NewMemoryIdempotencyStore(), time.Minute)(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			WriteProblem(w, http.StatusConflict, ProblemDetails{Detail: "email already registered"})
+		}))
+
+	var bodies []string
+	for range 2 {
+		req := httptest.NewRequest("POST", "/users", nil)
+		req.Header.Set("Idempotency-Key", "k")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusConflict {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusConflict)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+			t.Errorf("Content-Type = %q, want application/problem+json", ct)
+		}
+		bodies = append(bodies, rec.Body.String())
+	}
+	if !strings.Contains(bodies[1], "email already registered") || bodies[0] != bodies[1] {
+		t.Errorf("replayed body = %q, want %q", bodies[1], bodies[0])
+	}
+}
+
+func TestIdempotencyScope(t *testing.T) {
+	var calls atomic.Int32
+	handler := Idempotency(NewMemoryIdempotencyStore(), time.Minute)(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			fmt.Fprintf(w, "%d:%s:%s", calls.Add(1), r.Header.Get("Authorization"), body)
+		}))
+
+	post := func(auth, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/transfers", strings.NewReader(body))
+		req.Header.Set("Idempotency-Key", "same-key")
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// 不同调用方的相同 key 互不影响
+	if rec := post("Bearer alice", `{"amount":1}`); rec.Body.String() != `1:Bearer alice:{"amount":1}` {
+		t.Errorf("alice = %q", rec.Body.String())
+	}
+	if rec := post("Bearer bob", `{"amount":1}`); rec.Body.String() != `2:Bearer bob:{"amount":1}` {
+		t.Errorf("bob = %q, want a separate execution", rec.Body.String())
+	}
+	if rec := post("Bearer alice", `{"amount":1}`); rec.Body.String() != `1:Bearer alice:{"amount":1}` {
+		t.Errorf("alice retry = %q, want replay", rec.Body.String())
+	}
+
+	// 相同 key 但请求体不同
+	rec := post("Bearer alice", `{"amount":100}`)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("changed body status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("handler called %d times, want 2", got)
+	}
+}
+
+func TestIdempotencyMaxBodySize(t *testing.T) {
+	var calls atomic.Int32
+	handler := Idempotency(NewMemoryIdempotencyStore(), time.Minute, IdempotencyConfig{MaxBodySize: 8})(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.Write([]byte("a large response body"))
+		}))
+
+	post := func(body string) int {
+		req := httptest.NewRequest("POST", "/exports", strings.NewReader(body))
+		req.Header.Set("Idempotency-Key", "k")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := post("too large request"); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("large request status = %d, want %d", code, http.StatusRequestEntityTooLarge)
+	}
+
+	// 超过上限的响应不保存，重试会再次执行
+	post("small")
+	post("small")
+	if got := calls.Load(); got != 2 {
+		t.Errorf("handler called %d times, want 2", got)
+	}
+}
@@ -0,0 +1,234 @@
+package h3
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// ServletState Servlet 的生命周期状态
+type ServletState string
+
+const (
+	// StateRegistered 已添加到应用，尚未启动
+	StateRegistered ServletState = "registered"
+
+	// StateRunning Start 成功返回
+	StateRunning ServletState = "running"
+
+	// StateFailed Start 返回了错误
+	StateFailed ServletState = "failed"
+
+	// StateStopped Stop 已被调用，无论是否返回错误
+	StateStopped ServletState = "stopped"
+)
+
+// ComponentInfo 已注册组件的描述
+type ComponentInfo struct {
+	Prefix  string // 组件挂载的路径前缀，RegisterVersioned 注册的组件包含版本号
+	Version string // RegisterVersioned 的版本号，普通注册时为空
+	Servlet bool   // 组件是否实现了 Servlet 接口
+}
+
+// ServletInfo Servlet 的描述和当前状态
+type ServletInfo struct {
+	Name  string       // Servlet 的 Name() 返回值，未实现时为类型名
+	State ServletState // 当前的生命周期状态
+}
+
+// Components 返回按注册顺序排列的已注册组件
+//
+// 包括通过 Register、RegisterErr 和 RegisterVersioned 注册的组件，
+// 可用于实现管理或诊断端点。
+func (a *App) Components() []ComponentInfo {
+	return slices.Clone(a.comps)
+}
+
+// Servlets 返回按启动顺序排列的 Servlet 及其当前状态
+//
+// 包括组件提供的 Servlet 和通过 AddServlet 添加的 Servlet。
+// 可以在处理请求时并发调用。
+//
+// 示例:
+//
+//	mux.HandleFunc("GET /admin/servlets", func(w http.ResponseWriter, r *http.Request) {
+//		json.NewEncoder(w).Encode(app.Servlets())
+//	})
+func (a *App) Servlets() []ServletInfo {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	infos := make([]ServletInfo, len(a.servs))
+	for i, s := range a.servs {
+		infos[i] = ServletInfo{Name: servletName(s), State: a.states[i]}
+	}
+	return infos
+}
+
+// RouteInfo 已注册路由的描述
+type RouteInfo struct {
+	Method     string   `json:"method,omitempty"`     // 路由模式中的方法，未限定方法时为空
+	Pattern    string   `json:"pattern"`              // 包含挂载前缀的完整路径模式，不含方法
+	Mount      string   `json:"mount,omitempty"`      // 路由所在子路由的挂载前缀，顶层路由为空
+	Handler    string   `json:"handler"`              // 处理器名称：函数的完整名称或处理器的类型名
+	Middleware []string `json:"middleware,omitempty"` // 对该路由生效的中间件名称，由外向内排列
+}
+
+// anonymousMiddleware 匿名中间件在诊断信息中使用的名称
+const anonymousMiddleware = "anonymous"
+
+// Routes 返回按注册顺序排列的所有路由
+//
+// 挂载的组件和子路由被展开为其中的每一条路由，模式包含挂载前缀。
+// Middleware 列出对该路由生效的中间件：通过 UseNamed 注册的使用其名称，
+// 其他中间件记为 "anonymous"；通过 HandleWith 注册的路由不包含所在路由器的中间件链。
+//
+// 示例:
+//
+//	for _, rt := range app.Routes() {
+//		fmt.Println(rt.Method, rt.Pattern)
+//	}
+func (a *App) Routes() []RouteInfo {
+	var infos []RouteInfo
+	a.mux.Walk(func(rt RouteInfo) error {
+		infos = append(infos, rt)
+		return nil
+	})
+	return infos
+}
+
+// routes 展开路由器中的路由，prefix 为挂载前缀，chain 为外层生效的中间件名称
+func (m *mux) routes(prefix string, chain []string) []RouteInfo {
+	names := append(slices.Clone(chain), m.middlewareNames()...)
+
+	var infos []RouteInfo
+	for _, rt := range m.rts {
+		method, pattern := joinPattern(prefix, rt.pattern)
+		if sub, ok := rt.sub.(*mux); ok {
+			mount := strings.TrimSuffix(pattern, "/{path...}")
+			if mount == "/" {
+				mount = prefix
+			}
+			infos = append(infos, sub.routes(mount, names)...)
+			continue
+		}
+
+		name := rt.name
+		if name == "" {
+			name = handlerName(rt.handler)
+		}
+		info := RouteInfo{Method: method, Pattern: pattern, Mount: prefix, Handler: name, Middleware: names}
+		if m.bkd[rt.pattern] {
+			info.Middleware = chain
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// Walk 按注册顺序遍历所有路由
+//
+// 挂载的子路由被展开为其中的每一条路由，模式包含逐层组合的挂载前缀，
+// 每条路由恰好被访问一次。RouteInfo 的字段含义与 App.Routes 相同。
+// fn 返回错误时停止遍历并返回该错误。
+//
+// 示例:
+//
+//	err := mux.Walk(func(rt h3.RouteInfo) error {
+//		fmt.Printf("%-6s %-30s %s\n", rt.Method, rt.Pattern, rt.Handler)
+//		return nil
+//	})
+func (m *mux) Walk(fn func(RouteInfo) error) error {
+	for _, rt := range m.routes("", nil) {
+		if err := fn(rt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handlerName 返回处理器的名称
+//
+// http.HandlerFunc 使用函数的完整名称，例如 "main.listUsers"，
+// 其他处理器使用类型名，例如 "*main.UserHandler"。
+func handlerName(h http.Handler) string {
+	if f, ok := h.(http.HandlerFunc); ok {
+		if fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer()); fn != nil {
+			return fn.Name()
+		}
+	}
+	return fmt.Sprintf("%T", h)
+}
+
+// middlewareNames 返回路由器中间件链的名称，由外向内排列
+func (m *mux) middlewareNames() []string {
+	names := make([]string, len(m.mws))
+	for i, mw := range m.mws {
+		names[i] = mw.name
+		if names[i] == "" {
+			names[i] = anonymousMiddleware
+		}
+	}
+	return names
+}
+
+// joinPattern 拆分路由模式中的方法，并在路径前加上挂载前缀
+func joinPattern(prefix, pattern string) (method, full string) {
+	if i := strings.IndexAny(pattern, " \t"); i >= 0 {
+		method, pattern = pattern[:i], strings.TrimLeft(pattern[i:], " \t")
+	}
+	host, path := "", pattern
+	if i := strings.IndexByte(pattern, '/'); i > 0 {
+		host, path = pattern[:i], pattern[i:]
+	}
+	if prefix != "" && path == "/" {
+		path = ""
+	}
+	return method, host + prefix + path
+}
+
+// RoutesHandler 返回以 JSON 列出路由、中间件和组件的诊断处理器
+//
+// 响应包含三部分：
+//   - middleware: 应用的全局中间件，由外向内排列
+//   - routes: Routes 的返回值
+//   - components: Components 的返回值
+//
+// 路由表会暴露应用的内部结构，生产环境中应通过 middleware 加以保护，
+// 例如认证或仅允许内网访问；middleware 按 Chain 的顺序包装处理器。
+// 路由表在每次请求时重新生成，反映当时的注册情况。
+//
+// 示例:
+//
+//	app.Handle("GET /debug/routes", app.RoutesHandler(adminOnly))
+func (a *App) RoutesHandler(middleware ...func(http.Handler) http.Handler) http.Handler {
+	type component struct {
+		Prefix  string `json:"prefix"`
+		Version string `json:"version,omitempty"`
+		Servlet bool   `json:"servlet"`
+	}
+
+	return Chain(middleware...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Middleware []string    `json:"middleware"`
+			Routes     []RouteInfo `json:"routes"`
+			Components []component `json:"components"`
+		}
+		body.Middleware = []string{}
+		body.Routes = a.Routes()
+		body.Components = []component{}
+		if m, ok := a.mux.(*mux); ok {
+			body.Middleware = append(body.Middleware, m.middlewareNames()...)
+		}
+		for _, c := range a.Components() {
+			body.Components = append(body.Components, component(c))
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		json.NewEncoder(w).Encode(body)
+	}))
+}
@@ -0,0 +1,192 @@
+package h3
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestAppComponents(t *testing.T) {
+	app := New(NewMux())
+
+	app.Register(NewComponent("/users"))
+	app.Register(newMockServletComponent("/jobs"))
+	app.RegisterVersioned("v2", NewComponent("/orders"))
+
+	want := []ComponentInfo{
+		{Prefix: "/users"},
+		{Prefix: "/jobs", Servlet: true},
+		{Prefix: "/v2/orders", Version: "v2"},
+	}
+	if got := app.Components(); !slices.Equal(got, want) {
+		t.Errorf("Components = %+v, want %+v", got, want)
+	}
+
+	// 注册失败的组件不会出现在列表中
+	if err := app.RegisterErr(NewComponent("/users")); err == nil {
+		t.Fatal("expected conflict registering /users twice")
+	}
+	if got := len(app.Components()); got != len(want) {
+		t.Errorf("len(Components) = %d, want %d", got, len(want))
+	}
+}
+
+func TestAppServlets(t *testing.T) {
+	app := New(NewMux(), Options{Addr: "127.0.0.1:8124"})
+
+	app.AddServlet(newNamedServlet("db", nil))
+	app.AddServlet(newNamedServlet("cache", nil))
+
+	states := func() []ServletInfo { return app.Servlets() }
+
+	want := []ServletInfo{{"db", StateRegistered}, {"cache", StateRegistered}}
+	if got := states(); !slices.Equal(got, want) {
+		t.Errorf("before Start: %+v, want %+v", got, want)
+	}
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	want = []ServletInfo{{"db", StateRunning}, {"cache", StateRunning}}
+	if got := states(); !slices.Equal(got, want) {
+		t.Errorf("after Start: %+v, want %+v", got, want)
+	}
+
+	if err := app.Stop(ctx); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	want = []ServletInfo{{"db", StateStopped}, {"cache", StateStopped}}
+	if got := states(); !slices.Equal(got, want) {
+		t.Errorf("after Stop: %+v, want %+v", got, want)
+	}
+}
+
+func TestAppServletsFailedStart(t *testing.T) {
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(io.Discard)
+
+	app := New(NewMux(), Options{Addr: "127.0.0.1:8124"})
+
+	app.AddServlet(newNamedServlet("db", nil))
+	app.AddServlet(newNamedServlet("broken", func(context.Context) error { return errors.New("boom") }))
+	app.AddServlet(newNamedServlet("cache", nil))
+
+	if err := app.Start(context.Background()); err == nil {
+		t.Fatal("expected Start to fail")
+	}
+
+	// 已启动的 Servlet 被回滚停止，之后的 Servlet 没有启动
+	want := []ServletInfo{{"db", StateStopped}, {"broken", StateFailed}, {"cache", StateRegistered}}
+	if got := app.Servlets(); !slices.Equal(got, want) {
+		t.Errorf("Servlets = %+v, want %+v", got, want)
+	}
+}
+
+func TestAppRoutes(t *testing.T) {
+	noop := func(next http.Handler) http.Handler { return next }
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+
+	app := New(NewMux())
+	app.mux.UseNamed("requestid", noop)
+	app.Use(noop)
+	app.HandleFunc("GET /healthz", ok)
+	app.mux.HandleWith("GET /ping", http.HandlerFunc(ok))
+
+	users := NewComponent("/users")
+	users.Mux().UseNamed("auth", noop)
+	users.Mux().HandleFunc("GET /{id}", ok)
+	users.Mux().HandleFunc("DELETE /{id}", ok)
+	app.Register(users)
+
+	orders := NewComponent("/orders")
+	orders.Mux().HandleFunc("POST /", ok)
+	app.RegisterVersioned("v2", orders)
+
+	global := []string{"requestid", "anonymous"}
+	name := "github.com/h3go/h3.TestAppRoutes.func2"
+	want := []RouteInfo{
+		{Method: "GET", Pattern: "/healthz", Handler: name, Middleware: global},
+		{Method: "GET", Pattern: "/ping", Handler: name},
+		{Method: "GET", Pattern: "/users/{id}", Mount: "/users", Handler: name, Middleware: append(slices.Clone(global), "auth")},
+		{Method: "DELETE", Pattern: "/users/{id}", Mount: "/users", Handler: name, Middleware: append(slices.Clone(global), "auth")},
+		{Method: "POST", Pattern: "/v2/orders", Mount: "/v2/orders", Handler: name, Middleware: append(slices.Clone(global), "anonymous")},
+	}
+	if got := app.Routes(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Routes =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestAppRoutesHandler(t *testing.T) {
+	app := New(NewMux())
+	app.mux.UseNamed("logger", func(next http.Handler) http.Handler { return next })
+
+	users := NewComponent("/users")
+	users.Mux().HandleFunc("GET /{id}", func(w http.ResponseWriter, r *http.Request) {})
+	app.Register(users)
+	app.Register(newMockServletComponent("/jobs"))
+
+	guard := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Admin") != "yes" {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	app.Handle("GET /debug/routes", app.RoutesHandler(guard))
+
+	rec := httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/routes", nil))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("unguarded status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	req := httptest.NewRequest("GET", "/debug/routes", nil)
+	req.Header.Set("X-Admin", "yes")
+	rec = httptest.NewRecorder()
+	app.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	var body struct {
+		Middleware []string
+		Routes     []RouteInfo
+		Components []struct {
+			Prefix  string
+			Servlet bool
+		}
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, rec.Body)
+	}
+
+	if !slices.Equal(body.Middleware, []string{"logger"}) {
+		t.Errorf("middleware = %q, want [logger]", body.Middleware)
+	}
+	patterns := make([]string, len(body.Routes))
+	for i, rt := range body.Routes {
+		patterns[i] = rt.Method + " " + rt.Pattern
+	}
+	for _, want := range []string{"GET /users/{id}", "GET /debug/routes"} {
+		if !slices.Contains(patterns, want) {
+			t.Errorf("routes %q missing %q", patterns, want)
+		}
+	}
+	if len(body.Components) != 2 || body.Components[0].Prefix != "/users" ||
+		body.Components[1].Prefix != "/jobs" || !body.Components[1].Servlet {
+		t.Errorf("components = %+v", body.Components)
+	}
+}
@@ -0,0 +1,153 @@
+package h3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// LifecycleEventType 生命周期事件的类型
+type LifecycleEventType string
+
+const (
+	// ServerStarted 所有监听地址已绑定、Servlet 已启动，开始提供服务
+	ServerStarted LifecycleEventType = "server_started"
+
+	// ServerStopped 优雅关闭完成
+	ServerStopped LifecycleEventType = "server_stopped"
+
+	// ServletStarted Servlet 的 Start 方法返回，失败时 Err 不为 nil
+	ServletStarted LifecycleEventType = "servlet_started"
+
+	// ServletStopped Servlet 的 Stop 方法返回，失败时 Err 不为 nil
+	ServletStopped LifecycleEventType = "servlet_stopped"
+)
+
+// LifecycleEvent 应用的生命周期事件
+type LifecycleEvent struct {
+	Type     LifecycleEventType // 事件类型
+	Name     string             // 服务器事件为监听地址，Servlet 事件为 Servlet 名称
+	Time     time.Time          // 事件发生的时间
+	Duration time.Duration      // 对应操作的耗时
+	Err      error              // 操作返回的错误
+}
+
+// servletName 返回 Servlet 的名称
+//
+// Servlet 实现了 Name() string 方法时使用其返回值，否则使用类型名。
+func servletName(s Servlet) string {
+	if n, ok := s.(interface{ Name() string }); ok {
+		return n.Name()
+	}
+	return fmt.Sprintf("%T", s)
+}
+
+// emit 调用 Options.LifecycleHook
+func (a *App) emit(typ LifecycleEventType, name string, start time.Time, err error) {
+	if a.opts.LifecycleHook == nil {
+		return
+	}
+	now := time.Now()
+	a.opts.LifecycleHook(LifecycleEvent{
+		Type:     typ,
+		Name:     name,
+		Time:     now,
+		Duration: now.Sub(start),
+		Err:      err,
+	})
+}
+
+// startServlets 按添加顺序启动所有 Servlet
+//
+// 实现了 AddressAwareServlet 的 Servlet 在启动之前收到 Addr 返回的地址。
+// 某个 Servlet 启动失败时，逆序停止已经启动的 Servlet 并返回启动错误。
+func (a *App) startServlets(ctx context.Context) error {
+	addr := a.Addr()
+	for i := range a.servs {
+		servletSetAddr(a.servs[i], addr)
+		if err := a.startServlet(ctx, i); err != nil {
+			for j := i - 1; j >= 0; j-- {
+				if stopErr := a.stopServlet(j); stopErr != nil {
+					log.Println(stopErr)
+				}
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// stopServlets 逆序停止所有 Servlet，返回所有 Stop 错误的合并
+func (a *App) stopServlets() error {
+	var errs []error
+	for i := len(a.servs) - 1; i >= 0; i-- {
+		if err := a.stopServlet(i); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// defaultServletStartBackoff Options.ServletStartBackoff 为零时第一次重试前的等待时间
+const defaultServletStartBackoff = 100 * time.Millisecond
+
+// startServlet 启动第 i 个 Servlet，记录其状态并发出 ServletStarted 事件
+//
+// Start 返回错误时按 Options.ServletStartRetries 以指数退避重试，
+// 事件只在最终成功或放弃后发出一次，Duration 包含所有重试的耗时。
+func (a *App) startServlet(ctx context.Context, i int) error {
+	s := a.servs[i]
+	start := time.Now()
+	err := a.retryStart(ctx, s)
+	if err != nil {
+		a.setServletState(i, StateFailed)
+	} else {
+		a.setServletState(i, StateRunning)
+	}
+	a.emit(ServletStarted, servletName(s), start, err)
+	return err
+}
+
+// retryStart 调用 Servlet 的 Start，失败时按配置重试
+func (a *App) retryStart(ctx context.Context, s Servlet) error {
+	backoff := a.opts.ServletStartBackoff
+	if backoff <= 0 {
+		backoff = defaultServletStartBackoff
+	}
+
+	err := s.Start(ctx)
+	for attempt := 1; err != nil && attempt <= a.opts.ServletStartRetries; attempt++ {
+		log.Printf("h3: servlet %s start failed (attempt %d of %d): %v; retrying in %v",
+			servletName(s), attempt, a.opts.ServletStartRetries+1, err, backoff)
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+		backoff *= 2
+		err = s.Start(ctx)
+	}
+	return err
+}
+
+// stopServlet 停止第 i 个 Servlet，记录其状态并发出 ServletStopped 事件
+func (a *App) stopServlet(i int) error {
+	s := a.servs[i]
+	start := time.Now()
+	err := s.Stop()
+	a.setServletState(i, StateStopped)
+	a.emit(ServletStopped, servletName(s), start, err)
+	return err
+}
+
+// setServletState 设置第 i 个 Servlet 的状态
+func (a *App) setServletState(i int, state ServletState) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.states[i] = state
+}
@@ -0,0 +1,203 @@
+package h3
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"sync"
+	"testing"
+	"time"
+)
+
+// namedServlet 带名称的测试 Servlet 组件
+type namedServlet struct {
+	Component
+	Servlet
+	name string
+}
+
+func newNamedServlet(name string, start func(ctx context.Context) error) namedServlet {
+	return namedServlet{NewComponent("/" + name), ServletFunc(start, nil), name}
+}
+
+func (s namedServlet) Name() string {
+	return s.name
+}
+
+// eventRecorder 记录生命周期事件
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []LifecycleEvent
+}
+
+func (r *eventRecorder) hook(ev LifecycleEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, ev)
+}
+
+func (r *eventRecorder) sequence() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	seq := make([]string, len(r.events))
+	for i, ev := range r.events {
+		seq[i] = string(ev.Type) + ":" + ev.Name
+	}
+	return seq
+}
+
+func TestAppLifecycleHook(t *testing.T) {
+	var rec eventRecorder
+
+	app := New(NewMux(), Options{Addr: ":8118", LifecycleHook: rec.hook})
+	app.Register(newNamedServlet("db", nil))
+	app.Register(newNamedServlet("cache", nil))
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := app.Stop(ctx); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+
+	want := []string{
+		"servlet_started:db",
+		"servlet_started:cache",
+		"server_started::8118",
+		"servlet_stopped:cache",
+		"servlet_stopped:db",
+		"server_stopped::8118",
+	}
+
+	got := rec.sequence()
+	if len(got) != len(want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("events[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	for _, ev := range rec.events {
+		if ev.Time.IsZero() || ev.Duration < 0 || ev.Err != nil {
+			t.Errorf("event %+v has invalid fields", ev)
+		}
+	}
+}
+
+func TestAppLifecycleHookServletError(t *testing.T) {
+	var rec eventRecorder
+	errStart := errors.New("connection refused")
+
+	app := New(NewMux(), Options{Addr: ":8119", LifecycleHook: rec.hook})
+	app.Register(newNamedServlet("db", nil))
+	app.Register(newNamedServlet("queue", func(ctx context.Context) error { return errStart }))
+
+	if err := app.Start(context.Background()); !errors.Is(err, errStart) {
+		t.Fatalf("Start error = %v, want %v", err, errStart)
+	}
+
+	want := []string{
+		"servlet_started:db",
+		"servlet_started:queue",
+		"servlet_stopped:db",
+	}
+
+	got := rec.sequence()
+	if len(got) != len(want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("events[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	if !errors.Is(rec.events[1].Err, errStart) {
+		t.Errorf("queue start event error = %v, want %v", rec.events[1].Err, errStart)
+	}
+}
+
+func TestAppServletStartRetries(t *testing.T) {
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(io.Discard)
+
+	t.Run("eventually succeeds", func(t *testing.T) {
+		var rec eventRecorder
+		app := New(NewMux(), Options{
+			ServletStartRetries: 3,
+			ServletStartBackoff: time.Millisecond,
+			LifecycleHook:       rec.hook,
+		})
+
+		attempts := 0
+		app.AddServlet(ServletFunc(func(ctx context.Context) error {
+			attempts++
+			if attempts <= 2 {
+				return errors.New("database not ready")
+			}
+			return nil
+		}, nil))
+
+		if err := app.startServlets(context.Background()); err != nil {
+			t.Fatalf("startServlets = %v, want nil", err)
+		}
+		if attempts != 3 {
+			t.Errorf("attempts = %d, want 3", attempts)
+		}
+		if got := app.Servlets()[0].State; got != StateRunning {
+			t.Errorf("state = %q, want %q", got, StateRunning)
+		}
+		if seq := rec.sequence(); len(seq) != 1 || rec.events[0].Err != nil {
+			t.Errorf("events = %q, want one successful ServletStarted", seq)
+		}
+	})
+
+	t.Run("gives up", func(t *testing.T) {
+		app := New(NewMux(), Options{ServletStartRetries: 2, ServletStartBackoff: time.Millisecond})
+
+		errStart := errors.New("connection refused")
+		attempts := 0
+		app.AddServlet(ServletFunc(func(ctx context.Context) error {
+			attempts++
+			return errStart
+		}, nil))
+
+		if err := app.startServlets(context.Background()); !errors.Is(err, errStart) {
+			t.Fatalf("startServlets = %v, want %v", err, errStart)
+		}
+		if attempts != 3 {
+			t.Errorf("attempts = %d, want 3", attempts)
+		}
+		if got := app.Servlets()[0].State; got != StateFailed {
+			t.Errorf("state = %q, want %q", got, StateFailed)
+		}
+	})
+
+	t.Run("context cancelled during backoff", func(t *testing.T) {
+		app := New(NewMux(), Options{ServletStartRetries: 5, ServletStartBackoff: time.Minute})
+
+		attempts := 0
+		app.AddServlet(ServletFunc(func(ctx context.Context) error {
+			attempts++
+			return errors.New("unavailable")
+		}, nil))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		begin := time.Now()
+		if err := app.startServlets(ctx); err == nil {
+			t.Fatal("startServlets = nil, want error")
+		}
+		if d := time.Since(begin); d > 5*time.Second {
+			t.Errorf("startServlets took %v, want it to stop with ctx", d)
+		}
+		if attempts != 1 {
+			t.Errorf("attempts = %d, want 1", attempts)
+		}
+	})
+}
@@ -0,0 +1,52 @@
+package h3
+
+import "net/http"
+
+// LimitHeaders 创建限制请求头数量和长度的中间件
+//
+// Options.MaxHeaderBytes 是全局的，LimitHeaders 可以为敏感的路由设置更严格的限制，
+// 并拒绝携带大量请求头字段的请求（一种常见的 DoS 手段）。
+// 超出限制时返回 431 Request Header Fields Too Large。
+//
+// 参数:
+//   - maxCount: 请求头字段的最大数量，同名字段的每个值单独计数；零或负值表示不限制
+//   - maxValueLen: 单个请求头值的最大字节数；零或负值表示不限制
+//
+// 示例:
+//
+//	// 全局限制
+//	mux.Use(h3.LimitHeaders(100, 8<<10))
+//
+//	// 单个路由限制
+//	mux.Handle("POST /login", h3.LimitHeaders(20, 1024)(loginHandler))
+func LimitHeaders(maxCount int, maxValueLen int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			count := 0
+			for _, values := range r.Header {
+				count += len(values)
+				if maxValueLen > 0 {
+					for _, v := range values {
+						if len(v) > maxValueLen {
+							tooLarge(w)
+							return
+						}
+					}
+				}
+			}
+
+			if maxCount > 0 && count > maxCount {
+				tooLarge(w)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// tooLarge 返回 431 Request Header Fields Too Large
+func tooLarge(w http.ResponseWriter) {
+	code := http.StatusRequestHeaderFieldsTooLarge
+	http.Error(w, http.StatusText(code), code)
+}
@@ -0,0 +1,65 @@
+package h3
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLimitHeaders(t *testing.T) {
+	mux := NewMux()
+	mux.Handle("GET /login", LimitHeaders(5, 16)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})))
+	mux.HandleFunc("GET /public", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	tests := []struct {
+		name    string
+		path    string
+		headers map[string][]string
+		status  int
+	}{
+		{"compliant", "/login", map[string][]string{"X-A": {"1"}, "X-B": {"2"}}, http.StatusOK},
+		{"over count", "/login", map[string][]string{"X-A": {"1", "2", "3"}, "X-B": {"1", "2", "3"}}, http.StatusRequestHeaderFieldsTooLarge},
+		{"over length", "/login", map[string][]string{"X-Token": {strings.Repeat("x", 17)}}, http.StatusRequestHeaderFieldsTooLarge},
+		{"other route", "/public", map[string][]string{"X-Token": {strings.Repeat("x", 17)}}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			for name, values := range tt.headers {
+				for _, v := range values {
+					req.Header.Add(name, v)
+				}
+			}
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestLimitHeadersUnlimited(t *testing.T) {
+	handler := LimitHeaders(0, 0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	for i := range 100 {
+		req.Header.Set(fmt.Sprintf("X-Header-%d", i), strings.Repeat("x", 1024))
+	}
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
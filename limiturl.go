@@ -0,0 +1,34 @@
+package h3
+
+import "net/http"
+
+// LimitURLLength 创建限制 URL 路径和查询字符串长度的中间件
+//
+// 过长的 URL 会消耗路由匹配和日志的资源，是一种常见的 DoS 手段。
+// 长度按编码后的原始形式计算（百分号编码的字符计为三个字节），
+// 路径使用 r.URL.EscapedPath()，查询字符串使用 r.URL.RawQuery（不含 "?"）。
+// 超出限制时返回 414 URI Too Long。
+//
+// 应当作为全局中间件使用，以便在路由匹配之前拒绝请求。
+//
+// 参数:
+//   - maxPath: 路径的最大字节数；零或负值表示不限制
+//   - maxQuery: 查询字符串的最大字节数；零或负值表示不限制
+//
+// 示例:
+//
+//	mux.Use(h3.LimitURLLength(2048, 4096))
+func LimitURLLength(maxPath, maxQuery int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if (maxPath > 0 && len(r.URL.EscapedPath()) > maxPath) ||
+				(maxQuery > 0 && len(r.URL.RawQuery) > maxQuery) {
+				code := http.StatusRequestURITooLong
+				http.Error(w, http.StatusText(code), code)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package h3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLimitURLLength(t *testing.T) {
+	h := LimitURLLength(16, 10)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	tests := []struct {
+		name   string
+		target string
+		status int
+	}{
+		{"compliant", "/users/42?page=2", http.StatusOK},
+		{"path at limit", "/" + strings.Repeat("a", 15), http.StatusOK},
+		{"long path", "/" + strings.Repeat("a", 16), http.StatusRequestURITooLong},
+		// 解码后只有 6 个字节，编码后为 16 个字节
+		{"encoded path", "/%20%20%20%20%20", http.StatusOK},
+		{"long encoded path", "/%20%20%20%20%20%20", http.StatusRequestURITooLong},
+		{"query at limit", "/?q=" + strings.Repeat("x", 8), http.StatusOK},
+		{"long query", "/?q=" + strings.Repeat("x", 9), http.StatusRequestURITooLong},
+		{"long encoded query", "/?q=%41%41%41", http.StatusRequestURITooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest("GET", tt.target, nil))
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestLimitURLLengthUnlimited(t *testing.T) {
+	h := LimitURLLength(0, -1)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/"+strings.Repeat("a", 4096)+"?q="+strings.Repeat("b", 4096), nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
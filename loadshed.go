@@ -0,0 +1,39 @@
+package h3
+
+import "net/http"
+
+// LoadShed 创建在过载时丢弃低优先级请求的中间件
+//
+// 每个请求都会调用 isHealthy 检查服务的负载状态：
+//   - 返回 true 时所有请求正常处理
+//   - 返回 false 时，shouldShed 返回 true 的低优先级请求直接得到
+//     503 Service Unavailable（overloaded），其余请求继续处理
+//
+// isHealthy 在每个请求上调用，应当足够廉价，例如读取一个原子变量或比较计数器；
+// 负载信号可以来自 App.IdleConnCount、进行中的请求数或外部的监控数据。
+// 错误响应通过 RenderJSONError 写出，并设置 Retry-After: 1。
+//
+// 示例:
+//
+//	var inflight atomic.Int64
+//	mux.Use(h3.LoadShed(
+//		func() bool { return inflight.Load() < 1000 },
+//		func(r *http.Request) bool { return r.Header.Get("X-Priority") == "low" },
+//	))
+func LoadShed(isHealthy func() bool, shouldShed func(*http.Request) bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isHealthy() || !shouldShed(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Retry-After", "1")
+			RenderJSONError(w, r, &StatusError{
+				Status:  http.StatusServiceUnavailable,
+				Code:    "overloaded",
+				Message: "server is overloaded, please retry later",
+			})
+		})
+	}
+}
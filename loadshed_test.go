@@ -0,0 +1,63 @@
+package h3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestLoadShed(t *testing.T) {
+	var overloaded atomic.Bool
+	mux := NewMux()
+	mux.Use(LoadShed(
+		func() bool { return !overloaded.Load() },
+		func(r *http.Request) bool {
+			return r.Header.Get("X-Priority") == "low" || strings.HasPrefix(r.URL.Path, "/reports/")
+		},
+	))
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	tests := []struct {
+		name       string
+		path       string
+		priority   string
+		overloaded bool
+		status     int
+	}{
+		{"normal low priority", "/api", "low", false, http.StatusOK},
+		{"normal report", "/reports/daily", "", false, http.StatusOK},
+		{"normal high priority", "/api", "high", false, http.StatusOK},
+		{"overloaded low priority", "/api", "low", true, http.StatusServiceUnavailable},
+		{"overloaded report", "/reports/daily", "", true, http.StatusServiceUnavailable},
+		{"overloaded high priority", "/api", "high", true, http.StatusOK},
+		{"overloaded default", "/api", "", true, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			overloaded.Store(tt.overloaded)
+			req := httptest.NewRequest("GET", tt.path, nil)
+			if tt.priority != "" {
+				req.Header.Set("X-Priority", tt.priority)
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.status == http.StatusServiceUnavailable {
+				if rec.Header().Get("Retry-After") != "1" {
+					t.Errorf("Retry-After = %q, want 1", rec.Header().Get("Retry-After"))
+				}
+				if !strings.Contains(rec.Body.String(), "overloaded") {
+					t.Errorf("body = %q, want overloaded error", rec.Body.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package h3
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// MaintenanceConfig 维护模式的配置
+type MaintenanceConfig struct {
+	// Allow 维护期间仍然正常处理的路由模式，例如 "/healthz" 或 "GET /status/{path...}"，
+	// 模式语法与 http.ServeMux 相同；无效的模式会触发 panic。
+	Allow []string
+
+	// RetryAfter 维护期间 503 响应的 Retry-After 头，按秒取整。
+	// 零值表示不设置 Retry-After。
+	RetryAfter time.Duration
+
+	// Body 维护期间 503 响应的响应体，为空时使用 "Service Unavailable"
+	Body string
+
+	// ContentType 响应体的 Content-Type，为空时使用 "text/plain; charset=utf-8"
+	ContentType string
+}
+
+// MaintenanceMode 可以在运行时开启和关闭的维护模式
+//
+// 通过 Maintenance 创建，Middleware 方法作为中间件注册到路由器。
+// Enable 和 Disable 可以在处理请求的同时从任意 goroutine 调用。
+type MaintenanceMode struct {
+	enabled atomic.Bool
+	allow   *http.ServeMux // 允许的路由模式，为 nil 时没有例外
+	cfg     MaintenanceConfig
+}
+
+// Maintenance 创建维护模式，初始为关闭状态
+//
+// 开启后，除 Allow 中的路由外，所有请求都返回 503 Service Unavailable，
+// 不会调用后续的中间件和处理器。关闭后恢复正常处理。
+//
+// 示例:
+//
+//	maint := h3.Maintenance(h3.MaintenanceConfig{
+//		Allow:      []string{"/healthz", "/readyz"},
+//		RetryAfter: 5 * time.Minute,
+//		Body:       `{"error":"maintenance"}`,
+//		ContentType: "application/json",
+//	})
+//	mux.Use(maint.Middleware)
+//
+//	// 部署开始时
+//	maint.Enable()
+func Maintenance(config ...MaintenanceConfig) *MaintenanceMode {
+	var cfg MaintenanceConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Body == "" {
+		cfg.Body = http.StatusText(http.StatusServiceUnavailable)
+	}
+	if cfg.ContentType == "" {
+		cfg.ContentType = "text/plain; charset=utf-8"
+	}
+
+	m := &MaintenanceMode{cfg: cfg}
+	if len(cfg.Allow) > 0 {
+		m.allow = http.NewServeMux()
+		for _, pattern := range cfg.Allow {
+			m.allow.Handle(pattern, http.NotFoundHandler())
+		}
+	}
+	return m
+}
+
+// Enable 开启维护模式
+func (m *MaintenanceMode) Enable() {
+	m.enabled.Store(true)
+}
+
+// Disable 关闭维护模式
+func (m *MaintenanceMode) Disable() {
+	m.enabled.Store(false)
+}
+
+// Enabled 返回维护模式是否开启
+func (m *MaintenanceMode) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// Middleware 返回维护模式的中间件
+//
+// 关闭时请求直接交给 next；开启时只有匹配 Allow 的请求交给 next。
+func (m *MaintenanceMode) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !m.enabled.Load() || m.allowed(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		if m.cfg.RetryAfter > 0 {
+			h.Set("Retry-After", strconv.Itoa(int(m.cfg.RetryAfter.Round(time.Second)/time.Second)))
+		}
+		h.Set("Content-Type", m.cfg.ContentType)
+		h.Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(m.cfg.Body))
+	})
+}
+
+// allowed 判断请求是否匹配 Allow 中的路由模式
+func (m *MaintenanceMode) allowed(r *http.Request) bool {
+	if m.allow == nil {
+		return false
+	}
+	_, pattern := m.allow.Handler(r)
+	return pattern != ""
+}
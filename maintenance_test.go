@@ -0,0 +1,112 @@
+package h3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMaintenance(t *testing.T) {
+	maint := Maintenance(MaintenanceConfig{
+		Allow:       []string{"/healthz", "GET /status/{path...}"},
+		RetryAfter:  90 * time.Second,
+		Body:        `{"error":"maintenance"}`,
+		ContentType: "application/json",
+	})
+
+	mux := NewMux()
+	mux.Use(maint.Middleware)
+	ok := func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) }
+	mux.HandleFunc("GET /users", ok)
+	mux.HandleFunc("/healthz", ok)
+	mux.HandleFunc("/status/{path...}", ok)
+
+	do := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	// 初始为关闭状态
+	if maint.Enabled() {
+		t.Fatal("maintenance mode should start disabled")
+	}
+	if rec := do("GET", "/users"); rec.Code != http.StatusOK {
+		t.Fatalf("disabled: status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	maint.Enable()
+	rec := do("GET", "/users")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("enabled: status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "90" {
+		t.Errorf("Retry-After = %q, want %q", got, "90")
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want %q", got, "application/json")
+	}
+	if rec.Body.String() != `{"error":"maintenance"}` {
+		t.Errorf("body = %q", rec.Body)
+	}
+
+	for _, tt := range []struct {
+		method, target string
+		status         int
+	}{
+		{"GET", "/healthz", http.StatusOK},
+		{"HEAD", "/healthz", http.StatusOK},
+		{"GET", "/status/db", http.StatusOK},
+		{"POST", "/status/db", http.StatusServiceUnavailable},
+		{"GET", "/missing", http.StatusServiceUnavailable},
+	} {
+		if rec := do(tt.method, tt.target); rec.Code != tt.status {
+			t.Errorf("enabled: %s %s status = %d, want %d", tt.method, tt.target, rec.Code, tt.status)
+		}
+	}
+
+	maint.Disable()
+	if rec := do("GET", "/users"); rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+		t.Errorf("disabled again: response = %d %q, want 200 %q", rec.Code, rec.Body, "ok")
+	}
+}
+
+func TestMaintenanceDefaults(t *testing.T) {
+	maint := Maintenance()
+	maint.Enable()
+
+	h := maint.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler should not be called in maintenance mode")
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/healthz", nil))
+
+	if rec.Code != http.StatusServiceUnavailable || rec.Body.String() != "Service Unavailable" {
+		t.Errorf("response = %d %q", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "" {
+		t.Errorf("Retry-After = %q, want empty", got)
+	}
+}
+
+func TestMaintenanceConcurrentToggle(t *testing.T) {
+	maint := Maintenance()
+	h := maint.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Go(func() {
+			for range 100 {
+				if i%2 == 0 {
+					maint.Enable()
+				} else {
+					maint.Disable()
+				}
+				h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+			}
+		})
+	}
+	wg.Wait()
+}
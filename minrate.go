@@ -0,0 +1,122 @@
+package h3
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// MinReadRate 创建限制请求体最低读取速率的中间件，用于防御 Slowloris 类攻击
+//
+// ReadHeaderTimeout 只能限制请求头的读取时间，客户端仍然可以缓慢地发送请求体来长期占用连接。
+// 此中间件在处理器每次读取请求体之前，通过 http.ResponseController.SetReadDeadline
+// 将连接的读取截止时间设置为 start + grace + 已读字节数/bytesPerSecond，
+// 即客户端在宽限期之后必须保持不低于 bytesPerSecond 的平均发送速率。
+//
+// 读取超时后，处理器从请求体读取时会得到错误，中间件将响应替换为 408 Request Timeout，
+// 处理器之后写出的内容会被丢弃。
+// 如果底层连接不支持设置读取截止时间（例如 httptest.ResponseRecorder），中间件不做任何限制。
+//
+// 如果 bytesPerSecond 不是正数，会触发 panic。
+//
+// 参数:
+//   - bytesPerSecond: 最低的平均读取速率（字节/秒）
+//   - grace: 开始计算速率之前的宽限时间
+//
+// 示例:
+//
+//	mux.Use(h3.MinReadRate(1024, 5*time.Second))
+func MinReadRate(bytesPerSecond int64, grace time.Duration) func(http.Handler) http.Handler {
+	if bytesPerSecond <= 0 {
+		panic(errors.New("h3: invalid minimum read rate"))
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rc := http.NewResponseController(w)
+			start := time.Now()
+			if err := rc.SetReadDeadline(start.Add(grace)); err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rw := &rateWriter{Response: NewResponse(w)}
+			r.Body = &rateBody{
+				ReadCloser: r.Body,
+				rc:         rc,
+				start:      start.Add(grace),
+				rate:       bytesPerSecond,
+				w:          rw,
+			}
+
+			next.ServeHTTP(rw, r)
+
+			// 超时的连接保持已过期的截止时间，避免服务器在关闭前继续等待剩余的请求体
+			if !rw.timedOut {
+				_ = rc.SetReadDeadline(time.Time{})
+			}
+		})
+	}
+}
+
+// rateBody 在每次读取前根据已读字节数延长读取截止时间
+type rateBody struct {
+	io.ReadCloser
+	rc    *http.ResponseController
+	start time.Time
+	rate  int64
+	n     int64
+	w     *rateWriter
+}
+
+func (b *rateBody) Read(p []byte) (int, error) {
+	deadline := b.start.Add(time.Duration(b.n * int64(time.Second) / b.rate))
+	_ = b.rc.SetReadDeadline(deadline)
+
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		b.w.timeout()
+	}
+	return n, err
+}
+
+// rateWriter 在请求体读取超时后将响应替换为 408
+type rateWriter struct {
+	Response
+	timedOut bool
+}
+
+// timeout 写出 408 响应，之后处理器的写入都会被丢弃
+func (w *rateWriter) timeout() {
+	if w.timedOut {
+		return
+	}
+	w.timedOut = true
+	if !w.Response.Committed() {
+		// 请求体未读完，连接无法复用
+		w.Header().Set("Connection", "close")
+		http.Error(w.Response, http.StatusText(http.StatusRequestTimeout), http.StatusRequestTimeout)
+	}
+}
+
+func (w *rateWriter) WriteHeader(code int) {
+	if w.timedOut {
+		return
+	}
+	w.Response.WriteHeader(code)
+}
+
+func (w *rateWriter) Write(p []byte) (int, error) {
+	if w.timedOut {
+		return len(p), nil
+	}
+	return w.Response.Write(p)
+}
@@ -0,0 +1,95 @@
+package h3
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newMinReadRateServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := NewMux()
+	mux.Use(MinReadRate(1000, 100*time.Millisecond))
+	mux.HandleFunc("POST /upload", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprintf(w, "%d", len(body))
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestMinReadRate(t *testing.T) {
+	srv := newMinReadRateServer(t)
+
+	resp, err := http.Post(srv.URL+"/upload", "text/plain", strings.NewReader(strings.Repeat("x", 100)))
+	if err != nil {
+		t.Fatalf("POST failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	if string(body) != "100" {
+		t.Errorf("body = %q, want %q", body, "100")
+	}
+}
+
+func TestMinReadRateSlowClient(t *testing.T) {
+	srv := newMinReadRateServer(t)
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	// 声明 100 字节的请求体，但只发送 10 字节后停止
+	fmt.Fprint(conn, "POST /upload HTTP/1.1\r\nHost: example.com\r\nContent-Length: 100\r\n\r\n")
+	fmt.Fprint(conn, strings.Repeat("x", 10))
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("ReadResponse failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusRequestTimeout {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusRequestTimeout)
+	}
+}
+
+func TestMinReadRateWithoutDeadline(t *testing.T) {
+	mux := NewMux()
+	mux.Use(MinReadRate(1, 0))
+	mux.HandleFunc("POST /upload", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	})
+
+	// httptest.ResponseRecorder 不支持读取截止时间，中间件直接放行
+	req := httptest.NewRequest("POST", "/upload", strings.NewReader("hello"))
+	rec := httptest.NewRecorder()
+
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "hello" {
+		t.Errorf("response = %d %q, want %d %q", rec.Code, rec.Body.String(), http.StatusOK, "hello")
+	}
+}
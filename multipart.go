@@ -0,0 +1,76 @@
+package h3
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+)
+
+// MultipartConfig multipart 上传中间件的配置
+type MultipartConfig struct {
+	// MaxSize 请求体的最大字节数，超出时返回 413。
+	// 如果为零，使用 32MB。
+	MaxSize int64
+}
+
+// MultipartSpool 创建解析 multipart/form-data 请求体的中间件
+//
+// 中间件在调用处理器之前使用 r.ParseMultipartForm(memLimit) 解析表单：
+// 文件内容超过 memLimit 的部分会被写入磁盘上的临时文件，
+// 处理器返回后（包括 panic 时）调用 r.MultipartForm.RemoveAll() 删除这些临时文件。
+// 处理器可以直接使用 r.MultipartForm、r.FormFile 和 r.FormValue。
+//
+// 请求体大小受 MaxSize 限制，超出时返回 413；表单格式错误时返回 400。
+// 非 multipart/form-data 的请求不做处理，直接交给下一个处理器。
+//
+// 临时文件由标准库的 mime/multipart 创建，总是写入 os.TempDir()，
+// 标准库不支持为单个请求指定目录；需要使用其他目录（例如容量更大的磁盘）时，
+// 在进程启动时设置 TMPDIR 环境变量。
+//
+// 参数:
+//   - memLimit: 保存在内存中的最大字节数
+//   - config: 可选配置
+//
+// 示例:
+//
+//	mux.Use(h3.MultipartSpool(8<<20, h3.MultipartConfig{MaxSize: 1 << 30}))
+func MultipartSpool(memLimit int64, config ...MultipartConfig) func(http.Handler) http.Handler {
+	var cfg MultipartConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = 32 << 20
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil || mediaType != "multipart/form-data" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, cfg.MaxSize)
+			if err := r.ParseMultipartForm(memLimit); err != nil {
+				// 解析失败时可能已经写入了部分临时文件
+				if r.MultipartForm != nil {
+					r.MultipartForm.RemoveAll()
+				}
+
+				var mbe *http.MaxBytesError
+				if errors.As(err, &mbe) {
+					http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+					return
+				}
+				http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+				return
+			}
+
+			form := r.MultipartForm
+			defer form.RemoveAll()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package h3
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newMultipartRequest(t *testing.T, field, filename string, content []byte) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile(field, filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fw.Write(content)
+	mw.WriteField("name", "report")
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	return req
+}
+
+func TestMultipartSpool(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TMPDIR", dir)
+
+	content := bytes.Repeat([]byte("x"), 4096)
+
+	var spooled string
+	mux := NewMux()
+	mux.Use(MultipartSpool(1024))
+	mux.HandleFunc("POST /upload", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("name") != "report" {
+			t.Errorf("name = %q, want %q", r.FormValue("name"), "report")
+		}
+
+		f, _, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("FormFile: %v", err)
+		}
+		defer f.Close()
+
+		// 超过内存阈值的文件应当被写入磁盘
+		osf, ok := f.(*os.File)
+		if !ok {
+			t.Fatalf("file is %T, want *os.File", f)
+		}
+		spooled = osf.Name()
+
+		data, _ := io.ReadAll(f)
+		if !bytes.Equal(data, content) {
+			t.Errorf("file content length = %d, want %d", len(data), len(content))
+		}
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, newMultipartRequest(t, "file", "big.bin", content))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	if filepath.Dir(spooled) != dir {
+		t.Errorf("spooled file %q not in %q", spooled, dir)
+	}
+
+	if _, err := os.Stat(spooled); !os.IsNotExist(err) {
+		t.Errorf("temp file %q still exists: %v", spooled, err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("temp dir has %d entries, want 0", len(entries))
+	}
+}
+
+func TestMultipartSpoolMaxSize(t *testing.T) {
+	called := false
+	mux := NewMux()
+	mux.Use(MultipartSpool(1024, MultipartConfig{MaxSize: 2048}))
+	mux.HandleFunc("POST /upload", func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, newMultipartRequest(t, "file", "big.bin", bytes.Repeat([]byte("x"), 4096)))
+
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+
+	if called {
+		t.Error("handler should not be called")
+	}
+}
+
+func TestMultipartSpoolInvalid(t *testing.T) {
+	mux := NewMux()
+	mux.Use(MultipartSpool(1024))
+	mux.HandleFunc("POST /upload", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	})
+
+	tests := []struct {
+		name        string
+		contentType string
+		status      int
+		body        string
+	}{
+		{"not multipart", "text/plain", http.StatusOK, "hello"},
+		{"missing boundary", "multipart/form-data", http.StatusBadRequest, "Bad Request\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/upload", strings.NewReader("hello"))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+
+			if rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+		})
+	}
+}
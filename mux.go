@@ -1,8 +1,16 @@
 package h3
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
 	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 )
 
 // Mux 路由复用器接口，扩展了标准库的 http.ServeMux
@@ -22,6 +30,22 @@ type Mux interface {
 	// 中间件按注册顺序执行：先注册的在外层，后注册的在内层
 	Use(func(http.Handler) http.Handler)
 
+	// UseFor 添加接收匹配路由模式的中间件到中间件链
+	// 中间件可以根据路由模板（而不是具体路径）调整行为
+	UseFor(fn func(pattern string, next http.Handler) http.Handler)
+
+	// UseNamed 添加具名中间件到中间件链
+	// 具名中间件可以在之后通过 Replace 或 Remove 按名称替换或移除
+	UseNamed(name string, middleware func(http.Handler) http.Handler)
+
+	// Replace 按名称替换已注册的中间件，保持其在链中的位置
+	// 如果不存在该名称的中间件，返回 false
+	Replace(name string, middleware func(http.Handler) http.Handler) bool
+
+	// Remove 按名称移除已注册的中间件
+	// 如果不存在该名称的中间件，返回 false
+	Remove(name string) bool
+
 	// Handler 返回匹配请求的处理器和模式
 	// 这是对底层 http.ServeMux.Handler 的封装
 	Handler(r *http.Request) (h http.Handler, pattern string)
@@ -34,6 +58,18 @@ type Mux interface {
 	// 这是 Handle 方法的便捷包装
 	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
 
+	// HandleWith 注册在注册时就组合好中间件的处理器，该路由不经过 Use 注册的中间件链
+	HandleWith(pattern string, handler http.Handler, middleware ...func(http.Handler) http.Handler)
+
+	// HandleMethods 将同一个处理器注册到多个方法的同一路径
+	HandleMethods(methods []string, path string, handler http.Handler)
+
+	// HandleHost 注册只匹配指定 Host 请求头的处理器
+	HandleHost(host, pattern string, handler http.Handler)
+
+	// Any 注册匹配指定前缀下所有路径和方法的处理器
+	Any(prefix string, handler http.Handler)
+
 	// Mount 将子路由挂载到指定路径
 	// 子路由的所有路径都会添加 pattern 作为前缀
 	//
@@ -42,6 +78,51 @@ type Mux interface {
 	//   // apiMux 中的 "GET /users" 会变成 "GET /api/users"
 	Mount(pattern string, mux Mux)
 
+	// NotFound 设置没有路由匹配时使用的 404 处理器
+	NotFound(handler http.Handler)
+
+	// Fallback 设置没有路由匹配时的兜底处理器，优先于 NotFound
+	// 适用于单页应用等需要为任意未知路径返回内容的场景
+	Fallback(handler http.Handler)
+
+	// MethodFallback 设置指定方法的请求没有路由匹配时的兜底处理器，优先于 Fallback 和 NotFound
+	MethodFallback(method string, handler http.Handler)
+
+	// SPA 在指定路径下提供单页应用，未知路径回退到 index 文件
+	// assets 指定返回真实 404 的静态资源子树，默认为 "/assets/"
+	SPA(prefix string, fsys fs.FS, index string, assets ...string)
+
+	// Favicon 注册 GET /favicon.ico，返回给定的图标数据
+	Favicon(data []byte)
+
+	// Robots 注册 GET /robots.txt，返回给定的文本内容
+	Robots(content string)
+
+	// ServeFile 注册返回单个文件的路由，文件不存在时使用 NotFound 处理器
+	ServeFile(pattern, name string)
+
+	// VersionSwitch 返回根据版本请求头分发到不同处理器的处理器
+	VersionSwitch(header string, versions map[string]http.Handler, fallback http.Handler) http.Handler
+
+	// ContentTypeSwitch 在同一路由上根据请求的 Content-Type 前缀分发到不同的处理器
+	ContentTypeSwitch(pattern string, handlers map[string]http.Handler, fallback http.Handler)
+
+	// Clone 返回包含相同中间件和路由的独立副本
+	Clone() Mux
+
+	// Recover 在整个中间件链的最外层捕获 panic
+	Recover(render ...ErrorRenderer)
+
+	// AutoHeadOptions 开启后，所有路由路径自动响应 OPTIONS 请求并返回 Allow 头
+	AutoHeadOptions(enable bool)
+
+	// NormalizeMethod 开启后，在路由之前将请求方法转换为大写，无效的方法返回 400
+	NormalizeMethod(enable bool)
+
+	// Walk 按注册顺序遍历所有路由，包括挂载的子路由中的路由
+	// fn 返回错误时停止遍历并返回该错误
+	Walk(fn func(RouteInfo) error) error
+
 	// ServeHTTP 实现 http.Handler 接口
 	ServeHTTP(http.ResponseWriter, *http.Request)
 }
@@ -49,7 +130,33 @@ type Mux interface {
 // mux 路由复用器的内部实现
 type mux struct {
 	mux *http.ServeMux                  // 底层标准库路由器
+	mws []middleware                    // 按注册顺序排列的中间件
 	pre func(http.Handler) http.Handler // 已合并的中间件链
+	nf  http.Handler                    // 自定义 404 处理器
+	fb  http.Handler                    // 兜底处理器
+	mfb map[string]http.Handler         // 按请求方法的兜底处理器
+	rts []route                         // 按注册顺序排列的路由，用于 Clone
+	rec func(http.Handler) http.Handler // 最外层的 panic 恢复中间件
+	aho bool                            // 是否自动响应 OPTIONS 请求
+	nmt bool                            // 是否在路由之前规范化请求方法
+	bkd map[string]bool                 // 通过 HandleWith 注册、绕过中间件链的路由模式
+}
+
+// route 已注册的路由模式及其处理器
+type route struct {
+	pattern string
+	handler http.Handler
+	sub     Mux    // 通过 Mount 挂载的子路由，普通路由为 nil
+	name    string // 处理器名称，为空时从 handler 推断
+}
+
+// middleware 中间件及其名称，匿名中间件的名称为空字符串
+//
+// 通过 UseFor 注册的中间件 fn 为 nil，使用 pfn 在请求时接收匹配的路由模式。
+type middleware struct {
+	name string
+	fn   func(http.Handler) http.Handler
+	pfn  func(pattern string, next http.Handler) http.Handler
 }
 
 // NewMux 创建新的路由复用器
@@ -73,18 +180,129 @@ func NewMux() Mux {
 //	mux.Use(authMiddleware)     // 内层
 //	// 执行顺序：logging before -> auth before -> handler -> auth after -> logging after
 func (m *mux) Use(middleware func(http.Handler) http.Handler) {
-	pre := m.pre
+	m.UseNamed("", middleware)
+}
+
+// UseFor 添加接收匹配路由模式的中间件到中间件链
+//
+// 每个请求到达该中间件时，h3 通过底层路由器解析请求匹配的路由模式，
+// 以注册时的模板形式传入（例如 "GET /users/{id}" 而不是 "/users/42"），
+// 中间件无需解析路径即可按路由调整行为，例如为不同路由设置不同的限流。
+// 没有路由匹配时 pattern 为空字符串；请求匹配挂载的子路由时，pattern 为挂载时的模式。
+//
+// 执行顺序与 Use 相同。fn 在每个请求上调用一次，应避免在其中执行昂贵的初始化，
+// 需要按路由缓存的状态可以以 pattern 为键预先构建。
+//
+// 示例：
+//
+//	limits := map[string]int{"POST /login": 5}
+//	mux.UseFor(func(pattern string, next http.Handler) http.Handler {
+//		if n, ok := limits[pattern]; ok {
+//			return limiters[n](next)
+//		}
+//		return next
+//	})
+func (m *mux) UseFor(fn func(pattern string, next http.Handler) http.Handler) {
+	if fn == nil {
+		panic(errors.New("h3: nil middleware"))
+	}
+	m.mws = append(m.mws, middleware{pfn: fn})
+	m.compose()
+}
+
+// UseNamed 添加具名中间件到中间件链
+//
+// 执行顺序与 Use 相同。名称用于之后通过 Replace 或 Remove 定位该中间件，
+// 空名称等同于 Use，这样注册的中间件无法被替换或移除。
+//
+// 示例：
+//
+//	mux.UseNamed("auth", authMiddleware)
+//	// 测试中禁用认证
+//	mux.Remove("auth")
+func (m *mux) UseNamed(name string, fn func(http.Handler) http.Handler) {
+	m.mws = append(m.mws, middleware{name: name, fn: fn})
+	m.compose()
+}
+
+// Replace 按名称替换已注册的中间件
+//
+// 新中间件保持被替换中间件在链中的位置。如果有多个同名中间件，
+// 只替换第一个。如果不存在该名称的中间件，返回 false。
+//
+// 注意：此方法不是并发安全的，不应在处理请求的同时调用。
+func (m *mux) Replace(name string, fn func(http.Handler) http.Handler) bool {
+	i := m.lookup(name)
+	if i < 0 {
+		return false
+	}
+
+	m.mws[i].fn = fn
+	m.compose()
+	return true
+}
+
+// Remove 按名称移除已注册的中间件
+//
+// 如果有多个同名中间件，只移除第一个。如果不存在该名称的中间件，返回 false。
+//
+// 注意：此方法不是并发安全的，不应在处理请求的同时调用。
+func (m *mux) Remove(name string) bool {
+	i := m.lookup(name)
+	if i < 0 {
+		return false
+	}
+
+	m.mws = append(m.mws[:i], m.mws[i+1:]...)
+	m.compose()
+	return true
+}
+
+// lookup 返回指定名称的中间件在链中的位置，不存在时返回 -1
+func (m *mux) lookup(name string) int {
+	if name == "" {
+		return -1
+	}
+	for i, mw := range m.mws {
+		if mw.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// compose 根据中间件列表重新合并中间件链
+//
+// 先注册的中间件在外层，因此从最后一个开始向外包装。
+func (m *mux) compose() {
+	if len(m.mws) == 0 {
+		m.pre = nil
+		return
+	}
+
+	mws := make([]middleware, len(m.mws))
+	copy(mws, m.mws)
+	for i, mw := range mws {
+		if mw.pfn != nil {
+			mws[i].fn = m.withPattern(mw.pfn)
+		}
+	}
 
 	m.pre = func(next http.Handler) http.Handler {
-		if pre != nil {
-			// 将新中间件包装在已有中间件链的内部
-			return pre(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				middleware(next).ServeHTTP(w, r)
-			}))
-		} else {
-			// 第一个中间件
-			return middleware(next)
+		for i := len(mws) - 1; i >= 0; i-- {
+			next = mws[i].fn(next)
 		}
+		return next
+	}
+}
+
+// withPattern 将 UseFor 注册的中间件转换为普通中间件，在请求时解析匹配的路由模式
+func (m *mux) withPattern(fn func(pattern string, next http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, pattern := m.mux.Handler(r)
+			fn(pattern, next).ServeHTTP(w, r)
+		})
 	}
 }
 
@@ -115,6 +333,129 @@ func (m *mux) HandleFunc(pattern string, handler func(http.ResponseWriter, *http
 	m.register(pattern, http.HandlerFunc(handler))
 }
 
+// HandleWith 注册在注册时就组合好中间件的处理器
+//
+// middleware 在注册时立即按 Use 的顺序（第一个在最外层）包装 handler，
+// 之后每个请求直接调用组合好的处理器。匹配该路由的请求不经过 Use 注册的中间件链，
+// 但仍然受 Recover 的保护。
+//
+// 取舍：路由的中间件在注册时固定，之后通过 Use、Replace 或 Remove 修改的中间件链
+// 对该路由不生效；需要全局中间件（日志、认证等）的行为时，必须显式包含在 middleware 中。
+// 存在这样的路由时，每个请求会多一次路由匹配，用于判断是否绕过中间件链。
+//
+// 示例：
+//
+//	mux.HandleWith("GET /healthz", healthHandler, h3.RequestID())
+func (m *mux) HandleWith(pattern string, handler http.Handler, middleware ...func(http.Handler) http.Handler) {
+	if handler == nil {
+		panic(errors.New("h3: nil handler"))
+	}
+	m.register(pattern, Chain(middleware...)(handler))
+	m.rts[len(m.rts)-1].name = handlerName(handler)
+
+	if m.bkd == nil {
+		m.bkd = make(map[string]bool)
+	}
+	m.bkd[pattern] = true
+}
+
+// bypass 判断请求是否匹配通过 HandleWith 注册的路由
+func (m *mux) bypass(r *http.Request) bool {
+	if len(m.bkd) == 0 {
+		return false
+	}
+	_, pattern := m.mux.Handler(r)
+	return m.bkd[pattern]
+}
+
+// HandleMethods 将同一个处理器注册到多个方法的同一路径
+//
+// 标准库的路由模式每次只能指定一个方法，此方法为每个方法分别注册
+// "METHOD path" 模式。重复的方法只注册一次。
+// 未列出的方法请求该路径时返回 405，Allow 头列出所有已注册的方法。
+//
+// 如果 methods 为空或包含空字符串，会触发 panic。
+//
+// 示例：
+//
+//	mux.HandleMethods([]string{"GET", "POST"}, "/search", searchHandler)
+func (m *mux) HandleMethods(methods []string, path string, handler http.Handler) {
+	if len(methods) == 0 {
+		panic(errors.New("h3: no methods"))
+	}
+
+	seen := make(map[string]bool, len(methods))
+	for _, method := range methods {
+		if method == "" {
+			panic(errors.New("h3: invalid method"))
+		}
+		if seen[method] {
+			continue
+		}
+		seen[method] = true
+		m.register(method+" "+path, handler)
+	}
+}
+
+// HandleHost 注册只匹配指定 Host 请求头的处理器
+//
+// 将 host 插入 pattern 的路径之前，注册标准库的带主机名模式，
+// 例如 HandleHost("api.example.com", "GET /users", h) 注册 "GET api.example.com/users"。
+// 适用于少量按主机名区分的路由，不需要为每个主机创建单独的路由器。
+//
+// 与标准库一致，匹配时忽略 Host 头中的端口，主机名区分大小写。
+// 带主机名的模式优先于不带主机名的模式：同一路径同时注册了两者时，
+// Host 匹配的请求交给 HandleHost 注册的处理器，其余请求交给不带主机名的路由。
+//
+// 如果 host 为空或包含 "/"、空白字符，或 pattern 的路径不以 "/" 开头，会触发 panic。
+//
+// 示例：
+//
+//	mux.HandleFunc("GET /", homeHandler)
+//	mux.HandleHost("docs.example.com", "GET /", docsHandler)
+func (m *mux) HandleHost(host, pattern string, handler http.Handler) {
+	if host == "" || strings.ContainsAny(host, "/ \t") {
+		panic(fmt.Errorf("h3: invalid host %q", host))
+	}
+
+	method, path := "", pattern
+	if i := strings.IndexAny(pattern, " \t"); i >= 0 {
+		method, path = pattern[:i]+" ", strings.TrimLeft(pattern[i:], " \t")
+	}
+	if !strings.HasPrefix(path, "/") {
+		panic(fmt.Errorf("h3: invalid pattern %q", pattern))
+	}
+	m.register(method+host+path, handler)
+}
+
+// Any 注册匹配指定前缀下所有路径和方法的处理器
+//
+// Any 注册 "prefix/{path...}" 模式，不限制请求方法，
+// 前缀之后的剩余路径可以通过 r.PathValue("path") 读取。
+// 适用于反向代理等只需要一个兜底处理器的场景。
+//
+// 与 Mount 的区别：Mount 挂载的是一个完整的子路由，并通过 http.StripPrefix
+// 移除前缀后再交给子路由匹配；Any 直接调用处理器，r.URL.Path 保持不变，
+// 处理器可以根据原始路径或剩余路径重新构造上游地址。
+//
+// prefix 带尾部斜杠时会被规范化，"/" 匹配所有路径；prefix 为空时触发 panic。
+//
+// 示例：
+//
+//	// GET /proxy/a/b/c -> r.PathValue("path") == "a/b/c"
+//	mux.Any("/proxy", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//		upstream := "http://backend/" + r.PathValue("path")
+//		// ...
+//	}))
+func (m *mux) Any(prefix string, handler http.Handler) {
+	if prefix == "" {
+		panic(errors.New("h3: invalid pattern"))
+	}
+
+	prefix = strings.TrimSuffix(prefix, "/")
+	m.register(prefix+"/{path...}", handler)
+}
+
 // Mount 将子路由挂载到指定路径
 //
 // 子路由中的所有模式都会自动添加 pattern 作为前缀。
@@ -138,6 +479,7 @@ func (m *mux) Mount(pattern string, mux Mux) {
 	// 根路径特殊处理
 	if pattern == "/" {
 		m.register("/", mux)
+		m.rts[len(m.rts)-1].sub = mux
 		return
 	}
 
@@ -150,6 +492,354 @@ func (m *mux) Mount(pattern string, mux Mux) {
 	// 例如: /api -> /api/{path...}
 	// StripPrefix 会移除 /api 前缀，然后交给子路由处理
 	m.register(pattern+"/{path...}", http.StripPrefix(pattern, mux))
+	m.rts[len(m.rts)-1].sub = mux
+}
+
+// NotFound 设置没有路由匹配时使用的 404 处理器
+//
+// 只有在路径没有任何路由匹配时才会调用；路径匹配但方法不匹配时
+// 仍然返回标准的 405 Method Not Allowed 响应。
+//
+// 与 Mount 的关系：挂载的子路由会捕获其前缀下的所有路径，
+// 因此前缀下的未匹配请求由子路由自己的 NotFound 处理，
+// 外层路由的 NotFound 只处理前缀之外的路径。
+//
+// 传入 nil 恢复默认的 404 响应。
+func (m *mux) NotFound(handler http.Handler) {
+	m.nf = handler
+}
+
+// Fallback 设置没有路由匹配时的兜底处理器
+//
+// Fallback 优先于 NotFound：两者都设置时，未匹配的请求交给 Fallback 处理。
+// 典型用法是为单页应用的任意前端路由返回 index.html，
+// 同时让挂载的 API 子路由通过自己的 NotFound 返回 JSON 格式的 404。
+//
+// 与 Mount 的关系同 NotFound：外层的 Fallback 不会接管子路由前缀下的请求，
+// 子路由可以设置自己的 Fallback 或 NotFound。
+//
+// 示例：
+//
+//	api := h3.NewMux()
+//	api.NotFound(jsonNotFound)
+//
+//	mux := h3.NewMux()
+//	mux.Mount("/api", api)
+//	mux.Fallback(spaIndex)
+//
+// 传入 nil 取消兜底处理器。
+func (m *mux) Fallback(handler http.Handler) {
+	m.fb = handler
+}
+
+// MethodFallback 设置指定方法的请求没有路由匹配时的兜底处理器
+//
+// 例如为 GET 和 POST 分别设置兜底处理器后，没有匹配到具体路由的 GET 请求
+// 交给前者，POST 请求交给后者，其他方法仍然按原来的方式处理。
+// 路径只为其他方法注册了路由（原本返回 405）的请求同样交给该方法的兜底处理器。
+// 与 http.ServeMux 中 GET 路由同时匹配 HEAD 一致，没有设置 HEAD 兜底处理器时，
+// HEAD 请求使用 GET 的兜底处理器。
+//
+// 优先级从高到低为：具体路由（包括挂载的子路由）、MethodFallback、Fallback、NotFound。
+// 兜底处理器在中间件链之内调用，与普通路由一样经过 Use 注册的中间件。
+//
+// 这里没有注册 "GET /" 这样的路由模式：它会让其他方法对未知路径的请求
+// 从 404 变为 405，从而绕过 Fallback 和 NotFound。
+//
+// method 为空时触发 panic；传入 nil 处理器取消该方法的兜底处理器。
+//
+// 示例：
+//
+//	mux.MethodFallback(http.MethodGet, spaIndex)
+//	mux.MethodFallback(http.MethodPost, http.HandlerFunc(rejectUnknownAction))
+func (m *mux) MethodFallback(method string, handler http.Handler) {
+	if method == "" {
+		panic(errors.New("h3: invalid method"))
+	}
+	if handler == nil {
+		delete(m.mfb, method)
+		return
+	}
+	if m.mfb == nil {
+		m.mfb = make(map[string]http.Handler)
+	}
+	m.mfb[method] = handler
+}
+
+// methodFallback 返回请求方法对应的兜底处理器，没有时返回 nil
+func (m *mux) methodFallback(r *http.Request) http.Handler {
+	if h := m.mfb[r.Method]; h != nil {
+		return h
+	}
+	if r.Method == http.MethodHead {
+		return m.mfb[http.MethodGet]
+	}
+	return nil
+}
+
+// SPA 在指定路径下提供单页应用（Single Page Application）
+//
+// 请求的路径对应 fsys 中存在的文件时，直接返回该文件；
+// 否则返回 index 文件，交给前端路由处理。位于 assets 子树下的路径
+// 不会回退，缺失的资源文件返回真实的 404，避免浏览器把 HTML 当作脚本或样式加载。
+//
+// 参数:
+//   - prefix: 挂载路径，规则与 Mount 相同
+//   - fsys: 静态文件系统，通常是 embed.FS（可配合 fs.Sub 去掉目录前缀）
+//   - index: 回退使用的文件名，例如 "index.html"
+//   - assets: 相对于 prefix 的资源子树，默认为 "/assets/"
+//
+// 只响应 GET 和 HEAD 请求，其他方法返回 405。
+// 路由注册时不带方法前缀，以免与 Mount 挂载的子路由冲突。
+//
+// 示例:
+//
+//	//go:embed dist
+//	var dist embed.FS
+//
+//	sub, _ := fs.Sub(dist, "dist")
+//	mux.Mount("/api", api)
+//	mux.SPA("/", sub, "index.html")
+func (m *mux) SPA(prefix string, fsys fs.FS, index string, assets ...string) {
+	if prefix == "" {
+		panic(errors.New("h3: invalid pattern"))
+	}
+	if len(assets) == 0 {
+		assets = []string{"/assets/"}
+	}
+
+	h := &spa{
+		fsys:   fsys,
+		index:  index,
+		assets: assets,
+		files:  http.FileServerFS(fsys),
+	}
+
+	if prefix == "/" {
+		m.register("/", h)
+		return
+	}
+
+	// 规范化 prefix：去掉尾部斜杠
+	if prefix[len(prefix)-1] == '/' {
+		prefix = prefix[:len(prefix)-1]
+	}
+
+	m.register(prefix+"/{path...}", http.StripPrefix(prefix, h))
+}
+
+// Favicon 注册 GET /favicon.ico 路由
+//
+// 浏览器会自动请求 /favicon.ico，注册它可以避免大量 404 日志。
+// 响应的 Content-Type 根据数据内容检测，并设置长期缓存。
+// data 为空时返回 204 No Content，让浏览器不再重复请求。
+//
+// 示例:
+//
+//	//go:embed favicon.ico
+//	var favicon []byte
+//
+//	mux.Favicon(favicon)
+func (m *mux) Favicon(data []byte) {
+	contentType := http.DetectContentType(data)
+
+	m.register("GET /favicon.ico", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=31536000")
+		if len(data) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Write(data)
+	}))
+}
+
+// Robots 注册 GET /robots.txt 路由
+//
+// 以纯文本返回给定的内容，例如禁止所有爬虫：
+//
+//	mux.Robots("User-agent: *\nDisallow: /\n")
+func (m *mux) Robots(content string) {
+	m.register("GET /robots.txt", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write([]byte(content))
+	}))
+}
+
+// ServeFile 注册返回单个文件的路由
+//
+// 每次请求时打开 name 指定的文件，通过 http.ServeContent 写出：
+// Content-Type 根据扩展名或文件内容检测，设置 Last-Modified，
+// 并支持 If-Modified-Since 等条件请求和 Range 请求。
+// 文件不存在或是目录时，交给 NotFound 设置的处理器，未设置时返回 404。
+//
+// 文件路径在注册时确定，不会使用请求路径的任何部分，因此不存在目录遍历的问题。
+// name 为空时触发 panic。
+//
+// 示例:
+//
+//	mux.ServeFile("GET /.well-known/security.txt", "static/security.txt")
+func (m *mux) ServeFile(pattern, name string) {
+	if name == "" {
+		panic(errors.New("h3: ServeFile requires a file name"))
+	}
+	name = filepath.Clean(name)
+
+	m.register(pattern, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f, err := os.Open(name)
+		if err != nil {
+			m.notFound(w, r)
+			return
+		}
+		defer f.Close()
+
+		fi, err := f.Stat()
+		if err != nil || fi.IsDir() {
+			m.notFound(w, r)
+			return
+		}
+
+		http.ServeContent(w, r, fi.Name(), fi.ModTime(), f)
+	}))
+}
+
+// notFound 使用 NotFound 设置的处理器写出 404 响应
+func (m *mux) notFound(w http.ResponseWriter, r *http.Request) {
+	if m.nf != nil {
+		m.nf.ServeHTTP(w, r)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+// VersionSwitch 返回根据版本请求头分发到不同处理器的处理器
+//
+// 用于不修改 URL 的 API 版本控制。返回的处理器读取请求头 header 的值，
+// 分发到 versions 中对应的处理器；请求头缺失或版本未知时交给 fallback，
+// fallback 为 nil 时返回 404。响应会带上 "Vary: header"，以免缓存混用不同版本的响应。
+//
+// 返回的处理器需要通过 Handle 注册到路由上，因此同样经过全局中间件，
+// 各版本的处理器收到的 http.ResponseWriter 都是 Response。
+//
+// 参数:
+//   - header: 版本请求头名称，为空时使用 "Accept-Version"
+//   - versions: 版本号到处理器的映射
+//   - fallback: 请求头缺失或版本未知时使用的处理器
+//
+// 示例:
+//
+//	mux.Handle("GET /users", mux.VersionSwitch("Accept-Version", map[string]http.Handler{
+//		"1": usersV1,
+//		"2": usersV2,
+//	}, usersV1))
+func (m *mux) VersionSwitch(header string, versions map[string]http.Handler, fallback http.Handler) http.Handler {
+	if header == "" {
+		header = "Accept-Version"
+	}
+	if fallback == nil {
+		fallback = http.NotFoundHandler()
+	}
+
+	handlers := maps.Clone(versions)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := NewResponse(w)
+		rw.Header().Add("Vary", header)
+
+		if h, ok := handlers[strings.TrimSpace(r.Header.Get(header))]; ok {
+			h.ServeHTTP(rw, r)
+			return
+		}
+		fallback.ServeHTTP(rw, r)
+	})
+}
+
+// ContentTypeSwitch 在同一路由上根据请求的 Content-Type 前缀分发到不同的处理器
+//
+// 用于在同一路径上同时提供 gRPC、gRPC-Web、Connect 和 REST 等不同协议的服务。
+// handlers 的键是 Content-Type 前缀（不区分大小写），多个前缀匹配时使用最长的前缀，
+// 例如 "application/grpc-web" 优先于 "application/grpc"。
+// 没有匹配的前缀时交给 fallback，fallback 为 nil 时返回 415 Unsupported Media Type。
+//
+// 参数:
+//   - pattern: 路由模式，与 Handle 相同
+//   - handlers: Content-Type 前缀到处理器的映射
+//   - fallback: 没有匹配的前缀时使用的处理器
+//
+// 示例:
+//
+//	mux.ContentTypeSwitch("POST /greet.v1.GreetService/{method}", map[string]http.Handler{
+//		"application/grpc":  grpcHandler,
+//		"application/proto": connectHandler,
+//	}, restHandler)
+func (m *mux) ContentTypeSwitch(pattern string, handlers map[string]http.Handler, fallback http.Handler) {
+	if fallback == nil {
+		fallback = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			code := http.StatusUnsupportedMediaType
+			http.Error(w, http.StatusText(code), code)
+		})
+	}
+
+	// 按前缀长度从长到短排列，保证最长前缀优先
+	prefixes := slices.SortedFunc(maps.Keys(handlers), func(a, b string) int {
+		return len(b) - len(a)
+	})
+	targets := make([]http.Handler, len(prefixes))
+	for i, p := range prefixes {
+		targets[i] = handlers[p]
+		prefixes[i] = strings.ToLower(p)
+	}
+
+	m.register(pattern, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ct := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Type")))
+		if ct != "" {
+			for i, p := range prefixes {
+				if strings.HasPrefix(ct, p) {
+					targets[i].ServeHTTP(w, r)
+					return
+				}
+			}
+		}
+		fallback.ServeHTTP(w, r)
+	}))
+}
+
+// Clone 返回包含相同中间件和路由的独立副本
+//
+// 副本使用新的 http.ServeMux，并按原来的顺序重新注册所有路由，
+// 同时复制中间件链、NotFound 和 Fallback 处理器。
+// 之后在副本或原路由器上添加路由、中间件，互不影响。
+//
+// 复制是浅层的：处理器本身（包括通过 Mount 挂载的子路由）在两者之间共享。
+//
+// 示例：
+//
+//	base := h3.NewMux()
+//	base.Use(authMiddleware)
+//	base.HandleFunc("GET /health", health)
+//
+//	mux := base.Clone()
+//	mux.HandleFunc("GET /test", testHandler) // 不影响 base
+func (m *mux) Clone() Mux {
+	c := &mux{
+		mux: http.NewServeMux(),
+		mws: slices.Clone(m.mws),
+		nf:  m.nf,
+		fb:  m.fb,
+		mfb: maps.Clone(m.mfb),
+		rec: m.rec,
+		aho: m.aho,
+		nmt: m.nmt,
+		bkd: maps.Clone(m.bkd),
+	}
+	c.compose()
+
+	for _, rt := range m.rts {
+		c.register(rt.pattern, rt.handler)
+		c.rts[len(c.rts)-1].sub = rt.sub
+		c.rts[len(c.rts)-1].name = rt.name
+	}
+	return c
 }
 
 // register 注册路由，如果参数无效则 panic
@@ -165,7 +855,10 @@ func (mux *mux) register(pattern string, handler http.Handler) {
 //   - pattern 不能为空
 //   - handler 不能为 nil
 //   - http.HandlerFunc 类型的 handler 不能为 nil 函数
-func (m *mux) registerErr(pattern string, handler http.Handler) error {
+//
+// http.ServeMux 在模式无效或与已注册的模式冲突时会 panic，
+// 这里将其转换为错误，错误信息包含冲突的两个模式。
+func (m *mux) registerErr(pattern string, handler http.Handler) (err error) {
 	if pattern == "" {
 		return errors.New("h3: invalid pattern")
 	}
@@ -176,18 +869,215 @@ func (m *mux) registerErr(pattern string, handler http.Handler) error {
 		return errors.New("h3: nil handler")
 	}
 
+	defer func() {
+		if v := recover(); v != nil {
+			err = fmt.Errorf("h3: cannot register %q: %v", pattern, v)
+		}
+	}()
+
 	m.mux.Handle(pattern, handler)
+	m.rts = append(m.rts, route{pattern: pattern, handler: handler})
 	return nil
 }
 
+// Recover 在整个中间件链的最外层捕获 panic
+//
+// 通过 Use 添加的 Recoverer 只能保护在它之后执行的中间件和处理器，
+// 排在它之前的中间件发生 panic 时仍然会中断连接。
+// 调用 Recover 后，ServeHTTP 使用 Recoverer 包装整个中间件链，
+// 任何中间件或处理器的 panic 都会被记录并以 500 响应，
+// http.ErrAbortHandler 仍然会被重新抛出。
+//
+// render 为空时使用 RenderJSONError。
+//
+// 示例：
+//
+//	mux := h3.NewMux()
+//	mux.Recover()
+//	mux.Use(authMiddleware) // authMiddleware 中的 panic 同样会被捕获
+func (m *mux) Recover(render ...ErrorRenderer) {
+	m.rec = Recoverer(render...)
+}
+
+// AutoHeadOptions 设置是否为所有路由自动响应 HEAD 和 OPTIONS 请求
+//
+// http.ServeMux 已经让 GET 路由同时匹配 HEAD 请求，开启后还会:
+//   - 对已注册路由的路径发起的 OPTIONS 请求返回 204，Allow 头列出该路径的所有方法和 OPTIONS
+//   - 405 响应的 Allow 头同样包含 OPTIONS
+//
+// 显式注册的 HEAD 或 OPTIONS 路由优先于自动行为。
+// 没有任何路由的路径仍然返回 404。
+// 设置只对当前路由器生效，挂载的子路由需要分别开启。
+//
+// 示例：
+//
+//	mux.AutoHeadOptions(true)
+//	mux.HandleFunc("GET /users", listUsers)
+//	mux.HandleFunc("POST /users", createUser)
+//	// OPTIONS /users -> 204, Allow: GET, HEAD, POST, OPTIONS
+func (m *mux) AutoHeadOptions(enable bool) {
+	m.aho = enable
+}
+
+// NormalizeMethod 设置是否在路由之前规范化请求方法
+//
+// RFC 9110 规定请求方法区分大小写，http.ServeMux 因此不会用 "GET /users" 匹配
+// 方法为 "get" 的请求，而是返回令人困惑的 404 或 405。
+// 这是一个出于兼容性考虑的开关，用于接纳发送小写方法的不规范客户端：
+// 开启后请求方法在进入中间件链之前被转换为大写，中间件和处理器看到的都是转换后的方法；
+// 不是合法 token 的方法（例如包含空格或分隔符）直接返回 400 Bad Request。
+// 设置只对当前路由器生效，挂载的子路由看到的已经是转换后的方法。
+//
+// 示例：
+//
+//	mux.NormalizeMethod(true)
+//	mux.HandleFunc("GET /users", listUsers)
+//	// "get /users" -> listUsers
+func (m *mux) NormalizeMethod(enable bool) {
+	m.nmt = enable
+}
+
+// normalizeMethod 将请求方法转换为大写，方法不是合法的 token 时返回 false
+func normalizeMethod(r *http.Request) (*http.Request, bool) {
+	if r.Method == "" {
+		return r, true
+	}
+	for i := 0; i < len(r.Method); i++ {
+		if !isTokenChar(r.Method[i]) {
+			return r, false
+		}
+	}
+
+	upper := strings.ToUpper(r.Method)
+	if upper == r.Method {
+		return r, true
+	}
+	r2 := *r
+	r2.Method = upper
+	return &r2, true
+}
+
+// isTokenChar 判断字节是否为 RFC 9110 中 token 允许的字符
+func isTokenChar(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0
+}
+
 // ServeHTTP 实现 http.Handler 接口
 //
 // 如果存在中间件，会先应用中间件链，然后调用底层路由器。
 // 如果没有中间件，直接调用底层路由器。
+// 调用过 Recover 时，整个中间件链被包装在 panic 恢复之内。
+// 开启 NormalizeMethod 时，请求方法在这一切之前被转换为大写。
+// 请求上下文中还没有 Store 时，会先创建一个，供 StoreFromRequest 使用。
 func (m *mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if m.pre != nil {
-		m.pre(m.mux).ServeHTTP(NewResponse(w), r)
-	} else {
-		m.mux.ServeHTTP(NewResponse(w), r)
+	if m.nmt {
+		var ok bool
+		if r, ok = normalizeMethod(r); !ok {
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if StoreFromRequest(r) == nil {
+		r = r.WithContext(context.WithValue(r.Context(), storeKey{}, &Store{}))
+	}
+
+	var h http.Handler = http.HandlerFunc(m.serve)
+	if m.pre != nil && !m.bypass(r) {
+		h = m.pre(h)
+	}
+	if m.rec != nil {
+		h = m.rec(h)
+	}
+	h.ServeHTTP(NewResponse(w), r)
+}
+
+// serve 分发请求到底层路由器
+//
+// 没有路由匹配时，按以下顺序选择处理方式：
+//  1. 404 或 405 且设置了请求方法的 MethodFallback：交给该兜底处理器
+//  2. 404 且设置了 Fallback：交给 Fallback
+//  3. 404 且设置了 NotFound：交给 NotFound
+//  4. 405 且开启了 AutoHeadOptions：OPTIONS 请求返回 204，其他请求的 Allow 头添加 OPTIONS
+//  5. 404 或 405 且应用配置了 Options.ErrorResponseWriter：由其写出错误响应
+//  6. 否则使用 http.ServeMux 的默认行为
+func (m *mux) serve(w http.ResponseWriter, r *http.Request) {
+	ew, _ := r.Context().Value(errorWriterKey{}).(func(http.ResponseWriter, *http.Request, int))
+
+	if m.fb != nil || m.nf != nil || ew != nil || m.aho || len(m.mfb) > 0 {
+		if h, pattern := m.mux.Handler(r); pattern == "" {
+			pw := probe(h, r)
+			mfb := m.methodFallback(r)
+			switch {
+			case (pw.status == http.StatusNotFound || pw.status == http.StatusMethodNotAllowed) && mfb != nil:
+				mfb.ServeHTTP(w, r)
+				return
+			case pw.status == http.StatusNotFound && m.fb != nil:
+				m.fb.ServeHTTP(w, r)
+				return
+			case pw.status == http.StatusNotFound && m.nf != nil:
+				m.nf.ServeHTTP(w, r)
+				return
+			case pw.status == http.StatusMethodNotAllowed && m.aho:
+				w.Header().Set("Allow", pw.header.Get("Allow")+", OPTIONS")
+				switch {
+				case r.Method == http.MethodOptions:
+					w.WriteHeader(http.StatusNoContent)
+				case ew != nil:
+					ew(w, r, http.StatusMethodNotAllowed)
+				default:
+					http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+				}
+				return
+			case (pw.status == http.StatusNotFound || pw.status == http.StatusMethodNotAllowed) && ew != nil:
+				if allow := pw.header.Get("Allow"); allow != "" {
+					w.Header().Set("Allow", allow)
+				}
+				ew(w, r, pw.status)
+				return
+			}
+		}
+	}
+
+	m.mux.ServeHTTP(w, r)
+}
+
+// errorWriterKey 请求上下文中 Options.ErrorResponseWriter 的键
+type errorWriterKey struct{}
+
+// probe 在不写出响应的情况下执行处理器，返回记录了状态码和响应头的 probeWriter
+//
+// 仅用于 http.ServeMux 在未匹配时返回的内置处理器（404、405、重定向），
+// 以区分它们的响应类型。
+func probe(h http.Handler, r *http.Request) *probeWriter {
+	w := &probeWriter{header: make(http.Header), status: http.StatusOK}
+	h.ServeHTTP(w, r)
+	return w
+}
+
+// probeWriter 只记录状态码并丢弃响应体的 ResponseWriter
+type probeWriter struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+}
+
+func (w *probeWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *probeWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return len(p), nil
+}
+
+func (w *probeWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
 	}
 }
@@ -1,10 +1,20 @@
 package h3
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
 	"testing"
+	"testing/fstest"
+	"time"
 )
 
 func TestNewMux(t *testing.T) {
@@ -414,3 +424,1377 @@ func TestMuxResponseWrapping(t *testing.T) {
 
 	mux.ServeHTTP(rec, req)
 }
+
+func TestMuxReplaceMiddleware(t *testing.T) {
+	mux := NewMux()
+
+	mux.UseNamed("auth", func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		})
+	})
+
+	mux.HandleFunc("GET /test", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusUnauthorized)
+	}
+
+	ok := mux.Replace("auth", func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Auth", "fake")
+			next.ServeHTTP(w, r)
+		})
+	})
+	if !ok {
+		t.Fatal("Replace returned false for registered middleware")
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	if got := rec.Header().Get("X-Auth"); got != "fake" {
+		t.Errorf("X-Auth header = %q, want %q", got, "fake")
+	}
+
+	if mux.Replace("missing", func(next http.Handler) http.Handler { return next }) {
+		t.Error("Replace should return false for unknown name")
+	}
+}
+
+func TestMuxReplaceMiddlewareKeepsOrder(t *testing.T) {
+	mux := NewMux()
+
+	order := []string{}
+	named := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	mux.UseNamed("first", named("first"))
+	mux.UseNamed("second", named("second"))
+	mux.UseNamed("third", named("third"))
+	mux.Replace("second", named("replaced"))
+
+	mux.HandleFunc("GET /test", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	expected := []string{"first", "replaced", "third"}
+	if strings.Join(order, ",") != strings.Join(expected, ",") {
+		t.Errorf("order = %v, want %v", order, expected)
+	}
+}
+
+func TestMuxRemoveMiddleware(t *testing.T) {
+	mux := NewMux()
+
+	mux.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Global", "true")
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	mux.UseNamed("auth", func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		})
+	})
+
+	mux.HandleFunc("GET /test", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	if !mux.Remove("auth") {
+		t.Fatal("Remove returned false for registered middleware")
+	}
+
+	req := httptest.NewRequest("GET", "/test", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	if got := rec.Header().Get("X-Global"); got != "true" {
+		t.Errorf("X-Global header = %q, want %q", got, "true")
+	}
+
+	if mux.Remove("auth") {
+		t.Error("Remove should return false for already removed middleware")
+	}
+}
+
+func TestMuxNotFound(t *testing.T) {
+	mux := NewMux()
+
+	mux.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("users"))
+	})
+
+	mux.NotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"not found"}`))
+	}))
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{"GET", "/users", http.StatusOK, "users"},
+		{"GET", "/missing", http.StatusNotFound, `{"error":"not found"}`},
+		{"POST", "/users", http.StatusMethodNotAllowed, "Method Not Allowed\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+
+			if rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+		})
+	}
+}
+
+func TestMuxFallback(t *testing.T) {
+	api := NewMux()
+	api.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("users"))
+	})
+	api.NotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"not found"}`))
+	}))
+
+	mux := NewMux()
+	mux.Mount("/api", api)
+	mux.HandleFunc("GET /assets/app.js", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("js"))
+	})
+
+	// 外层的 NotFound 被 Fallback 覆盖
+	mux.NotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("outer NotFound should not be called when Fallback is set")
+	}))
+	mux.Fallback(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("index.html"))
+	}))
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{"GET", "/assets/app.js", http.StatusOK, "js"},
+		{"GET", "/dashboard/settings", http.StatusOK, "index.html"},
+		{"GET", "/", http.StatusOK, "index.html"},
+		{"POST", "/dashboard", http.StatusNotFound, "404 page not found\n"},
+		{"GET", "/api/users", http.StatusOK, "users"},
+		{"GET", "/api/missing", http.StatusNotFound, `{"error":"not found"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+
+			if rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+		})
+	}
+}
+
+func TestMuxMethodFallback(t *testing.T) {
+	text := func(s string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(s))
+		})
+	}
+
+	mux := NewMux()
+	mux.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Middleware", "1")
+			next.ServeHTTP(w, r)
+		})
+	})
+	mux.Handle("GET /users", text("users"))
+	mux.Handle("POST /orders", text("orders"))
+	mux.MethodFallback(http.MethodGet, text("get-fallback"))
+	mux.MethodFallback(http.MethodPost, text("post-fallback"))
+	mux.Fallback(text("fallback"))
+
+	tests := []struct {
+		method, path string
+		want         string
+	}{
+		{"GET", "/users", "users"},
+		{"POST", "/orders", "orders"},
+		{"GET", "/unknown", "get-fallback"},
+		{"POST", "/unknown", "post-fallback"},
+		{"GET", "/orders", "get-fallback"},
+		{"POST", "/users", "post-fallback"},
+		{"PUT", "/unknown", "fallback"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Body.String() != tt.want {
+			t.Errorf("%s %s = %q, want %q", tt.method, tt.path, rec.Body.String(), tt.want)
+		}
+		if rec.Header().Get("X-Middleware") != "1" {
+			t.Errorf("%s %s did not pass through middleware", tt.method, tt.path)
+		}
+	}
+
+	// HEAD 使用 GET 的兜底处理器
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("HEAD", "/unknown", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "get-fallback" {
+		t.Errorf("HEAD /unknown = %d %q, want GET fallback", rec.Code, rec.Body.String())
+	}
+
+	// 没有 Fallback 时其他方法仍然是 404 和 405
+	plain := NewMux()
+	plain.Handle("POST /orders", text("orders"))
+	plain.MethodFallback(http.MethodGet, text("get-fallback"))
+	for _, tt := range []struct {
+		method, path string
+		status       int
+	}{
+		{"PUT", "/unknown", http.StatusNotFound},
+		{"PUT", "/orders", http.StatusMethodNotAllowed},
+	} {
+		rec := httptest.NewRecorder()
+		plain.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s %s = %d, want %d", tt.method, tt.path, rec.Code, tt.status)
+		}
+	}
+
+	// 传入 nil 取消兜底处理器
+	plain.MethodFallback(http.MethodGet, nil)
+	rec = httptest.NewRecorder()
+	plain.ServeHTTP(rec, httptest.NewRequest("GET", "/unknown", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET /unknown after removal = %d, want 404", rec.Code)
+	}
+}
+
+func TestMuxSPA(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":        {Data: []byte("<html>index</html>")},
+		"assets/app.js":     {Data: []byte("console.log(1)")},
+		"static/robots.txt": {Data: []byte("robots")},
+	}
+
+	api := NewMux()
+	api.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("users"))
+	})
+
+	mux := NewMux()
+	mux.Mount("/api", api)
+	mux.SPA("/", fsys, "index.html")
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{"root", "GET", "/", http.StatusOK, "<html>index</html>"},
+		{"asset", "GET", "/assets/app.js", http.StatusOK, "console.log(1)"},
+		{"static file", "GET", "/static/robots.txt", http.StatusOK, "robots"},
+		{"client route", "GET", "/dashboard/settings", http.StatusOK, "<html>index</html>"},
+		{"missing asset", "GET", "/assets/missing.js", http.StatusNotFound, "404 page not found\n"},
+		{"api", "GET", "/api/users", http.StatusOK, "users"},
+		{"method", "POST", "/dashboard", http.StatusMethodNotAllowed, "Method Not Allowed\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+
+			if rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+		})
+	}
+}
+
+func TestMuxSPAPrefix(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":     {Data: []byte("index")},
+		"static/app.css": {Data: []byte("css")},
+	}
+
+	mux := NewMux()
+	mux.SPA("/app/", fsys, "index.html", "/static/")
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/app/", http.StatusOK, "index"},
+		{"/app/static/app.css", http.StatusOK, "css"},
+		{"/app/profile", http.StatusOK, "index"},
+		{"/app/static/missing.css", http.StatusNotFound, "404 page not found\n"},
+		{"/other", http.StatusNotFound, "404 page not found\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+
+			if rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+		})
+	}
+}
+
+func TestMuxFavicon(t *testing.T) {
+	// ICO 文件头
+	ico := []byte{0x00, 0x00, 0x01, 0x00, 0x01, 0x00, 0x10, 0x10}
+
+	mux := NewMux()
+	mux.Favicon(ico)
+
+	req := httptest.NewRequest("GET", "/favicon.ico", nil)
+	rec := httptest.NewRecorder()
+
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	if got := rec.Header().Get("Content-Type"); got != "image/x-icon" {
+		t.Errorf("Content-Type = %q, want %q", got, "image/x-icon")
+	}
+
+	if got := rec.Header().Get("Cache-Control"); got != "public, max-age=31536000" {
+		t.Errorf("Cache-Control = %q, want %q", got, "public, max-age=31536000")
+	}
+
+	if rec.Body.String() != string(ico) {
+		t.Errorf("body = %v, want %v", rec.Body.Bytes(), ico)
+	}
+}
+
+func TestMuxFaviconEmpty(t *testing.T) {
+	mux := NewMux()
+	mux.Favicon(nil)
+
+	req := httptest.NewRequest("GET", "/favicon.ico", nil)
+	rec := httptest.NewRecorder()
+
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusNoContent)
+	}
+
+	if rec.Body.Len() != 0 {
+		t.Errorf("body length = %d, want 0", rec.Body.Len())
+	}
+
+	if got := rec.Header().Get("Cache-Control"); got == "" {
+		t.Error("Cache-Control should be set for empty favicon")
+	}
+}
+
+func TestMuxRobots(t *testing.T) {
+	mux := NewMux()
+	mux.Robots("User-agent: *\nDisallow: /admin\n")
+
+	req := httptest.NewRequest("GET", "/robots.txt", nil)
+	rec := httptest.NewRecorder()
+
+	mux.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	if got := rec.Header().Get("Content-Type"); got != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type = %q, want %q", got, "text/plain; charset=utf-8")
+	}
+
+	if rec.Body.String() != "User-agent: *\nDisallow: /admin\n" {
+		t.Errorf("body = %q, want %q", rec.Body.String(), "User-agent: *\nDisallow: /admin\n")
+	}
+}
+
+func TestMuxHandleMethods(t *testing.T) {
+	mux := NewMux()
+	mux.HandleMethods([]string{"GET", "POST", "GET"}, "/search", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Method))
+	}))
+
+	tests := []struct {
+		method string
+		status int
+		body   string
+		allow  string
+	}{
+		{"GET", http.StatusOK, "GET", ""},
+		{"POST", http.StatusOK, "POST", ""},
+		{"HEAD", http.StatusOK, "", ""},
+		{"DELETE", http.StatusMethodNotAllowed, "Method Not Allowed\n", "GET, HEAD, POST"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/search", nil)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+
+			if tt.method != "HEAD" && rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+
+			if got := rec.Header().Get("Allow"); got != tt.allow {
+				t.Errorf("Allow = %q, want %q", got, tt.allow)
+			}
+		})
+	}
+}
+
+func TestMuxHandleMethodsPanic(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name    string
+		methods []string
+	}{
+		{"no methods", nil},
+		{"empty method", []string{"GET", ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Error("expected panic")
+				}
+			}()
+
+			NewMux().HandleMethods(tt.methods, "/test", handler)
+		})
+	}
+}
+
+func TestMuxVersionSwitch(t *testing.T) {
+	version := func(v string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := w.(Response); !ok {
+				t.Errorf("ResponseWriter is %T, want Response", w)
+			}
+			w.Write([]byte(v))
+		})
+	}
+
+	mux := NewMux()
+	mux.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Middleware", "true")
+			next.ServeHTTP(w, r)
+		})
+	})
+	mux.Handle("GET /users", mux.VersionSwitch("", map[string]http.Handler{
+		"1": version("v1"),
+		"2": version("v2"),
+	}, version("default")))
+
+	tests := []struct {
+		name    string
+		version string
+		body    string
+	}{
+		{"matching version", "2", "v2"},
+		{"unknown version", "3", "default"},
+		{"missing header", "", "default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/users", nil)
+			if tt.version != "" {
+				req.Header.Set("Accept-Version", tt.version)
+			}
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+
+			if rec.Header().Get("X-Middleware") != "true" {
+				t.Error("middleware was not applied")
+			}
+
+			if got := rec.Header().Get("Vary"); got != "Accept-Version" {
+				t.Errorf("Vary = %q, want %q", got, "Accept-Version")
+			}
+		})
+	}
+}
+
+func TestMuxClone(t *testing.T) {
+	base := NewMux()
+	base.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Base", "true")
+			next.ServeHTTP(w, r)
+		})
+	})
+	base.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	clone := base.Clone()
+	clone.HandleFunc("GET /clone", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("clone"))
+	})
+	base.HandleFunc("GET /base", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("base"))
+	})
+	clone.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Clone", "true")
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	tests := []struct {
+		name   string
+		mux    Mux
+		path   string
+		status int
+		clone  string
+	}{
+		{"clone serves base route", clone, "/health", http.StatusOK, "true"},
+		{"clone serves own route", clone, "/clone", http.StatusOK, "true"},
+		{"clone unaffected by base", clone, "/base", http.StatusNotFound, "true"},
+		{"base serves own route", base, "/base", http.StatusOK, ""},
+		{"base unaffected by clone", base, "/clone", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			tt.mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+
+			if rec.Header().Get("X-Base") != "true" {
+				t.Error("base middleware was not applied")
+			}
+
+			if got := rec.Header().Get("X-Clone"); got != tt.clone {
+				t.Errorf("X-Clone = %q, want %q", got, tt.clone)
+			}
+		})
+	}
+}
+
+func TestMuxAny(t *testing.T) {
+	mux := NewMux()
+	mux.Any("/proxy/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s %s", r.Method, r.URL.Path, r.PathValue("path"))
+	}))
+	mux.HandleFunc("GET /other", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("other"))
+	})
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{"GET", "/proxy/users", http.StatusOK, "GET /proxy/users users"},
+		{"DELETE", "/proxy/a/b/c", http.StatusOK, "DELETE /proxy/a/b/c a/b/c"},
+		{"POST", "/proxy/", http.StatusOK, "POST /proxy/ "},
+		{"GET", "/other", http.StatusOK, "other"},
+		{"GET", "/proxyx", http.StatusNotFound, "404 page not found\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+
+			if rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+		})
+	}
+}
+
+func TestMuxConflictingPatterns(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	m := NewMux().(*mux)
+	if err := m.registerErr("/a/{x}", handler); err != nil {
+		t.Fatalf("registerErr failed: %v", err)
+	}
+
+	err := m.registerErr("/a/{y}", handler)
+	if err == nil {
+		t.Fatal("expected error for conflicting pattern")
+	}
+
+	msg := err.Error()
+	for _, want := range []string{"h3:", "/a/{x}", "/a/{y}", "conflicts"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error %q does not contain %q", msg, want)
+		}
+	}
+
+	// 冲突的模式没有被记录，Clone 不会重放它
+	if len(m.rts) != 1 {
+		t.Errorf("recorded %d routes, want 1", len(m.rts))
+	}
+}
+
+func TestMuxRecover(t *testing.T) {
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(io.Discard)
+
+	mux := NewMux()
+	mux.Recover()
+	mux.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.Header.Get("X-Panic") {
+			case "value":
+				panic("middleware failed")
+			case "abort":
+				panic(http.ErrAbortHandler)
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	mux.HandleFunc("GET /test", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	// 正常请求不受影响
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/test", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+		t.Errorf("response = %d %q, want %d %q", rec.Code, rec.Body.String(), http.StatusOK, "ok")
+	}
+
+	// 中间件的 panic 返回 500
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-Panic", "value")
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+
+	// http.ErrAbortHandler 被重新抛出
+	func() {
+		defer func() {
+			if v := recover(); v != http.ErrAbortHandler {
+				t.Errorf("recovered %v, want %v", v, http.ErrAbortHandler)
+			}
+		}()
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("X-Panic", "abort")
+		mux.ServeHTTP(httptest.NewRecorder(), req)
+	}()
+}
+
+func TestMuxMountCumulativeSize(t *testing.T) {
+	var outerRW, innerRW Response
+	var outerSize, innerSize int64
+
+	outer := NewMux()
+	outer.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			outerRW = NewResponse(w)
+			outerRW.Write([]byte("outer-"))
+			next.ServeHTTP(outerRW, r)
+			outerSize = outerRW.Size()
+		})
+	})
+
+	inner := NewMux()
+	inner.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			innerRW = NewResponse(w)
+			innerRW.Write([]byte("inner-"))
+			next.ServeHTTP(innerRW, r)
+			innerSize = innerRW.Size()
+		})
+	})
+	inner.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("users"))
+	})
+	outer.Mount("/api", inner)
+
+	rec := httptest.NewRecorder()
+	outer.ServeHTTP(rec, httptest.NewRequest("GET", "/api/users", nil))
+
+	if rec.Body.String() != "outer-inner-users" {
+		t.Fatalf("body = %q, want %q", rec.Body.String(), "outer-inner-users")
+	}
+	if outerRW != innerRW {
+		t.Error("inner mux should reuse the outer Response wrapper across the mount boundary")
+	}
+
+	want := int64(rec.Body.Len())
+	if outerSize != want || innerSize != want {
+		t.Errorf("sizes = outer %d, inner %d, want both %d", outerSize, innerSize, want)
+	}
+}
+
+func TestMuxServeFile(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "report.pdf")
+	if err := os.WriteFile(name, []byte("%PDF-1.4 test"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	mux := NewMux()
+	mux.ServeFile("GET /report", name)
+	mux.ServeFile("GET /missing", filepath.Join(dir, "missing.txt"))
+	mux.ServeFile("GET /dir", dir)
+
+	t.Run("existing file", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", "/report", nil))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/pdf" {
+			t.Errorf("Content-Type = %q, want %q", ct, "application/pdf")
+		}
+		if rec.Header().Get("Last-Modified") == "" {
+			t.Error("Last-Modified header should be set")
+		}
+		if rec.Body.String() != "%PDF-1.4 test" {
+			t.Errorf("body = %q", rec.Body.String())
+		}
+	})
+
+	t.Run("not modified", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/report", nil)
+		req.Header.Set("If-Modified-Since", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusNotModified {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusNotModified)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", "/missing", nil))
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("directory", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", "/dir", nil))
+
+		if rec.Code != http.StatusNotFound {
+			t.Errorf("status = %d, want %d", rec.Code, http.StatusNotFound)
+		}
+	})
+
+	t.Run("custom not found", func(t *testing.T) {
+		mux.NotFound(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("custom"))
+		}))
+		defer mux.NotFound(nil)
+
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", "/missing", nil))
+
+		if rec.Code != http.StatusNotFound || rec.Body.String() != "custom" {
+			t.Errorf("response = %d %q, want %d %q", rec.Code, rec.Body.String(), http.StatusNotFound, "custom")
+		}
+	})
+}
+
+func TestMuxServeFileEmptyName(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for empty file name")
+		}
+	}()
+	NewMux().ServeFile("GET /file", "")
+}
+
+func TestMuxAutoHeadOptions(t *testing.T) {
+	mux := NewMux()
+	mux.AutoHeadOptions(true)
+	mux.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Route", "users")
+		w.Write([]byte("users"))
+	})
+	mux.HandleFunc("POST /users", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("DELETE /users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("GET /reports", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("reports"))
+	})
+	// 显式注册的 HEAD 和 OPTIONS 优先
+	mux.HandleFunc("HEAD /reports", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Explicit", "head")
+	})
+	mux.HandleFunc("OPTIONS /reports", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", "custom")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	tests := []struct {
+		method, path string
+		status       int
+		allow        string
+	}{
+		{"OPTIONS", "/users", http.StatusNoContent, "GET, HEAD, POST, OPTIONS"},
+		{"OPTIONS", "/users/42", http.StatusNoContent, "DELETE, OPTIONS"},
+		{"PUT", "/users", http.StatusMethodNotAllowed, "GET, HEAD, POST, OPTIONS"},
+		{"OPTIONS", "/reports", http.StatusOK, "custom"},
+		{"OPTIONS", "/missing", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			rec := serve(tt.method, tt.path)
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if got := rec.Header().Get("Allow"); got != tt.allow {
+				t.Errorf("Allow = %q, want %q", got, tt.allow)
+			}
+		})
+	}
+
+	// GET 路由自动响应 HEAD
+	rec := serve("HEAD", "/users")
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Route") != "users" {
+		t.Errorf("HEAD /users = %d %q, want GET handler", rec.Code, rec.Header().Get("X-Route"))
+	}
+
+	// 显式的 HEAD 路由优先
+	rec = serve("HEAD", "/reports")
+	if rec.Header().Get("X-Explicit") != "head" {
+		t.Error("explicit HEAD handler should take precedence")
+	}
+}
+
+func TestMuxAutoHeadOptionsDisabled(t *testing.T) {
+	mux := NewMux()
+	mux.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("OPTIONS", "/users", nil))
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+	if got := rec.Header().Get("Allow"); got != "GET, HEAD" {
+		t.Errorf("Allow = %q, want %q", got, "GET, HEAD")
+	}
+}
+
+func TestMuxNormalizeMethod(t *testing.T) {
+	newMux := func(enable bool) Mux {
+		mux := NewMux()
+		mux.NormalizeMethod(enable)
+		mux.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("X-Method", r.Method)
+				next.ServeHTTP(w, r)
+			})
+		})
+		mux.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("users"))
+		})
+		return mux
+	}
+
+	serve := func(mux Mux, method string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/users", nil)
+		r.Method = method
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, r)
+		return rec
+	}
+
+	on := newMux(true)
+	rec := serve(on, "get")
+	if rec.Code != http.StatusOK || rec.Body.String() != "users" {
+		t.Errorf("get with NormalizeMethod = %d %q, want 200 users", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("X-Method"); got != "GET" {
+		t.Errorf("method seen by middleware = %q, want GET", got)
+	}
+
+	for _, method := range []string{"GE T", "GET/", "GéT"} {
+		rec := serve(on, method)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%q with NormalizeMethod = %d, want 400", method, rec.Code)
+		}
+		if rec.Header().Get("X-Method") != "" {
+			t.Errorf("%q reached the middleware chain", method)
+		}
+	}
+
+	// 默认按 RFC 区分大小写：路径存在但方法不匹配
+	off := newMux(false)
+	rec = serve(off, "get")
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("get without NormalizeMethod = %d, want 405", rec.Code)
+	}
+	if got := rec.Header().Get("X-Method"); got != "get" {
+		t.Errorf("method seen by middleware = %q, want get", got)
+	}
+
+	// Clone 保留设置
+	if rec := serve(on.Clone(), "get"); rec.Code != http.StatusOK {
+		t.Errorf("get on clone = %d, want 200", rec.Code)
+	}
+}
+
+func TestMuxHandleWith(t *testing.T) {
+	var trace []string
+	mux := NewMux()
+	mux.Recover()
+	mux.Use(tracer(&trace, "global"))
+	mux.HandleWith("GET /fast/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trace = append(trace, "fast "+r.PathValue("id"))
+		if r.PathValue("id") == "panic" {
+			panic("boom")
+		}
+	}), tracer(&trace, "a"), tracer(&trace, "b"))
+	mux.HandleFunc("GET /slow", func(w http.ResponseWriter, r *http.Request) {
+		trace = append(trace, "slow")
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		trace = nil
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		return rec
+	}
+
+	serve("/fast/1")
+	want := []string{"a before", "b before", "fast 1", "b after", "a after"}
+	if !slices.Equal(trace, want) {
+		t.Errorf("baked route trace = %v, want %v", trace, want)
+	}
+
+	serve("/slow")
+	want = []string{"global before", "slow", "global after"}
+	if !slices.Equal(trace, want) {
+		t.Errorf("dynamic route trace = %v, want %v", trace, want)
+	}
+
+	// 之后添加的中间件对已注册的路由无效
+	mux.Use(tracer(&trace, "late"))
+	serve("/fast/2")
+	want = []string{"a before", "b before", "fast 2", "b after", "a after"}
+	if !slices.Equal(trace, want) {
+		t.Errorf("baked route trace after Use = %v, want %v", trace, want)
+	}
+
+	// 仍然受 Recover 保护
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(io.Discard)
+	if rec := serve("/fast/panic"); rec.Code != http.StatusInternalServerError {
+		t.Errorf("panic status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+}
+
+// benchmarkMiddleware 设置响应头的简单中间件
+func benchmarkMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Bench", "1")
+		next.ServeHTTP(w, r)
+	})
+}
+
+func benchmarkMuxRoute(b *testing.B, mux Mux) {
+	req := httptest.NewRequest("GET", "/items/42", nil)
+	w := httptest.NewRecorder()
+
+	for b.Loop() {
+		mux.ServeHTTP(w, req)
+	}
+}
+
+func BenchmarkMuxDynamicChain(b *testing.B) {
+	mux := NewMux()
+	for range 5 {
+		mux.Use(benchmarkMiddleware)
+	}
+	mux.HandleFunc("GET /items/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	benchmarkMuxRoute(b, mux)
+}
+
+func BenchmarkMuxHandleWith(b *testing.B) {
+	mux := NewMux()
+	mux.Use(benchmarkMiddleware)
+	mux.HandleWith("GET /items/{id}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		benchmarkMiddleware, benchmarkMiddleware, benchmarkMiddleware, benchmarkMiddleware, benchmarkMiddleware)
+
+	benchmarkMuxRoute(b, mux)
+}
+
+func TestMuxContentTypeSwitch(t *testing.T) {
+	named := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(name + ":" + r.PathValue("method")))
+		})
+	}
+
+	mux := NewMux()
+	mux.ContentTypeSwitch("POST /greet.v1.GreetService/{method}", map[string]http.Handler{
+		"application/grpc":     named("grpc"),
+		"application/grpc-web": named("grpc-web"),
+		"application/json":     named("rest"),
+	}, nil)
+	mux.ContentTypeSwitch("POST /echo", map[string]http.Handler{
+		"application/grpc": named("grpc"),
+	}, named("fallback"))
+
+	tests := []struct {
+		path, contentType string
+		status            int
+		body              string
+	}{
+		{"/greet.v1.GreetService/Greet", "application/grpc", http.StatusOK, "grpc:Greet"},
+		{"/greet.v1.GreetService/Greet", "application/grpc+proto", http.StatusOK, "grpc:Greet"},
+		{"/greet.v1.GreetService/Greet", "application/grpc-web+proto", http.StatusOK, "grpc-web:Greet"},
+		{"/greet.v1.GreetService/Greet", "Application/JSON; charset=utf-8", http.StatusOK, "rest:Greet"},
+		{"/greet.v1.GreetService/Greet", "text/plain", http.StatusUnsupportedMediaType, ""},
+		{"/greet.v1.GreetService/Greet", "", http.StatusUnsupportedMediaType, ""},
+		{"/echo", "application/xml", http.StatusOK, "fallback:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path+" "+tt.contentType, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.path, nil)
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.body != "" && rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+		})
+	}
+}
+
+func TestMuxUseFor(t *testing.T) {
+	var got []string
+	mux := NewMux()
+	mux.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = append(got, "outer")
+			next.ServeHTTP(w, r)
+		})
+	})
+	mux.UseFor(func(pattern string, next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = append(got, pattern)
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	mux.HandleFunc("GET /users/{id}", ok)
+	mux.HandleFunc("GET /files/{path...}", ok)
+	mux.HandleFunc("POST /users/{id}/posts/{post}", ok)
+
+	tests := []struct {
+		method, path, pattern string
+	}{
+		{"GET", "/users/42", "GET /users/{id}"},
+		{"GET", "/files/a/b/c.txt", "GET /files/{path...}"},
+		{"POST", "/users/1/posts/2", "POST /users/{id}/posts/{post}"},
+		{"GET", "/missing", ""},
+	}
+
+	for _, tt := range tests {
+		got = nil
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+		if want := []string{"outer", tt.pattern}; !slices.Equal(got, want) {
+			t.Errorf("%s %s: got %q, want %q", tt.method, tt.path, got, want)
+		}
+	}
+
+	// Clone 后的副本使用自己的路由解析模式
+	c := mux.Clone()
+	c.HandleFunc("GET /orders/{id}", ok)
+	got = nil
+	c.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders/7", nil))
+	if want := []string{"outer", "GET /orders/{id}"}; !slices.Equal(got, want) {
+		t.Errorf("clone: got %q, want %q", got, want)
+	}
+}
+
+func TestMuxUseForVariesByRoute(t *testing.T) {
+	limits := map[string]string{
+		"GET /users/{id}": "10",
+		"POST /login":     "1",
+	}
+	mux := NewMux()
+	mux.UseFor(func(pattern string, next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if n, ok := limits[pattern]; ok {
+				w.Header().Set("X-RateLimit-Limit", n)
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {})
+
+	for path, want := range map[string]string{"/users/1": "10", "/users/2": "10"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if got := rec.Header().Get("X-RateLimit-Limit"); got != want {
+			t.Errorf("GET %s limit = %q, want %q", path, got, want)
+		}
+	}
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("POST", "/login", nil))
+	if got := rec.Header().Get("X-RateLimit-Limit"); got != "1" {
+		t.Errorf("POST /login limit = %q, want %q", got, "1")
+	}
+}
+
+// userHandler 用于验证处理器类型名的测试处理器
+type userHandler struct{}
+
+func (userHandler) ServeHTTP(http.ResponseWriter, *http.Request) {}
+
+func listOrders(http.ResponseWriter, *http.Request) {}
+
+func TestMuxWalk(t *testing.T) {
+	noop := func(next http.Handler) http.Handler { return next }
+
+	items := NewMux()
+	items.UseNamed("audit", noop)
+	items.HandleFunc("GET /{item}", listOrders)
+
+	orders := NewMux()
+	orders.UseNamed("auth", noop)
+	orders.HandleFunc("GET /{$}", listOrders)
+	orders.Mount("/{id}/items", items)
+
+	root := NewMux()
+	root.UseNamed("logger", noop)
+	root.Handle("GET /users/{id}", userHandler{})
+	root.HandleWith("GET /healthz", userHandler{}, noop)
+	root.Mount("/orders", orders)
+
+	var got []RouteInfo
+	if err := root.Walk(func(rt RouteInfo) error {
+		got = append(got, rt)
+		return nil
+	}); err != nil {
+		t.Fatalf("Walk = %v", err)
+	}
+
+	want := []RouteInfo{
+		{Method: "GET", Pattern: "/users/{id}", Handler: "h3.userHandler", Middleware: []string{"logger"}},
+		{Method: "GET", Pattern: "/healthz", Handler: "h3.userHandler"},
+		{Method: "GET", Pattern: "/orders/{$}", Mount: "/orders", Handler: "github.com/h3go/h3.listOrders", Middleware: []string{"logger", "auth"}},
+		{Method: "GET", Pattern: "/orders/{id}/items/{item}", Mount: "/orders/{id}/items", Handler: "github.com/h3go/h3.listOrders", Middleware: []string{"logger", "auth", "audit"}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("visited %d routes, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("route %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// Clone 的副本遍历相同的路由
+	var n int
+	root.Clone().Walk(func(RouteInfo) error { n++; return nil })
+	if n != len(want) {
+		t.Errorf("clone visited %d routes, want %d", n, len(want))
+	}
+
+	// 返回错误时停止遍历
+	errStop := errors.New("stop")
+	visits := 0
+	err := root.Walk(func(RouteInfo) error {
+		visits++
+		return errStop
+	})
+	if !errors.Is(err, errStop) || visits != 1 {
+		t.Errorf("Walk = %v after %d visits, want %v after 1", err, visits, errStop)
+	}
+}
+
+func TestMuxHandleHost(t *testing.T) {
+	text := func(s string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(s))
+		})
+	}
+
+	mux := NewMux()
+	mux.Handle("GET /", text("default"))
+	mux.HandleHost("api.example.com", "GET /", text("api"))
+	mux.HandleHost("docs.example.com", "/guide", text("docs"))
+
+	tests := []struct {
+		host, path string
+		status     int
+		body       string
+	}{
+		{"api.example.com", "/", http.StatusOK, "api"},
+		{"api.example.com:8080", "/users", http.StatusOK, "api"},
+		{"docs.example.com", "/guide", http.StatusOK, "docs"},
+		{"docs.example.com", "/", http.StatusOK, "default"},
+		{"www.example.com", "/", http.StatusOK, "default"},
+		{"www.example.com", "/guide", http.StatusOK, "default"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			req.Host = tt.host
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+		})
+	}
+}
+
+func TestMuxHandleHostPanic(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name, host, pattern string
+	}{
+		{"empty host", "", "/"},
+		{"host with path", "example.com/a", "/"},
+		{"relative pattern", "example.com", "GET users"},
+		{"pattern with host", "example.com", "other.com/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Error("expected panic")
+				}
+			}()
+
+			NewMux().HandleHost(tt.host, tt.pattern, handler)
+		})
+	}
+}
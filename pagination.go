@@ -0,0 +1,200 @@
+package h3
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// PaginationConfig Pagination 中间件的配置
+type PaginationConfig struct {
+	// PageParam 页码的查询参数名，默认为 "page"，页码从 1 开始
+	PageParam string
+
+	// PerPageParam 每页数量的查询参数名，默认为 "per_page"
+	PerPageParam string
+
+	// CursorParam 游标的查询参数名，默认为 "cursor"
+	CursorParam string
+
+	// DefaultPerPage 请求没有指定每页数量时使用的值，默认为 20
+	DefaultPerPage int
+
+	// MaxPerPage 每页数量的上限，超过时被截断为该值，默认为 100
+	MaxPerPage int
+}
+
+// Page 一个请求的分页参数
+//
+// 由 Pagination 中间件解析后放入请求上下文，通过 PageFromRequest 取出。
+// 处理器在写出响应之前调用 SetTotal 或 SetNextCursor，
+// 中间件据此在响应头中生成 Link。
+type Page struct {
+	Number  int    // 页码，从 1 开始
+	PerPage int    // 每页数量，不超过 MaxPerPage
+	Cursor  string // 游标分页时客户端传入的游标，没有时为空
+
+	total      int    // 结果总数，-1 表示未知
+	nextCursor string // 下一页的游标
+}
+
+// Offset 返回当前页第一条记录的偏移量，即 (Number-1)*PerPage
+func (p *Page) Offset() int {
+	return (p.Number - 1) * p.PerPage
+}
+
+// SetTotal 设置结果总数，用于生成 first、prev、next 和 last 链接
+func (p *Page) SetTotal(total int) {
+	p.total = total
+}
+
+// SetNextCursor 设置下一页的游标，用于游标分页时生成 next 链接
+//
+// 为空表示没有下一页。
+func (p *Page) SetNextCursor(cursor string) {
+	p.nextCursor = cursor
+}
+
+// pageKey 请求上下文中 Page 的键
+type pageKey struct{}
+
+// PageFromRequest 返回 Pagination 中间件解析的分页参数
+//
+// 请求没有经过 Pagination 中间件时返回 nil。
+func PageFromRequest(r *http.Request) *Page {
+	p, _ := r.Context().Value(pageKey{}).(*Page)
+	return p
+}
+
+// Pagination 创建解析分页参数并输出 Link 响应头的中间件
+//
+// 中间件从查询参数中读取页码、每页数量和游标，保存在请求上下文中，
+// 处理器通过 PageFromRequest 读取。每页数量超过 MaxPerPage 时被截断，
+// 页码或每页数量不是正整数，或页码大到使 Offset 溢出时，
+// 通过 RenderJSONError 写出 400 Bad Request（invalid_pagination）。
+//
+// 处理器在写出响应之前设置结果总数或下一页的游标后，
+// 中间件在响应提交时添加 RFC 8288 的 Link 头，链接保留请求的其他查询参数:
+//
+//	Link: </items?page=3&per_page=20>; rel="next", </items?page=1&per_page=20>; rel="prev",
+//	      </items?page=1&per_page=20>; rel="first", </items?page=5&per_page=20>; rel="last"
+//
+// 示例:
+//
+//	mux.Handle("GET /items", h3.Pagination()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//		page := h3.PageFromRequest(r)
+//		items, total := store.List(page.Offset(), page.PerPage)
+//		page.SetTotal(total)
+//		json.NewEncoder(w).Encode(items)
+//	})))
+func Pagination(config ...PaginationConfig) func(http.Handler) http.Handler {
+	var cfg PaginationConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.PageParam == "" {
+		cfg.PageParam = "page"
+	}
+	if cfg.PerPageParam == "" {
+		cfg.PerPageParam = "per_page"
+	}
+	if cfg.CursorParam == "" {
+		cfg.CursorParam = "cursor"
+	}
+	if cfg.MaxPerPage <= 0 {
+		cfg.MaxPerPage = 100
+	}
+	if cfg.DefaultPerPage <= 0 {
+		cfg.DefaultPerPage = min(20, cfg.MaxPerPage)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			p := &Page{Number: 1, PerPage: cfg.DefaultPerPage, Cursor: query.Get(cfg.CursorParam), total: -1}
+
+			var problems []string
+			if v := query.Get(cfg.PageParam); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil || n < 1 {
+					problems = append(problems, cfg.PageParam+" must be a positive integer")
+				}
+				p.Number = n
+			}
+			if v := query.Get(cfg.PerPageParam); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil || n < 1 {
+					problems = append(problems, cfg.PerPageParam+" must be a positive integer")
+				}
+				p.PerPage = min(n, cfg.MaxPerPage)
+			}
+			// 偏移量 (Number-1)*PerPage 不能溢出，否则处理器会拿到负数偏移量
+			if len(problems) == 0 && p.Number > math.MaxInt/p.PerPage {
+				problems = append(problems, cfg.PageParam+" is too large")
+			}
+			if len(problems) > 0 {
+				RenderJSONError(w, r, &StatusError{
+					Status:  http.StatusBadRequest,
+					Code:    "invalid_pagination",
+					Message: "invalid pagination parameters: " + strings.Join(problems, "; "),
+				})
+				return
+			}
+
+			rw := NewResponse(w)
+			commit := func() {
+				if v := p.links(&cfg, r); v != "" {
+					rw.Header().Set("Link", v)
+				}
+			}
+			rw.OnBeforeCommit(commit)
+
+			next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), pageKey{}, p)))
+
+			// 处理器没有写出响应时，由服务器在返回后提交
+			if !rw.Committed() && !rw.Hijacked() {
+				commit()
+			}
+		})
+	}
+}
+
+// links 根据处理器设置的总数或游标返回 Link 头的值，没有链接时返回空字符串
+func (p *Page) links(cfg *PaginationConfig, r *http.Request) string {
+	var links []string
+	add := func(rel string, set map[string]string) {
+		u := *r.URL
+		q := u.Query()
+		for k, v := range set {
+			q.Set(k, v)
+		}
+		u.RawQuery = q.Encode()
+		u.Scheme, u.Host = "", ""
+		links = append(links, "<"+u.RequestURI()+`>; rel="`+rel+`"`)
+	}
+	pageLink := func(rel string, n int) {
+		add(rel, map[string]string{
+			cfg.PageParam:    strconv.Itoa(n),
+			cfg.PerPageParam: strconv.Itoa(p.PerPage),
+		})
+	}
+
+	if p.nextCursor != "" {
+		add("next", map[string]string{cfg.CursorParam: p.nextCursor})
+	}
+	if p.total >= 0 {
+		last := max(1, (p.total+p.PerPage-1)/p.PerPage)
+		if p.Number < last {
+			pageLink("next", p.Number+1)
+		}
+		if p.Number > 1 {
+			pageLink("prev", min(p.Number-1, last))
+		}
+		pageLink("first", 1)
+		pageLink("last", last)
+	}
+
+	return strings.Join(links, ", ")
+}
@@ -0,0 +1,85 @@
+package h3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPagination(t *testing.T) {
+	var got Page
+	total, nextCursor := -1, ""
+	h := Pagination(PaginationConfig{MaxPerPage: 50})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := PageFromRequest(r)
+		got = *p
+		if total >= 0 {
+			p.SetTotal(total)
+		}
+		p.SetNextCursor(nextCursor)
+		w.Write([]byte("items"))
+	}))
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+		return rec
+	}
+
+	// 默认值
+	rec := serve("/items")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if got.Number != 1 || got.PerPage != 20 || got.Cursor != "" {
+		t.Errorf("page = %+v, want page 1, 20 per page", got)
+	}
+	if link := rec.Header().Get("Link"); link != "" {
+		t.Errorf("Link without total = %q, want none", link)
+	}
+
+	// per_page 被截断
+	serve("/items?page=3&per_page=500")
+	if got.Number != 3 || got.PerPage != 50 || got.Offset() != 100 {
+		t.Errorf("page = %+v, offset %d, want page 3, 50 per page, offset 100", got, got.Offset())
+	}
+
+	// 无效参数
+	for _, target := range []string{"/items?page=0", "/items?page=abc", "/items?per_page=-5", "/items?page=184467440737095517&per_page=50"} {
+		if rec := serve(target); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", target, rec.Code)
+		}
+	}
+
+	// 处理器设置总数后输出 Link
+	total = 95
+	rec = serve("/items?q=go&page=2&per_page=20")
+	want := `</items?page=3&per_page=20&q=go>; rel="next", ` +
+		`</items?page=1&per_page=20&q=go>; rel="prev", ` +
+		`</items?page=1&per_page=20&q=go>; rel="first", ` +
+		`</items?page=5&per_page=20&q=go>; rel="last"`
+	if link := rec.Header().Get("Link"); link != want {
+		t.Errorf("Link =\n%s\nwant\n%s", link, want)
+	}
+
+	rec = serve("/items?page=5")
+	want = `</items?page=4&per_page=20>; rel="prev", </items?page=1&per_page=20>; rel="first", </items?page=5&per_page=20>; rel="last"`
+	if link := rec.Header().Get("Link"); link != want {
+		t.Errorf("Link on last page =\n%s\nwant\n%s", link, want)
+	}
+
+	// 游标分页
+	total, nextCursor = -1, "abc123"
+	rec = serve("/items?cursor=xyz")
+	if got.Cursor != "xyz" {
+		t.Errorf("Cursor = %q, want xyz", got.Cursor)
+	}
+	if link := rec.Header().Get("Link"); link != `</items?cursor=abc123>; rel="next"` {
+		t.Errorf("cursor Link = %q", link)
+	}
+}
+
+func TestPageFromRequestWithoutMiddleware(t *testing.T) {
+	if PageFromRequest(httptest.NewRequest("GET", "/", nil)) != nil {
+		t.Error("PageFromRequest without middleware should be nil")
+	}
+}
@@ -0,0 +1,75 @@
+package h3
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// StripPrefix 创建移除请求路径前缀的中间件
+//
+// 与标准库的 http.StripPrefix 相比，前缀按路径段匹配："/api" 匹配 "/api" 和 "/api/users"，
+// 但不匹配 "/apix"。移除后的路径总是以 "/" 开头，r.URL.RawPath 会被同步调整。
+// 路径不以 prefix 开头时返回 404。
+//
+// 与 Mount 不同，StripPrefix 不需要子路由，可以与任意处理器组合，
+// 例如放在反向代理之前，去掉后端服务不认识的公共前缀。
+//
+// 示例:
+//
+//	// GET /api/users -> 后端收到 GET /users
+//	mux.Handle("/api/", h3.StripPrefix("/api")(proxy))
+func StripPrefix(prefix string) func(http.Handler) http.Handler {
+	return RewritePrefix(prefix, "/")
+}
+
+// RewritePrefix 创建将请求路径前缀 from 替换为 to 的中间件
+//
+// 前缀按路径段匹配，规则与 StripPrefix 相同；路径不以 from 开头时返回 404。
+//
+// 示例:
+//
+//	// GET /api/users -> 后端收到 GET /internal/v2/users
+//	mux.Handle("/api/", h3.RewritePrefix("/api", "/internal/v2")(proxy))
+func RewritePrefix(from, to string) func(http.Handler) http.Handler {
+	from = strings.TrimSuffix(from, "/")
+	to = strings.TrimSuffix(to, "/")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := rewritePrefix(r.URL.Path, from, to)
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+
+			// 编码路径的前缀不一致时交给 EscapedPath 根据 Path 重新计算
+			rp := ""
+			if r.URL.RawPath != "" {
+				rp, _ = rewritePrefix(r.URL.RawPath, from, to)
+			}
+
+			r2 := new(http.Request)
+			*r2 = *r
+			r2.URL = new(url.URL)
+			*r2.URL = *r.URL
+			r2.URL.Path = p
+			r2.URL.RawPath = rp
+
+			next.ServeHTTP(w, r2)
+		})
+	}
+}
+
+// rewritePrefix 按路径段将 p 的前缀 from 替换为 to
+func rewritePrefix(p, from, to string) (string, bool) {
+	if p != from && !strings.HasPrefix(p, from+"/") {
+		return "", false
+	}
+
+	p = to + p[len(from):]
+	if p == "" {
+		p = "/"
+	}
+	return p, true
+}
@@ -0,0 +1,47 @@
+package h3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRewritePrefix(t *testing.T) {
+	backend := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.EscapedPath()))
+	})
+
+	tests := []struct {
+		name   string
+		mw     func(http.Handler) http.Handler
+		path   string
+		status int
+		body   string
+	}{
+		{"strip", StripPrefix("/api"), "/api/users/42", http.StatusOK, "/users/42"},
+		{"strip exact", StripPrefix("/api/"), "/api", http.StatusOK, "/"},
+		{"strip encoded", StripPrefix("/api"), "/api/files/a%2Fb", http.StatusOK, "/files/a%2Fb"},
+		{"strip segment boundary", StripPrefix("/api"), "/apix/users", http.StatusNotFound, "404 page not found\n"},
+		{"strip no match", StripPrefix("/api"), "/users", http.StatusNotFound, "404 page not found\n"},
+		{"rewrite", RewritePrefix("/api", "/internal/v2/"), "/api/users", http.StatusOK, "/internal/v2/users"},
+		{"rewrite root", RewritePrefix("/", "/static"), "/css/app.css", http.StatusOK, "/static/css/app.css"},
+		{"rewrite no match", RewritePrefix("/api", "/v2"), "/other", http.StatusNotFound, "404 page not found\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.path, nil)
+			rec := httptest.NewRecorder()
+
+			tt.mw(backend).ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+
+			if rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package h3
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+)
+
+// ProblemDetails RFC 7807 定义的 HTTP API 错误详情
+//
+// 序列化为 application/problem+json 格式，空字段被省略。
+// Extensions 中的成员与标准字段并列写出，不能覆盖标准字段。
+type ProblemDetails struct {
+	Type       string         // 标识问题类型的 URI，为空时使用 "about:blank"
+	Title      string         // 问题类型的简短描述，为空时使用状态码的标准文本
+	Status     int            // HTTP 状态码
+	Detail     string         // 针对本次问题的具体说明
+	Instance   string         // 标识本次问题的 URI，通常为请求路径
+	Extensions map[string]any // 扩展成员，例如错误码或字段错误列表
+}
+
+// MarshalJSON 将标准字段和扩展成员写出为一个 JSON 对象
+func (p ProblemDetails) MarshalJSON() ([]byte, error) {
+	m := make(map[string]any, len(p.Extensions)+5)
+	maps.Copy(m, p.Extensions)
+	for _, key := range []string{"type", "title", "status", "detail", "instance"} {
+		delete(m, key)
+	}
+
+	if p.Type != "" {
+		m["type"] = p.Type
+	}
+	if p.Title != "" {
+		m["title"] = p.Title
+	}
+	if p.Status != 0 {
+		m["status"] = p.Status
+	}
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+	return json.Marshal(m)
+}
+
+// WriteProblem 以 application/problem+json 格式写出错误详情
+//
+// status 覆盖 p.Status；p.Type 为空时使用 "about:blank"，
+// p.Title 为空时使用状态码的标准文本。
+// 写入经过 w，外层中间件包装的 Response 可以看到实际写出的状态码和响应体。
+//
+// 示例:
+//
+//	h3.WriteProblem(w, http.StatusConflict, h3.ProblemDetails{
+//		Type:   "https://example.com/problems/duplicate-email",
+//		Detail: "email already registered",
+//	})
+func WriteProblem(w http.ResponseWriter, status int, p ProblemDetails) error {
+	p.Status = status
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(status)
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "application/problem+json")
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	return json.NewEncoder(w).Encode(p)
+}
+
+// RenderProblem 以 RFC 7807 的 problem+json 格式写出错误，可以作为 ErrorRenderer 使用
+//
+// 状态码和说明的来源与 RenderJSONError 相同：
+//   - title: 状态码的标准文本
+//   - detail: 错误消息，与 RenderJSONError 的 message 相同
+//   - instance: 请求路径
+//   - code: 扩展成员，机器可读的错误码
+//   - request_id: 扩展成员，来自 RequestIDFromContext，没有请求 ID 时省略
+//   - errors: 扩展成员，*ValidationError 的字段错误列表
+//
+// 示例:
+//
+//	mux.Use(h3.Recoverer(h3.RenderProblem))
+//	mux.Handle("GET /users/{id}", h3.HandleError(getUser, h3.RenderProblem))
+func RenderProblem(w http.ResponseWriter, r *http.Request, err error) {
+	env := newErrorEnvelope(r, err)
+
+	p := ProblemDetails{
+		Detail:     env.Message,
+		Instance:   r.URL.Path,
+		Extensions: map[string]any{"code": env.Code},
+	}
+	if env.RequestID != "" {
+		p.Extensions["request_id"] = env.RequestID
+	}
+	if len(env.Errors) > 0 {
+		p.Extensions["errors"] = env.Errors
+	}
+	WriteProblem(w, env.Status, p)
+}
@@ -0,0 +1,140 @@
+package h3
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestWriteProblem(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rw := NewResponse(rec)
+
+	err := WriteProblem(rw, http.StatusConflict, ProblemDetails{
+		Type:       "https://example.com/problems/duplicate-email",
+		Detail:     "email already registered",
+		Instance:   "/users",
+		Status:     http.StatusTeapot,
+		Extensions: map[string]any{"email": "a@example.com", "status": "ignored"},
+	})
+	if err != nil {
+		t.Fatalf("WriteProblem = %v", err)
+	}
+
+	if rec.Code != http.StatusConflict || rw.Status() != http.StatusConflict {
+		t.Errorf("status = %d (Response %d), want %d", rec.Code, rw.Status(), http.StatusConflict)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("Content-Type = %q, want %q", ct, "application/problem+json")
+	}
+	if rw.Size() != int64(rec.Body.Len()) {
+		t.Errorf("Size = %d, want %d", rw.Size(), rec.Body.Len())
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	want := map[string]any{
+		"type":     "https://example.com/problems/duplicate-email",
+		"title":    "Conflict",
+		"status":   float64(http.StatusConflict),
+		"detail":   "email already registered",
+		"instance": "/users",
+		"email":    "a@example.com",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("body = %v, want %v", got, want)
+	}
+}
+
+func TestWriteProblemDefaults(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteProblem(rec, http.StatusNotFound, ProblemDetails{})
+
+	var got map[string]any
+	json.Unmarshal(rec.Body.Bytes(), &got)
+	want := map[string]any{"type": "about:blank", "title": "Not Found", "status": float64(404)}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("body = %v, want %v", got, want)
+	}
+}
+
+func TestRenderProblem(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want map[string]any
+	}{
+		{
+			"status error",
+			&StatusError{Status: http.StatusNotFound, Message: "user not found"},
+			map[string]any{
+				"type": "about:blank", "title": "Not Found", "status": float64(404),
+				"detail": "user not found", "instance": "/users/42", "code": "not_found",
+			},
+		},
+		{
+			"validation error",
+			&ValidationError{Errors: []FieldError{{Field: "/email", Message: "required"}}},
+			map[string]any{
+				"type": "about:blank", "title": "Unprocessable Entity", "status": float64(422),
+				"detail": "Unprocessable Entity", "instance": "/users/42", "code": "unprocessable_entity",
+				"errors": []any{map[string]any{"field": "/email", "message": "required"}},
+			},
+		},
+		{
+			"internal error",
+			errors.New("database password is hunter2"),
+			map[string]any{
+				"type": "about:blank", "title": "Internal Server Error", "status": float64(500),
+				"detail": "Internal Server Error", "instance": "/users/42", "code": "internal_server_error",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := HandleError(func(w http.ResponseWriter, r *http.Request) error {
+				return tt.err
+			}, RenderProblem)
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, httptest.NewRequest("GET", "/users/42", nil))
+
+			if ct := rec.Header().Get("Content-Type"); ct != "application/problem+json" {
+				t.Errorf("Content-Type = %q", ct)
+			}
+			if want := int(tt.want["status"].(float64)); rec.Code != want {
+				t.Errorf("status = %d, want %d", rec.Code, want)
+			}
+			var got map[string]any
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("body = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderProblemRequestID(t *testing.T) {
+	h := RequestID()(HandleError(func(w http.ResponseWriter, r *http.Request) error {
+		return &StatusError{Status: http.StatusForbidden}
+	}, RenderProblem))
+
+	req := httptest.NewRequest("GET", "/admin", nil)
+	req.Header.Set("X-Request-ID", "req-123")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var got map[string]any
+	json.Unmarshal(rec.Body.Bytes(), &got)
+	if got["request_id"] != "req-123" {
+		t.Errorf("request_id = %v, want %q", got["request_id"], "req-123")
+	}
+}
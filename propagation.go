@@ -0,0 +1,100 @@
+package h3
+
+import (
+	"context"
+	"net/http"
+)
+
+// DefaultPropagationHeaders Propagation 未指定请求头时默认传播的请求头
+//
+// 包括 W3C Trace Context 定义的 traceparent 和 tracestate，以及常用的关联 ID。
+var DefaultPropagationHeaders = []string{"traceparent", "tracestate", "X-Correlation-ID"}
+
+// propagationKey 请求上下文中传播请求头的键
+type propagationKey struct{}
+
+// Propagation 创建传播追踪/关联请求头的中间件
+//
+// 中间件把入站请求中指定的请求头保存到请求上下文中，
+// 之后通过 PropagatedClient 创建的客户端发出的请求会自动带上这些请求头，
+// 从而在服务之间统一传递追踪和关联信息。
+//
+// 如果没有指定请求头，使用 DefaultPropagationHeaders。
+//
+// 示例:
+//
+//	mux.Use(h3.Propagation())
+//
+//	mux.HandleFunc("GET /orders", func(w http.ResponseWriter, r *http.Request) {
+//		client := h3.PropagatedClient(r.Context())
+//		resp, err := client.Get("http://inventory/items")
+//		// ...
+//	})
+func Propagation(headers ...string) func(http.Handler) http.Handler {
+	if len(headers) == 0 {
+		headers = DefaultPropagationHeaders
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			propagated := make(http.Header)
+			for _, name := range headers {
+				if values := r.Header.Values(name); len(values) > 0 {
+					propagated[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+				}
+			}
+
+			ctx := context.WithValue(r.Context(), propagationKey{}, propagated)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// PropagatedHeaders 返回 Propagation 中间件保存在上下文中的请求头
+//
+// 返回的是副本，修改它不会影响后续的传播。
+// 如果上下文中没有保存请求头，返回空的 http.Header。
+func PropagatedHeaders(ctx context.Context) http.Header {
+	h, _ := ctx.Value(propagationKey{}).(http.Header)
+	if h == nil {
+		return make(http.Header)
+	}
+	return h.Clone()
+}
+
+// PropagatedClient 返回一个会自动带上传播请求头的 HTTP 客户端
+//
+// 客户端基于 http.DefaultTransport，发出请求时设置 Propagation 中间件
+// 保存在 ctx 中的请求头。请求中已经显式设置的同名请求头不会被覆盖。
+func PropagatedClient(ctx context.Context) *http.Client {
+	return &http.Client{
+		Transport: &propagatingTransport{
+			base:   http.DefaultTransport,
+			header: PropagatedHeaders(ctx),
+		},
+	}
+}
+
+// propagatingTransport 为出站请求添加传播请求头的 http.RoundTripper
+type propagatingTransport struct {
+	base   http.RoundTripper
+	header http.Header
+}
+
+// RoundTrip 实现 http.RoundTripper 接口
+//
+// 按照 RoundTripper 的约定不修改原请求，而是在副本上设置请求头。
+func (t *propagatingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if len(t.header) == 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	for name, values := range t.header {
+		if _, ok := req.Header[name]; !ok {
+			req.Header[name] = append([]string(nil), values...)
+		}
+	}
+
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,108 @@
+package h3
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPropagation(t *testing.T) {
+	// 下游服务回显收到的请求头
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Got-Traceparent", r.Header.Get("traceparent"))
+		w.Header().Set("X-Got-Correlation-ID", r.Header.Get("X-Correlation-ID"))
+		w.Header().Set("X-Got-Tenant", r.Header.Get("X-Tenant"))
+	}))
+	defer downstream.Close()
+
+	mux := NewMux()
+	mux.Use(Propagation())
+
+	var captured http.Header
+	mux.HandleFunc("GET /orders", func(w http.ResponseWriter, r *http.Request) {
+		captured = PropagatedHeaders(r.Context())
+
+		resp, err := PropagatedClient(r.Context()).Get(downstream.URL)
+		if err != nil {
+			t.Errorf("outbound request failed: %v", err)
+			return
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, resp.Body)
+
+		for _, name := range []string{"X-Got-Traceparent", "X-Got-Correlation-ID", "X-Got-Tenant"} {
+			w.Header().Set(name, resp.Header.Get(name))
+		}
+	})
+
+	traceparent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	req := httptest.NewRequest("GET", "/orders", nil)
+	req.Header.Set("traceparent", traceparent)
+	req.Header.Set("X-Correlation-ID", "corr-123")
+	req.Header.Set("X-Tenant", "acme")
+	rec := httptest.NewRecorder()
+
+	mux.ServeHTTP(rec, req)
+
+	if got := captured.Get("traceparent"); got != traceparent {
+		t.Errorf("captured traceparent = %q, want %q", got, traceparent)
+	}
+
+	if got := captured.Get("X-Tenant"); got != "" {
+		t.Errorf("captured X-Tenant = %q, want empty", got)
+	}
+
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"X-Got-Traceparent", traceparent},
+		{"X-Got-Correlation-ID", "corr-123"},
+		{"X-Got-Tenant", ""},
+	}
+
+	for _, tt := range tests {
+		if got := rec.Header().Get(tt.header); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestPropagationCustomHeaders(t *testing.T) {
+	var got string
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("X-Request-ID")
+	}))
+	defer downstream.Close()
+
+	mux := NewMux()
+	mux.Use(Propagation("X-Request-ID"))
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		// 显式设置的请求头不会被覆盖
+		out, _ := http.NewRequestWithContext(r.Context(), "GET", downstream.URL, nil)
+		out.Header.Set("X-Request-ID", "explicit")
+		resp, err := PropagatedClient(r.Context()).Do(out)
+		if err != nil {
+			t.Errorf("outbound request failed: %v", err)
+			return
+		}
+		resp.Body.Close()
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Request-ID", "inbound")
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got != "explicit" {
+		t.Errorf("X-Request-ID = %q, want %q", got, "explicit")
+	}
+}
+
+func TestPropagatedHeadersEmpty(t *testing.T) {
+	if h := PropagatedHeaders(context.Background()); len(h) != 0 {
+		t.Errorf("PropagatedHeaders = %v, want empty", h)
+	}
+}
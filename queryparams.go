@@ -0,0 +1,193 @@
+package h3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParamType 查询参数的类型
+type ParamType int
+
+const (
+	// ParamString 字符串，原样保存
+	ParamString ParamType = iota
+
+	// ParamInt 十进制整数，通过 QueryInt 读取
+	ParamInt
+
+	// ParamBool 布尔值，接受 strconv.ParseBool 支持的写法，通过 QueryBool 读取
+	ParamBool
+
+	// ParamTime 时间，按 ParamSpec.Layout 解析，通过 QueryTime 读取
+	ParamTime
+)
+
+// String 返回类型的名称
+func (t ParamType) String() string {
+	switch t {
+	case ParamString:
+		return "string"
+	case ParamInt:
+		return "integer"
+	case ParamBool:
+		return "boolean"
+	case ParamTime:
+		return "time"
+	}
+	return "ParamType(" + strconv.Itoa(int(t)) + ")"
+}
+
+// ParamSpec 声明一个查询参数
+type ParamSpec struct {
+	// Name 查询参数的名称，不能为空
+	Name string
+
+	// Type 参数的类型，默认为 ParamString
+	Type ParamType
+
+	// Required 为 true 时请求必须携带非空的该参数
+	Required bool
+
+	// Default 参数缺失时使用的值，按 Type 解析；为空时没有默认值
+	Default string
+
+	// Layout ParamTime 参数的时间格式，默认为 time.RFC3339
+	Layout string
+}
+
+// queryParamsKey 请求上下文中已解析查询参数的键
+type queryParamsKey struct{}
+
+// QueryParams 创建解析并校验查询参数的中间件
+//
+// 每个 ParamSpec 声明一个参数的名称、类型、是否必需以及默认值。
+// 中间件对每个请求只解析一次，转换后的值保存在请求上下文中，
+// 处理器通过 QueryString、QueryInt、QueryBool 和 QueryTime 读取。
+// 参数出现多次时只使用第一个值，值为空字符串视为缺失。
+//
+// 必需的参数缺失或值无法转换为声明的类型时，通过 RenderJSONError 写出
+// 400 Bad Request（invalid_query_parameter），消息列出所有出错的参数，不再调用处理器。
+//
+// 如果 spec 的名称为空、重复，或 Default 无法按类型解析，会触发 panic。
+//
+// 示例:
+//
+//	mux.Handle("GET /events", h3.QueryParams(
+//		h3.ParamSpec{Name: "page", Type: h3.ParamInt, Default: "1"},
+//		h3.ParamSpec{Name: "since", Type: h3.ParamTime, Required: true},
+//		h3.ParamSpec{Name: "verbose", Type: h3.ParamBool},
+//	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//		page := h3.QueryInt(r, "page")
+//		since := h3.QueryTime(r, "since")
+//		// ...
+//	})))
+func QueryParams(specs ...ParamSpec) func(http.Handler) http.Handler {
+	defaults := make(map[string]any, len(specs))
+	for i, spec := range specs {
+		if spec.Name == "" {
+			panic(errors.New("h3: invalid query parameter name"))
+		}
+		for _, prev := range specs[:i] {
+			if prev.Name == spec.Name {
+				panic(fmt.Errorf("h3: duplicate query parameter %q", spec.Name))
+			}
+		}
+		if spec.Default != "" {
+			v, err := spec.parse(spec.Default)
+			if err != nil {
+				panic(fmt.Errorf("h3: invalid default for query parameter %q: %v", spec.Name, err))
+			}
+			defaults[spec.Name] = v
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			query := r.URL.Query()
+			values := make(map[string]any, len(specs))
+
+			var problems []string
+			for _, spec := range specs {
+				raw := query.Get(spec.Name)
+				if raw == "" {
+					if v, ok := defaults[spec.Name]; ok {
+						values[spec.Name] = v
+					} else if spec.Required {
+						problems = append(problems, fmt.Sprintf("%s is required", spec.Name))
+					}
+					continue
+				}
+
+				v, err := spec.parse(raw)
+				if err != nil {
+					problems = append(problems, fmt.Sprintf("%s must be a valid %s", spec.Name, spec.Type))
+					continue
+				}
+				values[spec.Name] = v
+			}
+
+			if len(problems) > 0 {
+				RenderJSONError(w, r, &StatusError{
+					Status:  http.StatusBadRequest,
+					Code:    "invalid_query_parameter",
+					Message: "invalid query parameters: " + strings.Join(problems, "; "),
+				})
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), queryParamsKey{}, values)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// parse 将原始值转换为参数声明的类型
+func (s ParamSpec) parse(raw string) (any, error) {
+	switch s.Type {
+	case ParamString:
+		return raw, nil
+	case ParamInt:
+		return strconv.Atoi(raw)
+	case ParamBool:
+		return strconv.ParseBool(raw)
+	case ParamTime:
+		layout := s.Layout
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		return time.Parse(layout, raw)
+	}
+	return nil, fmt.Errorf("unknown parameter type %v", s.Type)
+}
+
+// queryValue 返回 QueryParams 解析的参数值，不存在或类型不符时返回零值
+func queryValue[T any](r *http.Request, name string) T {
+	values, _ := r.Context().Value(queryParamsKey{}).(map[string]any)
+	v, _ := values[name].(T)
+	return v
+}
+
+// QueryString 返回 QueryParams 解析的 ParamString 参数，参数缺失时返回空字符串
+func QueryString(r *http.Request, name string) string {
+	return queryValue[string](r, name)
+}
+
+// QueryInt 返回 QueryParams 解析的 ParamInt 参数，参数缺失时返回 0
+func QueryInt(r *http.Request, name string) int {
+	return queryValue[int](r, name)
+}
+
+// QueryBool 返回 QueryParams 解析的 ParamBool 参数，参数缺失时返回 false
+func QueryBool(r *http.Request, name string) bool {
+	return queryValue[bool](r, name)
+}
+
+// QueryTime 返回 QueryParams 解析的 ParamTime 参数，参数缺失时返回零值
+func QueryTime(r *http.Request, name string) time.Time {
+	return queryValue[time.Time](r, name)
+}
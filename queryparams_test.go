@@ -0,0 +1,107 @@
+package h3
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryParams(t *testing.T) {
+	var got string
+	h := QueryParams(
+		ParamSpec{Name: "q", Required: true},
+		ParamSpec{Name: "page", Type: ParamInt, Default: "1"},
+		ParamSpec{Name: "verbose", Type: ParamBool},
+		ParamSpec{Name: "since", Type: ParamTime},
+		ParamSpec{Name: "day", Type: ParamTime, Layout: time.DateOnly, Default: "2026-01-01"},
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = fmt.Sprintf("%s|%d|%t|%s|%s", QueryString(r, "q"), QueryInt(r, "page"), QueryBool(r, "verbose"),
+			QueryTime(r, "since").Format(time.RFC3339), QueryTime(r, "day").Format(time.DateOnly))
+	}))
+
+	serve := func(target string) *httptest.ResponseRecorder {
+		got = ""
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+		return rec
+	}
+
+	tests := []struct {
+		target, want string
+	}{
+		{"/?q=go&page=3&verbose=true&since=2026-03-14T10:00:00Z&day=2026-02-01", "go|3|true|2026-03-14T10:00:00Z|2026-02-01"},
+		// 默认值和缺失的可选参数
+		{"/?q=go", "go|1|false|0001-01-01T00:00:00Z|2026-01-01"},
+		{"/?q=go&page=", "go|1|false|0001-01-01T00:00:00Z|2026-01-01"},
+		{"/?q=go&verbose=1&page=7&page=9", "go|7|true|0001-01-01T00:00:00Z|2026-01-01"},
+	}
+	for _, tt := range tests {
+		rec := serve(tt.target)
+		if rec.Code != http.StatusOK {
+			t.Errorf("%s: status = %d, want 200", tt.target, rec.Code)
+		}
+		if got != tt.want {
+			t.Errorf("%s: values = %q, want %q", tt.target, got, tt.want)
+		}
+	}
+
+	errTests := []struct {
+		target string
+		msgs   []string
+	}{
+		{"/?page=2", []string{"q is required"}},
+		{"/?q=go&page=abc", []string{"page must be a valid integer"}},
+		{"/?q=go&verbose=maybe&since=yesterday", []string{"verbose must be a valid boolean", "since must be a valid time"}},
+	}
+	for _, tt := range errTests {
+		rec := serve(tt.target)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", tt.target, rec.Code)
+		}
+		if got != "" {
+			t.Errorf("%s: handler should not be called", tt.target)
+		}
+
+		var env errorEnvelope
+		if err := json.Unmarshal(rec.Body.Bytes(), &env); err != nil {
+			t.Fatalf("%s: invalid JSON body: %v", tt.target, err)
+		}
+		if env.Code != "invalid_query_parameter" {
+			t.Errorf("%s: code = %q, want invalid_query_parameter", tt.target, env.Code)
+		}
+		for _, msg := range tt.msgs {
+			if !strings.Contains(env.Message, msg) {
+				t.Errorf("%s: message = %q, want it to contain %q", tt.target, env.Message, msg)
+			}
+		}
+	}
+}
+
+func TestQueryParamsInvalidSpec(t *testing.T) {
+	specs := [][]ParamSpec{
+		{{Name: ""}},
+		{{Name: "a"}, {Name: "a"}},
+		{{Name: "page", Type: ParamInt, Default: "first"}},
+	}
+	for _, s := range specs {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("QueryParams(%+v) should panic", s)
+				}
+			}()
+			QueryParams(s...)
+		}()
+	}
+}
+
+func TestQueryAccessorsWithoutMiddleware(t *testing.T) {
+	r := httptest.NewRequest("GET", "/?page=2", nil)
+	if QueryInt(r, "page") != 0 || QueryString(r, "page") != "" || QueryBool(r, "page") || !QueryTime(r, "page").IsZero() {
+		t.Error("accessors without QueryParams should return zero values")
+	}
+}
@@ -0,0 +1,118 @@
+package h3
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// IDGenerator 为没有携带请求 ID 的请求生成新的 ID
+type IDGenerator interface {
+	Generate(r *http.Request) string
+}
+
+// IDExtractor 从入站请求中提取已有的请求 ID
+//
+// 返回的 bool 表示是否找到了可用的 ID，为 false 时由 IDGenerator 生成。
+type IDExtractor interface {
+	Extract(r *http.Request) (string, bool)
+}
+
+// IDGeneratorFunc 将普通函数适配为 IDGenerator
+type IDGeneratorFunc func(r *http.Request) string
+
+// Generate 调用 f(r)
+func (f IDGeneratorFunc) Generate(r *http.Request) string {
+	return f(r)
+}
+
+// IDExtractorFunc 将普通函数适配为 IDExtractor
+type IDExtractorFunc func(r *http.Request) (string, bool)
+
+// Extract 调用 f(r)
+func (f IDExtractorFunc) Extract(r *http.Request) (string, bool) {
+	return f(r)
+}
+
+// RequestIDConfig 请求 ID 中间件的配置
+type RequestIDConfig struct {
+	// Header 读取和写回请求 ID 的头名称，默认为 "X-Request-ID"
+	Header string
+
+	// Generator 生成新的请求 ID，默认生成 16 字节随机数的十六进制字符串
+	Generator IDGenerator
+
+	// Extractor 提取入站请求中的 ID，默认读取请求头 Header 的非空值
+	Extractor IDExtractor
+}
+
+// requestIDKey 请求上下文中请求 ID 的键
+type requestIDKey struct{}
+
+// RequestID 创建为每个请求分配请求 ID 的中间件
+//
+// 中间件先通过 Extractor 提取入站请求中已有的 ID，提取不到时通过 Generator 生成。
+// 得到的 ID 保存在请求上下文中，可以通过 RequestIDFromContext 取出，
+// 同时写入响应头和传给下一个处理器的请求头 Header，
+// 因此放在 Propagation 之前时，生成的 ID 同样可以传播到下游服务。
+//
+// 示例:
+//
+//	mux.Use(h3.RequestID(h3.RequestIDConfig{
+//		Generator: h3.IDGeneratorFunc(func(r *http.Request) string {
+//			return ulid.Make().String()
+//		}),
+//	}))
+//	mux.Use(h3.Propagation("X-Request-ID"))
+func RequestID(config ...RequestIDConfig) func(http.Handler) http.Handler {
+	var cfg RequestIDConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Header == "" {
+		cfg.Header = "X-Request-ID"
+	}
+	if cfg.Generator == nil {
+		cfg.Generator = IDGeneratorFunc(newRequestID)
+	}
+	if cfg.Extractor == nil {
+		header := cfg.Header
+		cfg.Extractor = IDExtractorFunc(func(r *http.Request) (string, bool) {
+			id := r.Header.Get(header)
+			return id, id != ""
+		})
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id, ok := cfg.Extractor.Extract(r)
+			if !ok {
+				id = cfg.Generator.Generate(r)
+			}
+
+			w.Header().Set(cfg.Header, id)
+
+			r = r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+			r.Header = r.Header.Clone()
+			r.Header.Set(cfg.Header, id)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequestIDFromContext 返回 RequestID 中间件保存在上下文中的请求 ID
+//
+// 如果上下文中没有请求 ID，返回空字符串。
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// newRequestID 生成 16 字节随机数的十六进制字符串
+func newRequestID(*http.Request) string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
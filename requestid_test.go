@@ -0,0 +1,93 @@
+package h3
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	mux := NewMux()
+	mux.Use(RequestID())
+	mux.HandleFunc("GET /test", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(RequestIDFromContext(r.Context())))
+	})
+
+	// 沿用入站请求的 ID
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("X-Request-ID", "abc")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	if rec.Body.String() != "abc" || rec.Header().Get("X-Request-ID") != "abc" {
+		t.Errorf("id = %q (header %q), want %q", rec.Body.String(), rec.Header().Get("X-Request-ID"), "abc")
+	}
+
+	// 生成新的 ID
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/test", nil))
+
+	if id := rec.Body.String(); len(id) != 32 || rec.Header().Get("X-Request-ID") != id {
+		t.Errorf("generated id = %q (header %q)", id, rec.Header().Get("X-Request-ID"))
+	}
+}
+
+func TestRequestIDCustom(t *testing.T) {
+	n := 0
+	generator := IDGeneratorFunc(func(r *http.Request) string {
+		n++
+		return fmt.Sprintf("gen-%d", n)
+	})
+
+	// 从 traceparent 中提取 trace-id
+	extractor := IDExtractorFunc(func(r *http.Request) (string, bool) {
+		parts := strings.Split(r.Header.Get("traceparent"), "-")
+		if len(parts) != 4 {
+			return "", false
+		}
+		return parts[1], true
+	})
+
+	mux := NewMux()
+	mux.Use(RequestID(RequestIDConfig{
+		Header:    "X-Trace-ID",
+		Generator: generator,
+		Extractor: extractor,
+	}))
+	mux.Use(Propagation("X-Trace-ID"))
+	mux.HandleFunc("GET /test", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s", RequestIDFromContext(r.Context()), PropagatedHeaders(r.Context()).Get("X-Trace-ID"))
+	})
+
+	tests := []struct {
+		name        string
+		traceparent string
+		want        string
+	}{
+		{"extracted", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{"generated", "", "gen-1"},
+		{"generated again", "invalid", "gen-2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/test", nil)
+			if tt.traceparent != "" {
+				req.Header.Set("traceparent", tt.traceparent)
+			}
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if want := tt.want + " " + tt.want; rec.Body.String() != want {
+				t.Errorf("body = %q, want %q", rec.Body.String(), want)
+			}
+
+			if got := rec.Header().Get("X-Trace-ID"); got != tt.want {
+				t.Errorf("X-Trace-ID = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
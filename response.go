@@ -4,9 +4,12 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"net/http"
+	"os"
+	"strings"
 )
 
 var (
@@ -14,6 +17,7 @@ var (
 	_ http.Flusher        = (*response)(nil)
 	_ http.Hijacker       = (*response)(nil)
 	_ http.Pusher         = (*response)(nil)
+	_ io.ReaderFrom       = (*response)(nil)
 	_ Response            = (*response)(nil)
 )
 
@@ -32,7 +36,9 @@ var (
 // 状态捕获方法:
 //   - Status() int: 获取 HTTP 响应状态码
 //   - Committed() bool: 检查响应是否已提交
+//   - Hijacked() bool: 检查连接是否已被接管
 //   - Size() int64: 获取已写入的字节数
+//   - Capabilities() ResponseCaps: 获取底层 ResponseWriter 实际支持的可选接口
 //   - Unwrap() http.ResponseWriter: 获取被包装的原始 ResponseWriter
 //   - Push(target, opts) error: HTTP/2 服务器推送
 //
@@ -84,6 +90,46 @@ type Response interface {
 	// 一旦响应提交，就无法再修改状态码。
 	Committed() bool
 
+	// SetStatus 设置将要写出的状态码，但不提交响应
+	//
+	// 之后的 Write 或不带参数提交响应的操作会使用该状态码；
+	// 显式调用 WriteHeader 时仍以 WriteHeader 的参数为准。
+	// 响应已提交时调用会被忽略并记录警告。
+	SetStatus(code int)
+
+	// SetTrailer 设置在响应体之后发送的 HTTP trailer
+	//
+	// 可以在写出响应体之前或之后调用，值通过 http.TrailerPrefix 写入 Header，
+	// 不需要预先声明 Trailer 头。只有分块传输或 HTTP/2 的响应才能携带 trailer。
+	SetTrailer(key, value string)
+
+	// Hijacked 返回底层连接是否已被接管
+	//
+	// 通过 Hijack 成功接管连接（例如 WebSocket 升级）之后返回 true，
+	// 此时不能再通过 ResponseWriter 写入响应。
+	Hijacked() bool
+
+	// Abort 立即断开连接，不发送任何响应
+	//
+	// 底层连接支持接管时，接管并关闭连接，之后的写入返回 http.ErrHijacked；
+	// 否则（例如 HTTP/2）以 http.ErrAbortHandler panic，由服务器中止请求。
+	Abort()
+
+	// OnBeforeCommit 注册在响应提交之前调用的函数
+	//
+	// 响应第一次提交时（WriteHeader、Write、Flush、ReadFrom 等任何方式），
+	// 在发送响应头之前按注册顺序调用这些函数，每个函数只调用一次，
+	// 函数中可以修改响应头。响应已提交或连接已被接管时不再调用。
+	// 中间件可以借此在处理器写出响应时添加响应头，而不必包装每个写入方法。
+	OnBeforeCommit(fn func())
+
+	// Capabilities 返回底层 ResponseWriter 支持的可选接口
+	//
+	// 结果在第一次调用时探测并缓存。http.Flusher、http.Hijacker、http.Pusher
+	// 总是由 Response 实现，处理器可以据此选择流式或缓冲等策略，
+	// 而不必在调用之后处理错误或 panic。
+	Capabilities() ResponseCaps
+
 	// Unwrap 返回原始的 http.ResponseWriter
 	//
 	// ResponseController 可以用来访问原始的 http.ResponseWriter。
@@ -92,24 +138,59 @@ type Response interface {
 }
 
 type response struct {
-	http.ResponseWriter       // 嵌入原始 ResponseWriter
-	status              int   // 捕获的 HTTP 状态码
-	size                int64 // 已写入的字节数
-	committed           bool  // 响应是否已开始写入
+	http.ResponseWriter               // 嵌入原始 ResponseWriter
+	status              int           // 捕获的 HTTP 状态码
+	size                int64         // 已写入的字节数
+	committed           bool          // 响应是否已开始写入
+	hijacked            bool          // 连接是否已被接管
+	deferEmpty          bool          // 零长度的 Write 是否不提交响应
+	caps                *ResponseCaps // 探测到的底层能力，第一次调用 Capabilities 时设置
+	hooks               []func()      // 提交之前调用的函数
+}
+
+// ResponseCaps 底层 ResponseWriter 支持的可选接口
+type ResponseCaps struct {
+	Flusher  bool // 支持 Flush，可以用于 SSE 和流式响应
+	Hijacker bool // 支持 Hijack，可以接管连接（通常只有 HTTP/1.x）
+	Pusher   bool // 支持 Push，即 HTTP/2 服务器推送
+}
+
+// ResponseConfig Response 包装器的配置
+type ResponseConfig struct {
+	// DeferEmptyWrite 为 true 时，零长度的 Write 不会提交响应，
+	// 响应头在写出第一个字节（或显式调用 WriteHeader、Flush）之前仍然可以修改。
+	// 默认情况下与 http.ResponseWriter 一致，任何 Write 都会提交响应。
+	DeferEmptyWrite bool
 }
 
 // NewResponse 创建 Response 包装器
 //
-// 如果传入的 ResponseWriter 已经是 Response 类型，直接返回避免重复包装。
+// 如果传入的 ResponseWriter 已经是 Response 类型，直接返回避免重复包装；
+// 此时 config 中开启的选项会同时应用到已有的包装器上。
 // 默认状态码设置为 200 OK，这是 HTTP 协议的默认状态。
-func NewResponse(w http.ResponseWriter) Response {
+//
+// 示例:
+//
+//	rw := h3.NewResponse(w, h3.ResponseConfig{DeferEmptyWrite: true})
+//	rw.Write(nil)                       // 不提交响应
+//	rw.Header().Set("X-Late", "value") // 仍然生效
+func NewResponse(w http.ResponseWriter, config ...ResponseConfig) Response {
+	var cfg ResponseConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
 	if r, ok := w.(Response); ok {
+		if rr, ok := r.(*response); ok && cfg.DeferEmptyWrite {
+			rr.deferEmpty = true
+		}
 		return r
 	}
 
 	return &response{
 		ResponseWriter: w,
 		status:         http.StatusOK,
+		deferEmpty:     cfg.DeferEmptyWrite,
 	}
 }
 
@@ -128,37 +209,258 @@ func (r *response) Committed() bool {
 	return r.committed
 }
 
+// Hijacked 返回底层连接是否已被接管
+func (r *response) Hijacked() bool {
+	return r.hijacked
+}
+
+// ServeFile 写出 name 指定的文件
+//
+// 文件通过 http.ServeContent 写出：Content-Type 根据扩展名或文件内容检测，
+// 设置 Last-Modified 和由文件大小与修改时间生成的弱 ETag（已设置 ETag 时保留），
+// 支持 If-None-Match、If-Modified-Since 等条件请求和 Range 请求。
+// 底层连接支持时，响应体通过 ReadFrom 使用 sendfile 直接从文件发送。
+//
+// 错误响应通过 Options.ErrorResponseWriter 写出，未配置时使用标准库的纯文本响应:
+//   - 400 Bad Request: name 包含 ".." 路径段，防止由请求参数拼接的路径逃出目标目录
+//   - 404 Not Found: 文件不存在、无法访问或是目录
+//
+// 示例:
+//
+//	mux.HandleFunc("GET /downloads/{file}", func(w http.ResponseWriter, r *http.Request) {
+//		h3.ServeFile(w, r, filepath.Join("downloads", r.PathValue("file")))
+//	})
+func ServeFile(w http.ResponseWriter, req *http.Request, name string) {
+	if containsDotDot(name) {
+		writeError(w, req, http.StatusBadRequest)
+		return
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		writeError(w, req, http.StatusNotFound)
+		return
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil || fi.IsDir() {
+		writeError(w, req, http.StatusNotFound)
+		return
+	}
+
+	if w.Header().Get("ETag") == "" {
+		w.Header().Set("ETag", fmt.Sprintf(`W/"%x-%x"`, fi.Size(), fi.ModTime().UnixNano()))
+	}
+	http.ServeContent(w, req, fi.Name(), fi.ModTime(), f)
+}
+
+// writeError 通过 Options.ErrorResponseWriter 写出错误响应，未配置时使用 http.Error
+func writeError(w http.ResponseWriter, req *http.Request, status int) {
+	if ew, ok := req.Context().Value(errorWriterKey{}).(func(http.ResponseWriter, *http.Request, int)); ok {
+		ew(w, req, status)
+		return
+	}
+	http.Error(w, http.StatusText(status), status)
+}
+
+// containsDotDot 判断路径是否包含 ".." 路径段
+func containsDotDot(name string) bool {
+	if !strings.Contains(name, "..") {
+		return false
+	}
+	for _, seg := range strings.FieldsFunc(name, func(c rune) bool { return c == '/' || c == '\\' }) {
+		if seg == ".." {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadFrom 实现 io.ReaderFrom 接口，将 src 的内容写入响应体
+//
+// 底层 ResponseWriter 实现了 io.ReaderFrom 时（例如 http.Server 的响应），
+// 直接交给它处理，从而在 src 是文件、连接是 TCP 时使用 sendfile 等零拷贝路径。
+// 与 Write 一样，尚未提交时先提交响应，并累计写入的字节数。
+func (r *response) ReadFrom(src io.Reader) (n int64, err error) {
+	if r.hijacked {
+		return 0, http.ErrHijacked
+	}
+	if !r.committed {
+		r.WriteHeader(r.status)
+	}
+
+	if rf, ok := r.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(src)
+	} else {
+		n, err = io.Copy(writerOnly{r.ResponseWriter}, src)
+	}
+	r.size += n
+	return n, err
+}
+
+// writerOnly 隐藏 io.ReaderFrom 等其他方法，避免 io.Copy 回到 ReadFrom
+type writerOnly struct {
+	io.Writer
+}
+
+// Capabilities 返回底层 ResponseWriter 支持的可选接口
+//
+// Flusher 和 Hijacker 与 http.ResponseController 一样沿 Unwrap 链查找，
+// Pusher 只检查被包装的 ResponseWriter 本身，与 Push 的行为一致。
+//
+// 示例:
+//
+//	rw := h3.NewResponse(w)
+//	if !rw.Capabilities().Flusher {
+//		renderBuffered(rw, items)
+//		return
+//	}
+//	streamItems(rw, items)
+func (r *response) Capabilities() ResponseCaps {
+	if r.caps == nil {
+		r.caps = &ResponseCaps{
+			Flusher: unwrapsTo(r.ResponseWriter, func(w http.ResponseWriter) bool {
+				switch w.(type) {
+				case http.Flusher, interface{ FlushError() error }:
+					return true
+				}
+				return false
+			}),
+			Hijacker: unwrapsTo(r.ResponseWriter, func(w http.ResponseWriter) bool {
+				_, ok := w.(http.Hijacker)
+				return ok
+			}),
+		}
+
+		// Push 直接断言被包装的 ResponseWriter，嵌套的 Response 再继续向内断言
+		w := r.ResponseWriter
+		for {
+			inner, ok := w.(*response)
+			if !ok {
+				break
+			}
+			w = inner.ResponseWriter
+		}
+		_, r.caps.Pusher = w.(http.Pusher)
+	}
+	return *r.caps
+}
+
+// unwrapsTo 沿 Unwrap 链查找满足 match 的 ResponseWriter
+//
+// *response 总是实现所有可选接口，因此跳过它而检查其包装的 ResponseWriter。
+func unwrapsTo(w http.ResponseWriter, match func(http.ResponseWriter) bool) bool {
+	for w != nil {
+		if _, ok := w.(*response); !ok && match(w) {
+			return true
+		}
+		u, ok := w.(interface{ Unwrap() http.ResponseWriter })
+		if !ok {
+			return false
+		}
+		w = u.Unwrap()
+	}
+	return false
+}
+
 // Unwrap 返回原始的 http.ResponseWriter
 func (r *response) Unwrap() http.ResponseWriter {
 	return r.ResponseWriter
 }
 
+// SetStatus 设置将要写出的状态码，但不提交响应
+//
+// 中间件可以在处理器写出响应之前修改状态码，例如将探针请求的状态统一为 200。
+// 响应已提交时调用会被忽略并记录警告。
+//
+// 示例:
+//
+//	rw := h3.NewResponse(w)
+//	rw.SetStatus(http.StatusAccepted)
+//	rw.Write(body) // 以 202 写出
+func (r *response) SetStatus(code int) {
+	if r.committed {
+		log.Printf("attempt to set status after response committed")
+		return
+	}
+	r.status = code
+}
+
+// SetTrailer 设置在响应体之后发送的 HTTP trailer
+//
+// 也可以使用标准库的方式：提交响应之前通过 Header().Set("Trailer", key) 声明，
+// 写完响应体后再通过 Header().Set(key, value) 设置值。
+// SetTrailer 使用 http.TrailerPrefix，无需预先声明，适合 gRPC 等
+// 在流结束时才知道状态的协议。
+//
+// 示例:
+//
+//	rw := h3.NewResponse(w)
+//	for msg := range stream {
+//		rw.Write(msg)
+//		rw.Flush()
+//	}
+//	rw.SetTrailer("Grpc-Status", "0")
+func (r *response) SetTrailer(key, value string) {
+	r.Header().Set(http.TrailerPrefix+key, value)
+}
+
 // WriteHeader 拦截并记录状态码
 //
 // 此方法会记录状态码并标记响应为已提交。
 // 如果响应已经提交（WriteHeader 或 Write 已被调用），
 // 再次调用此方法会被忽略并记录错误日志。
+// 连接已被接管（Hijack 或 Abort）之后的调用会被静默忽略。
 //
 // 注意:
 //   - HTTP 协议规定响应头只能发送一次
 //   - 多次调用 WriteHeader 是编程错误，应该避免
 //   - 标准库的行为是忽略后续调用（但可能记录警告）
 func (r *response) WriteHeader(code int) {
+	if r.hijacked {
+		return
+	}
 	if r.committed {
 		// 响应已提交，无法修改状态码，只能记录错误
 		log.Printf("attempt to write header after response committed")
 		return
 	}
 
+	hooks := r.hooks
+	r.hooks = nil
+	for _, fn := range hooks {
+		fn()
+	}
+
 	r.status = code
 	r.committed = true
 	r.ResponseWriter.WriteHeader(code)
 }
 
+// OnBeforeCommit 注册在响应提交之前调用的函数
+//
+// 示例:
+//
+//	rw := h3.NewResponse(w)
+//	start := time.Now()
+//	rw.OnBeforeCommit(func() {
+//		rw.Header().Set("X-Elapsed", time.Since(start).String())
+//	})
+//	next.ServeHTTP(rw, r)
+func (r *response) OnBeforeCommit(fn func()) {
+	if r.committed || r.hijacked {
+		return
+	}
+	r.hooks = append(r.hooks, fn)
+}
+
 // Write 实现 io.Writer 接口，写入响应体数据
 //
 // 如果在调用 Write 之前没有调用 WriteHeader，
 // 会自动调用 WriteHeader(200) 发送响应头。
+// 开启 ResponseConfig.DeferEmptyWrite 时，零长度的 Write 不做任何事情。
+// 连接已被接管（Hijack 或 Abort）之后返回 http.ErrHijacked。
 //
 // 此方法会:
 //   - 自动提交响应（如果尚未提交）
@@ -169,6 +471,13 @@ func (r *response) WriteHeader(code int) {
 //   - n: 成功写入的字节数
 //   - err: 写入过程中的错误（如果有）
 func (r *response) Write(p []byte) (size int, err error) {
+	if r.hijacked {
+		return 0, http.ErrHijacked
+	}
+	if len(p) == 0 && r.deferEmpty && !r.committed {
+		return 0, nil
+	}
+
 	if !r.committed {
 		// 默认状态码为 200 OK（如果处理器不显式调用 WriteHeader）
 		if r.status == 0 {
@@ -194,13 +503,46 @@ func (r *response) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	// 但是一些旧库不知道 `http.NewResponseController` 的存在，会尝试直接劫持
 	// `hj, ok := resp.(http.Hijacker)` <-- 如果 Response 不直接实现 Hijack 方法就会失败
 	// 所以为此我们需要实现 http.Hijacker 接口
-	return http.NewResponseController(r.ResponseWriter).Hijack()
+	conn, rw, err := http.NewResponseController(r.ResponseWriter).Hijack()
+	if err == nil {
+		r.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// Abort 立即断开连接，不发送任何响应
+//
+// 用于滥用防护等场景：对可疑请求不返回任何内容，避免泄露信息。
+// HTTP/1.x 连接会被接管并直接关闭，已经缓冲但尚未发送的数据被丢弃，
+// 响应被标记为已接管，之后的 Write 返回 http.ErrHijacked。
+// 底层连接不支持接管时（例如 HTTP/2 或 httptest.ResponseRecorder），
+// 以 http.ErrAbortHandler panic：服务器会中止该请求（HTTP/2 发送 RST_STREAM）
+// 且不记录调用栈，Recoverer 同样会重新抛出它。
+//
+// 示例:
+//
+//	if blocked(r) {
+//		h3.NewResponse(w).Abort()
+//		return
+//	}
+func (r *response) Abort() {
+	conn, _, err := r.Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	_ = conn.Close()
 }
 
 // Flush 实现 http.Flusher 接口，允许 HTTP 处理器将缓冲数据刷新到客户端
 //
+// 如果响应尚未提交，会先以当前状态码提交响应头，
+// 之后声明的 trailer 仍然会在响应体之后发送。
+//
 // 参见 [http.Flusher](https://golang.org/pkg/net/http/#Flusher)
 func (r *response) Flush() {
+	if !r.committed {
+		r.WriteHeader(r.status)
+	}
 	err := http.NewResponseController(r.ResponseWriter).Flush()
 	if err != nil && errors.Is(err, http.ErrNotSupported) {
 		panic(fmt.Errorf("h3: response writer %T does not support flushing (http.Flusher interface)", r.ResponseWriter))
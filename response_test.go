@@ -1,10 +1,18 @@
 package h3
 
 import (
+	"bufio"
 	"bytes"
+	"io"
+	"log"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewResponse(t *testing.T) {
@@ -303,6 +311,56 @@ func TestResponseEmptyWrite(t *testing.T) {
 	}
 }
 
+func TestResponseDeferEmptyWrite(t *testing.T) {
+	w := httptest.NewRecorder()
+	rw := NewResponse(w, ResponseConfig{DeferEmptyWrite: true})
+
+	for _, p := range [][]byte{nil, {}} {
+		if n, err := rw.Write(p); n != 0 || err != nil {
+			t.Fatalf("Write(%v) = %d, %v, want 0, nil", p, n, err)
+		}
+	}
+
+	if rw.Committed() {
+		t.Error("zero-length Write should not commit the response")
+	}
+
+	// 响应头仍然可以修改
+	rw.Header().Set("X-Late", "value")
+	rw.SetStatus(http.StatusAccepted)
+
+	rw.Write([]byte("data"))
+
+	if !rw.Committed() {
+		t.Error("non-empty Write should commit the response")
+	}
+	if w.Code != http.StatusAccepted {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusAccepted)
+	}
+	if w.Header().Get("X-Late") != "value" {
+		t.Error("header set after the empty write should be sent")
+	}
+
+	// 提交之后的零长度 Write 照常传递
+	if n, err := rw.Write(nil); n != 0 || err != nil {
+		t.Errorf("Write after commit = %d, %v, want 0, nil", n, err)
+	}
+}
+
+func TestResponseDeferEmptyWriteExisting(t *testing.T) {
+	rw := NewResponse(httptest.NewRecorder())
+
+	// 已有包装器上开启选项
+	if NewResponse(rw, ResponseConfig{DeferEmptyWrite: true}) != rw {
+		t.Fatal("NewResponse should return the existing wrapper")
+	}
+
+	rw.Write(nil)
+	if rw.Committed() {
+		t.Error("option should apply to the existing wrapper")
+	}
+}
+
 func TestResponseStatusBeforeAndAfterWrite(t *testing.T) {
 	w := httptest.NewRecorder()
 	rw := NewResponse(w)
@@ -392,6 +450,61 @@ func TestResponseHijack(t *testing.T) {
 		if buf != nil {
 			t.Error("buf should be nil when Hijack is not supported")
 		}
+
+		if rw.Hijacked() {
+			t.Error("Hijacked should be false when Hijack fails")
+		}
+	})
+}
+
+func TestResponseAbort(t *testing.T) {
+	t.Run("hijack closes connection", func(t *testing.T) {
+		writeErr := make(chan error, 1)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := NewResponse(w)
+			rw.Header().Set("X-Secret", "1")
+			rw.Abort()
+			if !rw.Hijacked() {
+				t.Error("Hijacked should be true after Abort")
+			}
+			_, err := rw.Write([]byte("late"))
+			writeErr <- err
+		}))
+		defer srv.Close()
+
+		conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+		if _, err := io.WriteString(conn, "GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"); err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(conn)
+		if err != nil {
+			t.Fatalf("read = %v, want EOF", err)
+		}
+		if len(data) != 0 {
+			t.Errorf("received %q, want nothing", data)
+		}
+
+		if err := <-writeErr; err != http.ErrHijacked {
+			t.Errorf("Write after Abort = %v, want %v", err, http.ErrHijacked)
+		}
+	})
+
+	t.Run("without hijacker support", func(t *testing.T) {
+		rw := NewResponse(httptest.NewRecorder())
+
+		defer func() {
+			if v := recover(); v != http.ErrAbortHandler {
+				t.Errorf("recovered %v, want http.ErrAbortHandler", v)
+			}
+		}()
+		rw.Abort()
+		t.Error("Abort should not return")
 	})
 }
 
@@ -468,3 +581,367 @@ func (w *mockPusherWriter) Push(target string, opts *http.PushOptions) error {
 	w.pushed[target] = opts
 	return nil
 }
+
+func TestResponseServeContent(t *testing.T) {
+	content := "0123456789abcdefghij"
+	modtime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		status int
+		body   string
+		size   int64
+	}{
+		{"full", nil, http.StatusOK, content, 20},
+		{"range", map[string]string{"Range": "bytes=5-9"}, http.StatusPartialContent, "56789", 5},
+		{"suffix range", map[string]string{"Range": "bytes=-3"}, http.StatusPartialContent, "hij", 3},
+		{"if-range mismatch", map[string]string{"Range": "bytes=5-9", "If-Range": "Sun, 31 Dec 2023 00:00:00 GMT"}, http.StatusOK, content, 20},
+		{"not modified", map[string]string{"If-Modified-Since": "Mon, 01 Jan 2024 00:00:00 GMT"}, http.StatusNotModified, "", 0},
+		{"invalid range", map[string]string{"Range": "bytes=100-200"}, http.StatusRequestedRangeNotSatisfiable, "", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/download", nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			rw := NewResponse(rec)
+
+			http.ServeContent(rw, req, "data.txt", modtime, strings.NewReader(content))
+
+			if rec.Code != tt.status || rw.Status() != tt.status {
+				t.Errorf("status = %d (Response %d), want %d", rec.Code, rw.Status(), tt.status)
+			}
+
+			if tt.body != "" && rec.Body.String() != tt.body {
+				t.Errorf("body = %q, want %q", rec.Body.String(), tt.body)
+			}
+
+			if tt.size >= 0 && rw.Size() != tt.size {
+				t.Errorf("Size = %d, want %d", rw.Size(), tt.size)
+			}
+
+			if tt.status == http.StatusOK && rec.Header().Get("Accept-Ranges") != "bytes" {
+				t.Errorf("Accept-Ranges = %q, want %q", rec.Header().Get("Accept-Ranges"), "bytes")
+			}
+		})
+	}
+}
+
+func TestResponseSetStatus(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rw := NewResponse(rec)
+
+	rw.SetStatus(http.StatusAccepted)
+
+	if rw.Committed() {
+		t.Error("SetStatus should not commit the response")
+	}
+	if rw.Status() != http.StatusAccepted {
+		t.Errorf("Status = %d, want %d", rw.Status(), http.StatusAccepted)
+	}
+
+	rw.Write([]byte("queued"))
+
+	if rec.Code != http.StatusAccepted {
+		t.Errorf("written status = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+
+	// 提交之后的调用被忽略
+	defer log.SetOutput(log.Writer())
+	log.SetOutput(io.Discard)
+
+	rw.SetStatus(http.StatusInternalServerError)
+
+	if rw.Status() != http.StatusAccepted {
+		t.Errorf("Status after commit = %d, want %d", rw.Status(), http.StatusAccepted)
+	}
+}
+
+func TestResponseSetStatusMiddleware(t *testing.T) {
+	mux := NewMux()
+	mux.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.(Response).SetStatus(http.StatusCreated)
+			next.ServeHTTP(w, r)
+		})
+	})
+	mux.HandleFunc("POST /items", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("created"))
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("POST", "/items", nil))
+
+	if rec.Code != http.StatusCreated || rec.Body.String() != "created" {
+		t.Errorf("response = %d %q, want %d %q", rec.Code, rec.Body.String(), http.StatusCreated, "created")
+	}
+}
+
+func TestResponseTrailers(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := NewResponse(w)
+
+		// 标准库方式：提交之前声明
+		rw.Header().Set("Trailer", "X-Checksum")
+		rw.SetStatus(http.StatusAccepted)
+
+		rw.Write([]byte("chunk1"))
+		rw.Flush()
+		rw.Write([]byte("chunk2"))
+		rw.Flush()
+
+		rw.Header().Set("X-Checksum", "abc123")
+		rw.SetTrailer("Grpc-Status", "0")
+	}))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusAccepted)
+	}
+	if resp.Header.Get("Grpc-Status") != "" || resp.Header.Get("X-Checksum") != "" {
+		t.Error("trailers should not be sent as headers")
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "chunk1chunk2" {
+		t.Errorf("body = %q, want %q", body, "chunk1chunk2")
+	}
+
+	if got := resp.Trailer.Get("X-Checksum"); got != "abc123" {
+		t.Errorf("X-Checksum trailer = %q, want %q", got, "abc123")
+	}
+	if got := resp.Trailer.Get("Grpc-Status"); got != "0" {
+		t.Errorf("Grpc-Status trailer = %q, want %q", got, "0")
+	}
+}
+
+func TestResponseFlushCommits(t *testing.T) {
+	w := httptest.NewRecorder()
+	rw := NewResponse(w)
+
+	rw.SetStatus(http.StatusCreated)
+	rw.Flush()
+
+	if !rw.Committed() {
+		t.Error("Flush should commit the response")
+	}
+	if w.Code != http.StatusCreated {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusCreated)
+	}
+}
+
+// plainWriter 只实现 http.ResponseWriter 的测试写入器
+type plainWriter struct{ header http.Header }
+
+func (w *plainWriter) Header() http.Header         { return w.header }
+func (w *plainWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w *plainWriter) WriteHeader(int)             {}
+
+// pushWriter 额外实现 http.Pusher 的测试写入器
+type pushWriter struct{ plainWriter }
+
+func (w *pushWriter) Push(string, *http.PushOptions) error { return nil }
+
+// hijackWriter 额外实现 http.Hijacker 的测试写入器
+type hijackWriter struct{ plainWriter }
+
+func (w *hijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) { return nil, nil, nil }
+
+// unwrapWriter 通过 Unwrap 暴露内层写入器的包装器
+type unwrapWriter struct{ http.ResponseWriter }
+
+func (w *unwrapWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+func TestResponseCapabilities(t *testing.T) {
+	tests := []struct {
+		name string
+		w    http.ResponseWriter
+		want ResponseCaps
+	}{
+		{"plain", &plainWriter{header: http.Header{}}, ResponseCaps{}},
+		{"recorder", httptest.NewRecorder(), ResponseCaps{Flusher: true}},
+		{"pusher", &pushWriter{plainWriter{header: http.Header{}}}, ResponseCaps{Pusher: true}},
+		{"hijacker", &hijackWriter{plainWriter{header: http.Header{}}}, ResponseCaps{Hijacker: true}},
+		{"unwrap", &unwrapWriter{&hijackWriter{plainWriter{header: http.Header{}}}}, ResponseCaps{Hijacker: true}},
+		{"nested response", &unwrapWriter{NewResponse(httptest.NewRecorder())}, ResponseCaps{Flusher: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := NewResponse(tt.w)
+			if got := rw.Capabilities(); got != tt.want {
+				t.Errorf("Capabilities = %+v, want %+v", got, tt.want)
+			}
+			// 第二次调用返回缓存的结果
+			if got := rw.Capabilities(); got != tt.want {
+				t.Errorf("cached Capabilities = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResponseCapabilitiesServer(t *testing.T) {
+	var caps ResponseCaps
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		caps = NewResponse(w).Capabilities()
+	}))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if want := (ResponseCaps{Flusher: true, Hijacker: true}); caps != want {
+		t.Errorf("HTTP/1.1 Capabilities = %+v, want %+v", caps, want)
+	}
+}
+
+func TestServeFile(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "hello.txt")
+	if err := os.WriteFile(name, []byte("hello, world"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	modtime := time.Date(2026, 3, 14, 10, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(name, modtime, modtime); err != nil {
+		t.Fatal(err)
+	}
+
+	serve := func(req *http.Request, name string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ServeFile(NewResponse(rec), req, name)
+		return rec
+	}
+
+	rec := serve(httptest.NewRequest("GET", "/hello.txt", nil), name)
+	if rec.Code != http.StatusOK || rec.Body.String() != "hello, world" {
+		t.Fatalf("GET = %d %q, want 200 file content", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/plain; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if lm := rec.Header().Get("Last-Modified"); lm != modtime.Format(http.TimeFormat) {
+		t.Errorf("Last-Modified = %q, want %q", lm, modtime.Format(http.TimeFormat))
+	}
+	etag := rec.Header().Get("ETag")
+	if !strings.HasPrefix(etag, `W/"`) {
+		t.Fatalf("ETag = %q, want weak ETag", etag)
+	}
+
+	// 条件请求
+	req := httptest.NewRequest("GET", "/hello.txt", nil)
+	req.Header.Set("If-None-Match", etag)
+	if rec := serve(req, name); rec.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match = %d, want 304", rec.Code)
+	}
+	req = httptest.NewRequest("GET", "/hello.txt", nil)
+	req.Header.Set("If-Modified-Since", modtime.Format(http.TimeFormat))
+	if rec := serve(req, name); rec.Code != http.StatusNotModified {
+		t.Errorf("If-Modified-Since = %d, want 304", rec.Code)
+	}
+
+	// Range 请求
+	req = httptest.NewRequest("GET", "/hello.txt", nil)
+	req.Header.Set("Range", "bytes=7-11")
+	rec = serve(req, name)
+	if rec.Code != http.StatusPartialContent || rec.Body.String() != "world" {
+		t.Errorf("Range = %d %q, want 206 %q", rec.Code, rec.Body.String(), "world")
+	}
+	if cr := rec.Header().Get("Content-Range"); cr != "bytes 7-11/12" {
+		t.Errorf("Content-Range = %q", cr)
+	}
+
+	// 文件不存在、目录和路径遍历
+	if rec := serve(httptest.NewRequest("GET", "/", nil), filepath.Join(dir, "missing.txt")); rec.Code != http.StatusNotFound {
+		t.Errorf("missing file = %d, want 404", rec.Code)
+	}
+	if rec := serve(httptest.NewRequest("GET", "/", nil), dir); rec.Code != http.StatusNotFound {
+		t.Errorf("directory = %d, want 404", rec.Code)
+	}
+	if rec := serve(httptest.NewRequest("GET", "/", nil), dir+"/../"+filepath.Base(dir)+"/hello.txt"); rec.Code != http.StatusBadRequest {
+		t.Errorf("traversal = %d, want 400", rec.Code)
+	}
+
+	// 通过 Options.ErrorResponseWriter 写出错误
+	app := New(NewMux(), Options{
+		ErrorResponseWriter: func(w http.ResponseWriter, r *http.Request, status int) {
+			w.WriteHeader(status)
+			w.Write([]byte(`{"error":"custom"}`))
+		},
+	})
+	app.HandleFunc("GET /missing", func(w http.ResponseWriter, r *http.Request) {
+		ServeFile(w, r, filepath.Join(dir, "missing.txt"))
+	})
+	rec = httptest.NewRecorder()
+	app.ServeHTTP(rec, httptest.NewRequest("GET", "/missing", nil))
+	if rec.Code != http.StatusNotFound || rec.Body.String() != `{"error":"custom"}` {
+		t.Errorf("missing file via app = %d %q, want custom 404", rec.Code, rec.Body.String())
+	}
+}
+
+func TestResponseReadFrom(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rw := NewResponse(rec)
+	rw.SetStatus(http.StatusCreated)
+
+	n, err := io.Copy(rw, strings.NewReader("streamed"))
+	if err != nil || n != 8 {
+		t.Fatalf("io.Copy = %d, %v", n, err)
+	}
+	if rec.Code != http.StatusCreated || rec.Body.String() != "streamed" {
+		t.Errorf("response = %d %q, want 201 streamed", rec.Code, rec.Body.String())
+	}
+	if rw.Size() != 8 || !rw.Committed() {
+		t.Errorf("Size = %d, Committed = %t", rw.Size(), rw.Committed())
+	}
+}
+
+func TestResponseOnBeforeCommit(t *testing.T) {
+	commits := map[string]func(Response){
+		"WriteHeader": func(rw Response) { rw.WriteHeader(http.StatusAccepted) },
+		"Write":       func(rw Response) { rw.Write([]byte("ok")) },
+		"Flush":       func(rw Response) { rw.Flush() },
+		"ReadFrom":    func(rw Response) { rw.(io.ReaderFrom).ReadFrom(strings.NewReader("ok")) },
+		"Problem":     func(rw Response) { WriteProblem(rw, http.StatusConflict, ProblemDetails{}) },
+	}
+
+	for name, commit := range commits {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			rw := NewResponse(rec)
+
+			var order []string
+			rw.OnBeforeCommit(func() {
+				order = append(order, "first")
+				rw.Header().Set("X-Hook", "set")
+			})
+			rw.OnBeforeCommit(func() { order = append(order, "second") })
+
+			commit(rw)
+			rw.Write([]byte("more"))
+
+			if got := strings.Join(order, ","); got != "first,second" {
+				t.Errorf("hooks ran %q, want %q", got, "first,second")
+			}
+			if got := rec.Header().Get("X-Hook"); got != "set" {
+				t.Errorf("X-Hook = %q, want header set before commit", got)
+			}
+
+			// 提交之后注册的函数不会被调用
+			rw.OnBeforeCommit(func() { t.Error("hook registered after commit should not run") })
+			rw.Write(nil)
+		})
+	}
+}
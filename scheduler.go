@@ -0,0 +1,330 @@
+package h3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SchedulerConfig NewScheduler 的配置
+type SchedulerConfig struct {
+	// AllowOverlap 如果为 true，任务的上一次执行尚未结束时到达的触发也会启动新的执行。
+	// 默认跳过这些触发，同一个任务在任意时刻最多只有一次执行。
+	AllowOverlap bool
+}
+
+// Scheduler 按计划周期性执行后台任务的 Servlet
+//
+// 通过 AddJob 添加任务后注册到应用，Start 时为每个任务启动调度 goroutine，
+// Stop 时取消所有任务的上下文并等待正在执行的任务返回。
+// 任务中的 panic 会被恢复并通过 log 包记录，不会影响其他任务和之后的调度。
+//
+// 示例:
+//
+//	sched := h3.NewScheduler()
+//	sched.AddJob("@every 30s", refreshCache)
+//	sched.AddJob("0 3 * * *", cleanupSessions) // 每天 03:00
+//	app.AddServlet(sched)
+type Scheduler struct {
+	cfg SchedulerConfig
+
+	mu     sync.Mutex
+	jobs   []*scheduledJob    // 已添加的任务
+	cancel context.CancelFunc // 取消所有任务，未启动时为 nil
+	wg     sync.WaitGroup     // 跟踪调度和执行 goroutine
+}
+
+// scheduledJob 调度器中的一个任务
+type scheduledJob struct {
+	sched   schedule
+	fn      func(ctx context.Context)
+	running atomic.Bool // 是否有正在进行的执行
+}
+
+// NewScheduler 创建任务调度器
+//
+// 参数:
+//   - config: 可选的调度器配置
+//
+// 返回:
+//   - *Scheduler: 调度器，实现了 Servlet 接口
+func NewScheduler(config ...SchedulerConfig) *Scheduler {
+	var cfg SchedulerConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	return &Scheduler{cfg: cfg}
+}
+
+// AddJob 添加按计划执行的任务
+//
+// schedule 支持以下格式:
+//   - 时间间隔: "30s"、"@every 5m" 等 time.ParseDuration 能够解析的正值，
+//     从 Start 开始每隔该时间触发一次
+//   - cron 表达式: "分 时 日 月 周" 五个字段，支持 *、列表 "1,15"、
+//     范围 "1-5" 和步长 "*/10"，周日为 0 或 7；日和周都受限时满足其一即可
+//   - 预定义计划: "@hourly"、"@daily"（"@midnight"）、"@weekly"、"@monthly"、"@yearly"（"@annually"）
+//
+// cron 表达式按本地时区计算。fn 收到的上下文在 Stop 时被取消，
+// 长时间运行的任务应该及时响应取消。
+// 必须在 Start 之前调用。
+//
+// 返回:
+//   - error: 计划格式无效或调度器已经启动时返回错误
+func (s *Scheduler) AddJob(schedule string, fn func(ctx context.Context)) error {
+	sched, err := parseSchedule(schedule)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		return errors.New("h3: cannot add job after scheduler Start")
+	}
+	s.jobs = append(s.jobs, &scheduledJob{sched: sched, fn: fn})
+	return nil
+}
+
+// Start 为每个任务启动调度 goroutine
+//
+// 任务的上下文继承 ctx 中的值，但不受 ctx 取消的影响，只在 Stop 时取消。
+func (s *Scheduler) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		return errors.New("h3: scheduler already started")
+	}
+
+	jctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	s.cancel = cancel
+	for _, job := range s.jobs {
+		s.wg.Go(func() {
+			s.run(jctx, job)
+		})
+	}
+	return nil
+}
+
+// Stop 取消所有任务并等待正在执行的任务返回
+//
+// 可以安全地多次调用；停止后可以再次调用 Start。
+func (s *Scheduler) Stop() error {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.cancel = nil
+	s.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	s.wg.Wait()
+	return nil
+}
+
+// run 按计划触发任务，直到 ctx 被取消
+func (s *Scheduler) run(ctx context.Context, job *scheduledJob) {
+	now := time.Now()
+	for {
+		next := job.sched.next(now)
+		if next.IsZero() {
+			return
+		}
+
+		t := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+		// 从实际触发时间计算下一次，避免调度延迟后连续补发
+		now = time.Now()
+
+		if !job.running.CompareAndSwap(false, true) && !s.cfg.AllowOverlap {
+			continue
+		}
+		s.wg.Go(func() {
+			defer job.running.Store(false)
+			defer func() {
+				if v := recover(); v != nil {
+					log.Printf("h3: scheduled job panic: %v\n%s", v, debug.Stack())
+				}
+			}()
+			job.fn(ctx)
+		})
+	}
+}
+
+// schedule 任务的触发计划
+type schedule interface {
+	// next 返回 t 之后的下一次触发时间，不再触发时返回零值
+	next(t time.Time) time.Time
+}
+
+// intervalSchedule 固定间隔的计划
+type intervalSchedule time.Duration
+
+func (d intervalSchedule) next(t time.Time) time.Time {
+	return t.Add(time.Duration(d))
+}
+
+// cronSchedule cron 表达式描述的计划，每个字段为允许取值的位集合
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	domStar, dowStar              bool // 日、周字段是否为 *
+}
+
+// cronMaxYears next 向后查找的最大年数，用于识别永远不会触发的表达式（例如 2 月 30 日）
+const cronMaxYears = 5
+
+func (c *cronSchedule) next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.Year() + cronMaxYears
+
+	for t.Year() <= limit {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches 判断 t 的日期是否满足日和周字段
+//
+// 与标准 cron 一致，两个字段都受限时满足其一即可，否则必须同时满足。
+func (c *cronSchedule) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if !c.domStar && !c.dowStar {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// cronDescriptors 预定义计划对应的 cron 表达式
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// parseSchedule 解析 AddJob 的计划字符串
+func parseSchedule(spec string) (schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if expr, ok := cronDescriptors[spec]; ok {
+		spec = expr
+	}
+
+	if d, ok := strings.CutPrefix(spec, "@every "); ok {
+		spec = strings.TrimSpace(d)
+	}
+	if d, err := time.ParseDuration(spec); err == nil {
+		if d <= 0 {
+			return nil, fmt.Errorf("h3: invalid schedule %q: interval must be positive", spec)
+		}
+		return intervalSchedule(d), nil
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("h3: invalid schedule %q: want an interval or 5 cron fields", spec)
+	}
+
+	var c cronSchedule
+	var err error
+	if c.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("h3: invalid schedule %q: minute: %w", spec, err)
+	}
+	if c.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("h3: invalid schedule %q: hour: %w", spec, err)
+	}
+	if c.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("h3: invalid schedule %q: day of month: %w", spec, err)
+	}
+	if c.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("h3: invalid schedule %q: month: %w", spec, err)
+	}
+	if c.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("h3: invalid schedule %q: day of week: %w", spec, err)
+	}
+	// 周日可以写作 0 或 7
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	c.domStar = fields[2] == "*"
+	c.dowStar = fields[4] == "*"
+	return &c, nil
+}
+
+// parseCronField 解析 cron 表达式的一个字段，返回允许取值的位集合
+func parseCronField(field string, lo, hi int) (uint64, error) {
+	var bits uint64
+	for part := range strings.SplitSeq(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepStr)
+			}
+			step = n
+		}
+
+		start, end := lo, hi
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			a, b, _ := strings.Cut(rng, "-")
+			var err1, err2 error
+			start, err1 = strconv.Atoi(a)
+			end, err2 = strconv.Atoi(b)
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("invalid range %q", rng)
+			}
+		default:
+			n, err := strconv.Atoi(rng)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", rng)
+			}
+			start = n
+			if !hasStep {
+				end = n
+			}
+		}
+		if start < lo || end > hi || start > end {
+			return 0, fmt.Errorf("value %q out of range [%d, %d]", part, lo, hi)
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
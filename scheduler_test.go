@@ -0,0 +1,156 @@
+package h3
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSchedulerRunsOnInterval(t *testing.T) {
+	s := NewScheduler()
+	var runs atomic.Int32
+	if err := s.AddJob("@every 20ms", func(ctx context.Context) {
+		runs.Add(1)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(110 * time.Millisecond)
+	if err := s.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := runs.Load(); n < 3 || n > 6 {
+		t.Errorf("runs = %d, want about 5", n)
+	}
+
+	after := runs.Load()
+	time.Sleep(50 * time.Millisecond)
+	if runs.Load() != after {
+		t.Error("job ran after Stop")
+	}
+}
+
+func TestSchedulerStopCancelsJobs(t *testing.T) {
+	s := NewScheduler()
+	started := make(chan struct{})
+	var canceled atomic.Bool
+	s.AddJob("10ms", func(ctx context.Context) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-ctx.Done()
+		canceled.Store(true)
+	})
+
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+
+	done := make(chan struct{})
+	go func() {
+		s.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Stop did not return")
+	}
+	if !canceled.Load() {
+		t.Error("Stop returned before the running job finished")
+	}
+
+	// 再次调用是安全的
+	if err := s.Stop(); err != nil {
+		t.Errorf("second Stop = %v", err)
+	}
+}
+
+func TestSchedulerSkipsOverlap(t *testing.T) {
+	run := func(cfg SchedulerConfig) (runs, maxActive int32) {
+		s := NewScheduler(cfg)
+		var n, active, peak atomic.Int32
+		s.AddJob("10ms", func(ctx context.Context) {
+			n.Add(1)
+			a := active.Add(1)
+			for {
+				p := peak.Load()
+				if a <= p || peak.CompareAndSwap(p, a) {
+					break
+				}
+			}
+			time.Sleep(55 * time.Millisecond)
+			active.Add(-1)
+		})
+		s.Start(context.Background())
+		time.Sleep(125 * time.Millisecond)
+		s.Stop()
+		return n.Load(), peak.Load()
+	}
+
+	runs, peak := run(SchedulerConfig{})
+	if peak != 1 {
+		t.Errorf("max concurrent runs = %d, want 1", peak)
+	}
+	if runs < 1 || runs > 3 {
+		t.Errorf("runs = %d, want 1 to 3", runs)
+	}
+
+	if _, peak := run(SchedulerConfig{AllowOverlap: true}); peak < 2 {
+		t.Errorf("max concurrent runs with AllowOverlap = %d, want at least 2", peak)
+	}
+}
+
+func TestSchedulerAddJobAfterStart(t *testing.T) {
+	s := NewScheduler()
+	s.Start(context.Background())
+	defer s.Stop()
+
+	if err := s.AddJob("1s", func(context.Context) {}); err == nil {
+		t.Error("AddJob after Start should fail")
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	for _, spec := range []string{"", "0s", "-1s", "@every", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := parseSchedule(spec); err == nil {
+			t.Errorf("parseSchedule(%q) should fail", spec)
+		}
+	}
+
+	base := time.Date(2026, time.March, 14, 10, 7, 30, 0, time.UTC) // 星期六
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"30s", base.Add(30 * time.Second)},
+		{"@every 1h", base.Add(time.Hour)},
+		{"* * * * *", time.Date(2026, 3, 14, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 3, 14, 10, 15, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2026, 3, 15, 3, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, 3, 14, 11, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2026, 3, 16, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * 1", time.Date(2026, 3, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		s, err := parseSchedule(tt.spec)
+		if err != nil {
+			t.Errorf("parseSchedule(%q) = %v", tt.spec, err)
+			continue
+		}
+		if got := s.next(base); !got.Equal(tt.want) {
+			t.Errorf("%q next = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package h3
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// FieldError 描述请求体中一个字段的校验错误
+type FieldError struct {
+	Field   string `json:"field"`   // 字段路径，例如 "/user/email"
+	Message string `json:"message"` // 错误描述
+}
+
+// ValidationError 请求体未通过校验的错误，由 RenderJSONError 写出为 422
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Field + ": " + fe.Message
+	}
+	return "h3: validation failed: " + strings.Join(msgs, "; ")
+}
+
+// SchemaValidator 根据 JSON Schema 校验 JSON 文档
+//
+// h3 不依赖任何 JSON Schema 实现，可以通过适配第三方库来提供校验。
+// 文档不符合 schema 时返回字段级错误；error 只用于 schema 本身无效等内部错误。
+type SchemaValidator interface {
+	Validate(schema, document []byte) ([]FieldError, error)
+}
+
+// ValidateConfig 请求体校验中间件的配置
+type ValidateConfig struct {
+	// MaxBodySize 请求体的最大字节数，超出时返回 413。
+	// 如果为零，使用 1MB。
+	MaxBodySize int64
+}
+
+// ValidateSchema 创建使用 JSON Schema 校验请求体的中间件
+//
+// 中间件缓冲整个请求体并交给 validator 校验，通过后将请求体原样交还给处理器。
+// 错误响应通过 RenderJSONError 写出:
+//   - 400 Bad Request: 请求体不是合法的 JSON
+//   - 413 Request Entity Too Large: 请求体超过 MaxBodySize
+//   - 415 Unsupported Media Type: Content-Type 不是 application/json
+//   - 422 Unprocessable Entity: 未通过校验，errors 字段列出每个字段的错误
+//   - 500 Internal Server Error: validator 返回错误
+//
+// 示例:
+//
+//	mux.Handle("POST /users", h3.ValidateSchema(userSchema, validator)(createUser))
+func ValidateSchema(schema []byte, validator SchemaValidator, config ...ValidateConfig) func(http.Handler) http.Handler {
+	var cfg ValidateConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.MaxBodySize <= 0 {
+		cfg.MaxBodySize = 1 << 20
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil || mediaType != "application/json" {
+				RenderJSONError(w, r, &BindError{
+					Status: http.StatusUnsupportedMediaType,
+					Err:    errors.New("h3: content type must be application/json"),
+				})
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, cfg.MaxBodySize))
+			if err != nil {
+				RenderJSONError(w, r, bindError(err))
+				return
+			}
+			if !json.Valid(body) {
+				RenderJSONError(w, r, &BindError{
+					Status: http.StatusBadRequest,
+					Err:    errors.New("h3: invalid JSON body"),
+				})
+				return
+			}
+
+			fieldErrs, err := validator.Validate(schema, body)
+			if err != nil {
+				RenderJSONError(w, r, fmt.Errorf("h3: schema validation: %w", err))
+				return
+			}
+			if len(fieldErrs) > 0 {
+				RenderJSONError(w, r, &ValidationError{Errors: fieldErrs})
+				return
+			}
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			r.ContentLength = int64(len(body))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package h3
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// requiredValidator 只支持 "required" 关键字的测试用校验器
+type requiredValidator struct{}
+
+func (requiredValidator) Validate(schema, document []byte) ([]FieldError, error) {
+	var s struct {
+		Required []string `json:"required"`
+	}
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return nil, err
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(document, &doc); err != nil {
+		return []FieldError{{Field: "/", Message: "must be an object"}}, nil
+	}
+
+	var errs []FieldError
+	for _, name := range s.Required {
+		if _, ok := doc[name]; !ok {
+			errs = append(errs, FieldError{Field: "/" + name, Message: "is required"})
+		}
+	}
+	return errs, nil
+}
+
+func TestValidateSchema(t *testing.T) {
+	schema := []byte(`{"type":"object","required":["name","email"]}`)
+
+	mux := NewMux()
+	mux.Handle("POST /users", ValidateSchema(schema, requiredValidator{}, ValidateConfig{MaxBodySize: 64})(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			w.Write(body)
+		})))
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		status      int
+		errors      []FieldError
+	}{
+		{"valid", "application/json", `{"name":"alice","email":"a@example.com"}`, http.StatusOK, nil},
+		{"invalid", "application/json; charset=utf-8", `{"name":"alice"}`, http.StatusUnprocessableEntity, []FieldError{{Field: "/email", Message: "is required"}}},
+		{"malformed", "application/json", `{"name":`, http.StatusBadRequest, nil},
+		{"too large", "application/json", `{"name":"` + strings.Repeat("x", 64) + `"}`, http.StatusRequestEntityTooLarge, nil},
+		{"not JSON", "text/plain", "name=alice", http.StatusUnsupportedMediaType, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/users", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+
+			mux.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+
+			if tt.status == http.StatusOK {
+				if rec.Body.String() != tt.body {
+					t.Errorf("body = %q, want %q", rec.Body.String(), tt.body)
+				}
+				return
+			}
+
+			var env struct {
+				Status int          `json:"status"`
+				Errors []FieldError `json:"errors"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &env); err != nil {
+				t.Fatalf("invalid JSON %q: %v", rec.Body.String(), err)
+			}
+
+			if env.Status != tt.status {
+				t.Errorf("envelope status = %d, want %d", env.Status, tt.status)
+			}
+
+			if len(env.Errors) != len(tt.errors) {
+				t.Fatalf("errors = %v, want %v", env.Errors, tt.errors)
+			}
+			for i := range tt.errors {
+				if env.Errors[i] != tt.errors[i] {
+					t.Errorf("errors[%d] = %v, want %v", i, env.Errors[i], tt.errors[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package h3
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Principal 已认证的请求主体，例如用户或服务账号
+//
+// 认证中间件验证凭据后通过 WithPrincipal 将主体放入请求上下文，
+// RequireScope 通过 Scopes 读取主体拥有的权限范围。
+// 凭据格式不同（JWT 的 scope 声明、API Key 对应的权限等）时，
+// 由实现决定如何提取权限范围。
+type Principal interface {
+	Scopes() []string
+}
+
+// principalKey 请求上下文中 Principal 的键
+type principalKey struct{}
+
+// WithPrincipal 返回携带已认证主体的上下文
+//
+// 示例:
+//
+//	func auth(next http.Handler) http.Handler {
+//		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//			user, err := verify(r.Header.Get("Authorization"))
+//			if err != nil {
+//				next.ServeHTTP(w, r)
+//				return
+//			}
+//			next.ServeHTTP(w, r.WithContext(h3.WithPrincipal(r.Context(), user)))
+//		})
+//	}
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFromContext 返回上下文中的已认证主体，不存在时返回 nil
+func PrincipalFromContext(ctx context.Context) Principal {
+	p, _ := ctx.Value(principalKey{}).(Principal)
+	return p
+}
+
+// RequireScope 创建要求已认证主体拥有所有指定权限范围的中间件
+//
+// 主体由认证中间件通过 WithPrincipal 放入请求上下文，
+// 权限范围通过 Principal.Scopes 获取，比较区分大小写。
+// 错误响应通过 RenderJSONError 写出:
+//   - 401 Unauthorized: 请求上下文中没有主体
+//   - 403 Forbidden: 主体缺少任何一个要求的权限范围，消息中列出缺少的权限范围
+//
+// 中间件不读取请求体，携带 Expect: 100-continue 的上传被拒绝时，
+// 客户端直接收到 401 或 403，不会发送请求体。
+//
+// 示例:
+//
+//	mux.Use(auth)
+//	mux.Handle("DELETE /users/{id}", h3.RequireScope("users:write", "admin")(deleteUser))
+func RequireScope(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p := PrincipalFromContext(r.Context())
+			if p == nil {
+				RenderJSONError(w, r, &StatusError{Status: http.StatusUnauthorized})
+				return
+			}
+
+			granted := p.Scopes()
+			var missing []string
+			for _, s := range scopes {
+				if !slices.Contains(granted, s) {
+					missing = append(missing, s)
+				}
+			}
+			if len(missing) > 0 {
+				RenderJSONError(w, r, &StatusError{
+					Status:  http.StatusForbidden,
+					Code:    "insufficient_scope",
+					Message: "missing scope: " + strings.Join(missing, " "),
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
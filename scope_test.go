@@ -0,0 +1,90 @@
+package h3
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// scopedUser 测试用的 Principal
+type scopedUser []string
+
+func (u scopedUser) Scopes() []string { return u }
+
+func TestRequireScope(t *testing.T) {
+	h := RequireScope("users:read", "users:write")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	tests := []struct {
+		name      string
+		principal Principal
+		status    int
+		code      string
+		message   string
+	}{
+		{"sufficient", scopedUser{"users:read", "admin", "users:write"}, http.StatusOK, "", ""},
+		{"insufficient", scopedUser{"users:read", "Users:Write"}, http.StatusForbidden, "insufficient_scope", "missing scope: users:write"},
+		{"no scopes", scopedUser(nil), http.StatusForbidden, "insufficient_scope", "missing scope: users:read users:write"},
+		{"no principal", nil, http.StatusUnauthorized, "unauthorized", "Unauthorized"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.principal != nil {
+				req = req.WithContext(WithPrincipal(req.Context(), tt.principal))
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if tt.status == http.StatusOK {
+				return
+			}
+
+			var env errorEnvelope
+			if err := json.NewDecoder(rec.Body).Decode(&env); err != nil {
+				t.Fatalf("decode error body: %v", err)
+			}
+			if env.Code != tt.code || env.Message != tt.message {
+				t.Errorf("error = %q %q, want %q %q", env.Code, env.Message, tt.code, tt.message)
+			}
+		})
+	}
+}
+
+func TestRequireScopeWithChain(t *testing.T) {
+	auth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "Bearer admin" {
+				r = r.WithContext(WithPrincipal(r.Context(), scopedUser{"admin"}))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	mux := NewMux()
+	mux.Use(auth)
+	mux.HandleWith("GET /admin", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		auth, RequireScope("admin"))
+	mux.Handle("GET /reports", Chain(RequireScope("reports"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+	serve := func(path string) int {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer admin")
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := serve("/admin"); code != http.StatusOK {
+		t.Errorf("GET /admin = %d, want %d", code, http.StatusOK)
+	}
+	if code := serve("/reports"); code != http.StatusForbidden {
+		t.Errorf("GET /reports = %d, want %d", code, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,126 @@
+package h3
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Timings 收集一个请求各阶段耗时的记录器
+//
+// 由 ServerTiming 中间件创建并放入请求上下文，通过 Timing 取出。
+// 方法可以被并发调用；nil 记录器上的方法什么也不做，
+// 因此处理器不需要关心是否启用了中间件。
+type Timings struct {
+	mu      sync.Mutex
+	names   []string                 // 按第一次记录的顺序排列的指标名称
+	metrics map[string]time.Duration // 每个指标的累计耗时
+}
+
+// timingsKey 请求上下文中 Timings 的键
+type timingsKey struct{}
+
+// Timing 返回 ServerTiming 中间件放入请求上下文的记录器
+//
+// 请求没有经过 ServerTiming 中间件时返回 nil，在其上调用 Mark 是安全的。
+func Timing(r *http.Request) *Timings {
+	t, _ := r.Context().Value(timingsKey{}).(*Timings)
+	return t
+}
+
+// Mark 记录名为 name 的阶段耗时
+//
+// 同一名称多次记录时耗时累加，例如多次数据库查询合计为一个 "db" 指标。
+// name 应该是合法的 HTTP token（字母、数字和 -、_ 等），不能包含空格、逗号或分号。
+func (t *Timings) Mark(name string, d time.Duration) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.metrics[name]; !ok {
+		t.names = append(t.names, name)
+	}
+	t.metrics[name] += d
+}
+
+// Start 开始计时名为 name 的阶段，返回的函数被调用时记录经过的时间
+//
+// 示例:
+//
+//	defer h3.Timing(r).Start("db")()
+func (t *Timings) Start(name string) func() {
+	start := time.Now()
+	return func() {
+		t.Mark(name, time.Since(start))
+	}
+}
+
+// header 返回 Server-Timing 头的值，没有任何记录时返回空字符串
+func (t *Timings) header() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var b strings.Builder
+	for i, name := range t.names {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		// 以毫秒为单位，保留到微秒
+		ms := math.Round(float64(t.metrics[name])/float64(time.Microsecond)) / 1000
+		b.WriteString(name)
+		b.WriteString(";dur=")
+		b.WriteString(strconv.FormatFloat(ms, 'f', -1, 64))
+	}
+	return b.String()
+}
+
+// ServerTiming 创建输出 Server-Timing 响应头的中间件
+//
+// 中间件为每个请求在上下文中放入一个 Timings 记录器，处理器和内层中间件
+// 通过 Timing(r).Mark 记录认证、数据库、渲染等阶段的耗时。
+// 响应头在响应提交的时刻（第一次 WriteHeader、Write 或 Flush，
+// 或者处理器返回时仍未提交）汇总写入，例如:
+//
+//	Server-Timing: auth;dur=1.2, db;dur=35.07, render;dur=4.5
+//
+// 因此在响应提交之后记录的耗时不会出现在响应头中。没有任何记录时不添加响应头。
+// 浏览器开发者工具会展示这些指标；它们同样对任何客户端可见，
+// 在公开服务中应避免暴露可能泄露内部信息的细粒度耗时。
+//
+// 示例:
+//
+//	mux.Use(h3.ServerTiming())
+//	mux.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {
+//		done := h3.Timing(r).Start("db")
+//		users := loadUsers(r.Context())
+//		done()
+//		h3.Timing(r).Mark("cache", cacheLatency)
+//		render(w, users)
+//	})
+func ServerTiming() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t := &Timings{metrics: make(map[string]time.Duration)}
+			rw := NewResponse(w)
+			commit := func() {
+				if v := t.header(); v != "" {
+					rw.Header().Set("Server-Timing", v)
+				}
+			}
+			rw.OnBeforeCommit(commit)
+
+			ctx := context.WithValue(r.Context(), timingsKey{}, t)
+			next.ServeHTTP(rw, r.WithContext(ctx))
+
+			// 处理器没有写出响应时，由服务器在返回后提交
+			if !rw.Committed() && !rw.Hijacked() {
+				commit()
+			}
+		})
+	}
+}
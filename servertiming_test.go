@@ -0,0 +1,71 @@
+package h3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestServerTiming(t *testing.T) {
+	mux := NewMux()
+	mux.Use(ServerTiming())
+	mux.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Timing(r).Mark("auth", 1200*time.Microsecond)
+			next.ServeHTTP(w, r)
+		})
+	})
+	mux.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {
+		Timing(r).Mark("db", 20*time.Millisecond)
+		Timing(r).Mark("db", 15070*time.Microsecond)
+		Timing(r).Mark("render", 4500*time.Microsecond)
+		w.Write([]byte("users"))
+		// 提交之后的记录不会出现在响应头中
+		Timing(r).Mark("late", time.Millisecond)
+	})
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		Timing(r).Mark("check", 2*time.Millisecond)
+		w.WriteHeader(http.StatusAccepted)
+	})
+	mux.HandleFunc("GET /empty", func(w http.ResponseWriter, r *http.Request) {
+		Timing(r).Mark("cache", 250*time.Microsecond)
+	})
+	mux.HandleFunc("GET /none", func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		path, want string
+	}{
+		{"/users", "auth;dur=1.2, db;dur=35.07, render;dur=4.5"},
+		{"/status", "auth;dur=1.2, check;dur=2"},
+		{"/empty", "auth;dur=1.2, cache;dur=0.25"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+		if got := rec.Header().Get("Server-Timing"); got != tt.want {
+			t.Errorf("%s: Server-Timing = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestServerTimingWithoutMarks(t *testing.T) {
+	h := ServerTiming()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if _, ok := rec.Header()["Server-Timing"]; ok {
+		t.Errorf("Server-Timing = %q, want no header", rec.Header().Get("Server-Timing"))
+	}
+}
+
+func TestTimingWithoutMiddleware(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	if Timing(r) != nil {
+		t.Fatal("Timing without middleware should be nil")
+	}
+	// nil 记录器上的调用是安全的
+	Timing(r).Mark("db", time.Millisecond)
+	Timing(r).Start("db")()
+}
@@ -1,6 +1,9 @@
 package h3
 
-import "context"
+import (
+	"context"
+	"net"
+)
 
 // Servlet 服务组件接口，表示可以启动和停止的服务
 //
@@ -64,3 +67,134 @@ type Servlet interface {
 	//   - error: 停止失败时返回错误（会被记录但不会阻止关闭流程）
 	Stop() error
 }
+
+// ReadyChecker 可选的就绪检查接口
+//
+// Servlet 实现此接口后，App.ReadinessHandler 会在 Ready 返回 false 时报告未就绪。
+// 适用于 Start 很快返回、但在后台继续预热（加载缓存、建立连接等）的服务组件。
+// Ready 会被并发调用，实现需要保证并发安全。
+//
+// 示例:
+//
+//	type CacheWarmer struct {
+//		ready atomic.Bool
+//	}
+//
+//	func (c *CacheWarmer) Start(ctx context.Context) error {
+//		go func() {
+//			c.warm()
+//			c.ready.Store(true)
+//		}()
+//		return nil
+//	}
+//
+//	func (c *CacheWarmer) Ready() bool { return c.ready.Load() }
+type ReadyChecker interface {
+	Ready() bool
+}
+
+// servletReady 返回 Servlet 是否就绪，未实现 ReadyChecker 的 Servlet 视为就绪
+func servletReady(s Servlet) bool {
+	if sc, ok := s.(*servletComponent); ok {
+		s = sc.Servlet
+	}
+	if rc, ok := s.(ReadyChecker); ok {
+		return rc.Ready()
+	}
+	return true
+}
+
+// AddressAwareServlet 需要知道应用实际监听地址的 Servlet
+//
+// 应用在绑定所有监听地址之后、调用 Servlet 的 Start 之前调用 SetAddr，
+// 参数为 Options.Addr 实际绑定的地址。Addr 使用 ":0" 等动态端口时，
+// 这是获取最终端口的唯一时机，适用于向服务发现注册实例等场景。
+// 每次 App.Start 都会调用一次，并且总是在同一次 Start 的 Start 方法之前。
+//
+// 示例:
+//
+//	type Registrar struct {
+//		addr net.Addr
+//	}
+//
+//	func (r *Registrar) SetAddr(addr net.Addr) { r.addr = addr }
+//
+//	func (r *Registrar) Start(ctx context.Context) error {
+//		return consul.Register(ctx, "api", r.addr.String())
+//	}
+type AddressAwareServlet interface {
+	Servlet
+	SetAddr(addr net.Addr)
+}
+
+// servletSetAddr 在 Servlet 实现了 AddressAwareServlet 时将地址传给它
+func servletSetAddr(s Servlet, addr net.Addr) {
+	if sc, ok := s.(*servletComponent); ok {
+		s = sc.Servlet
+	}
+	if as, ok := s.(AddressAwareServlet); ok {
+		as.SetAddr(addr)
+	}
+}
+
+// ServletFunc 使用函数创建 Servlet
+//
+// 适用于不值得定义具名类型的简单生命周期钩子。
+// start 或 stop 为 nil 时，对应的方法什么也不做并返回 nil。
+//
+// 示例:
+//
+//	worker := h3.ServletFunc(
+//		func(ctx context.Context) error {
+//			go runWorker()
+//			return nil
+//		},
+//		func() error {
+//			stopWorker()
+//			return nil
+//		},
+//	)
+func ServletFunc(start func(ctx context.Context) error, stop func() error) Servlet {
+	return &servletFunc{start: start, stop: stop}
+}
+
+// servletFunc 基于函数的 Servlet 实现
+type servletFunc struct {
+	start func(ctx context.Context) error
+	stop  func() error
+}
+
+// Start 调用 start 函数
+func (s *servletFunc) Start(ctx context.Context) error {
+	if s.start == nil {
+		return nil
+	}
+	return s.start(ctx)
+}
+
+// Stop 调用 stop 函数
+func (s *servletFunc) Stop() error {
+	if s.stop == nil {
+		return nil
+	}
+	return s.stop()
+}
+
+// ServletFromComponent 为应用组件附加 Servlet 生命周期
+//
+// 返回的组件与 c 具有相同的路由和前缀，同时实现了 Servlet 接口，
+// 注册到应用后会在启动和关闭时调用 s 的 Start 和 Stop 方法。
+//
+// 示例:
+//
+//	c := h3.NewComponent("/jobs")
+//	app.Register(h3.ServletFromComponent(c, h3.ServletFunc(startJobs, stopJobs)))
+func ServletFromComponent(c Component, s Servlet) Component {
+	return &servletComponent{Component: c, Servlet: s}
+}
+
+// servletComponent 同时实现 Component 和 Servlet 的组合类型
+type servletComponent struct {
+	Component
+	Servlet
+}
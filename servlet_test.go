@@ -3,6 +3,10 @@ package h3
 import (
 	"context"
 	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -701,3 +705,161 @@ func TestCompositeServletStopWithErrors(t *testing.T) {
 		t.Error("servlet3 should be stopped")
 	}
 }
+
+func TestServletFunc(t *testing.T) {
+	var calls []string
+
+	s := ServletFunc(
+		func(ctx context.Context) error {
+			calls = append(calls, "start")
+			return nil
+		},
+		func() error {
+			calls = append(calls, "stop")
+			return errors.New("stop error")
+		},
+	)
+
+	if err := s.Start(context.Background()); err != nil {
+		t.Errorf("Start failed: %v", err)
+	}
+
+	if err := s.Stop(); err == nil || err.Error() != "stop error" {
+		t.Errorf("Stop error = %v, want %q", err, "stop error")
+	}
+
+	if len(calls) != 2 || calls[0] != "start" || calls[1] != "stop" {
+		t.Errorf("calls = %v, want [start stop]", calls)
+	}
+}
+
+func TestServletFuncNil(t *testing.T) {
+	s := ServletFunc(nil, nil)
+
+	if err := s.Start(context.Background()); err != nil {
+		t.Errorf("Start failed: %v", err)
+	}
+
+	if err := s.Stop(); err != nil {
+		t.Errorf("Stop failed: %v", err)
+	}
+}
+
+func TestServletFromComponent(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	record := func(name string) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, name)
+	}
+
+	newServlet := func(name string) Servlet {
+		return ServletFunc(
+			func(ctx context.Context) error {
+				record(name + ".start")
+				return nil
+			},
+			func() error {
+				record(name + ".stop")
+				return nil
+			},
+		)
+	}
+
+	first := NewComponent("/first")
+	first.Mux().HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("first"))
+	})
+
+	app := New(NewMux(), Options{Addr: ":8110"})
+	app.Register(ServletFromComponent(first, newServlet("first")))
+	app.Register(ServletFromComponent(NewComponent("/second"), newServlet("second")))
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	resp, err := http.Get("http://localhost:8110/first/status")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if string(body) != "first" {
+		t.Errorf("body = %q, want %q", string(body), "first")
+	}
+
+	if err := app.Stop(ctx); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"first.start", "second.start", "second.stop", "first.stop"}
+	if strings.Join(calls, ",") != strings.Join(want, ",") {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
+
+// addrServlet 记录收到的监听地址的 AddressAwareServlet
+type addrServlet struct {
+	addr      net.Addr
+	startAddr net.Addr // Start 被调用时已经收到的地址
+}
+
+func (s *addrServlet) SetAddr(addr net.Addr) { s.addr = addr }
+
+func (s *addrServlet) Start(ctx context.Context) error {
+	s.startAddr = s.addr
+	return nil
+}
+
+func (s *addrServlet) Stop() error { return nil }
+
+func TestAddressAwareServlet(t *testing.T) {
+	direct := &addrServlet{}
+	wrapped := &addrServlet{}
+	grouped := &addrServlet{}
+	sub := &addrServlet{}
+
+	app := New(NewMux(), Options{Addr: "127.0.0.1:0"})
+	app.AddServlet(direct)
+	app.Register(ServletFromComponent(NewComponent("/wrapped"), wrapped))
+	app.RegisterGroup("group", ServletFromComponent(NewComponent("/grouped"), grouped))
+	subApp := New(NewMux())
+	subApp.AddServlet(sub)
+	app.Register(subApp.AsComponent("/sub"))
+
+	if app.Addr() != nil {
+		t.Errorf("Addr before Start = %v, want nil", app.Addr())
+	}
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer func() { _ = app.Stop(ctx) }()
+
+	addr, ok := app.Addr().(*net.TCPAddr)
+	if !ok || addr.Port == 0 {
+		t.Fatalf("Addr = %v, want resolved TCP address", app.Addr())
+	}
+
+	for name, s := range map[string]*addrServlet{"direct": direct, "wrapped": wrapped, "grouped": grouped, "sub": sub} {
+		if s.startAddr == nil || s.startAddr.String() != addr.String() {
+			t.Errorf("%s: address at Start = %v, want %v", name, s.startAddr, addr)
+		}
+	}
+
+	// 收到的地址可以直接访问
+	resp, err := http.Get("http://" + direct.addr.String() + "/")
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,128 @@
+package h3
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Singleflight 创建合并重复并发请求的中间件
+//
+// 键相同的请求同时到达时，只有第一个请求会调用处理器，
+// 其余请求等待并共享它的响应（状态码、响应头和响应体）。
+// 这可以防止昂贵接口在缓存失效时被并发请求击穿。
+//
+// 只合并 GET 和 HEAD 请求，其他方法直接交给处理器。
+// 处理器的响应会先完整缓冲再回放，因此不支持流式响应、Flush 和 Hijack。
+//
+// keyFunc 为 nil 时，带有 Authorization 或 Cookie 头的请求不会被合并，
+// 避免一个用户的个性化响应被交给其他用户。
+// 共享的响应带有 Cache-Control: private 或 Set-Cookie 时，
+// 等待中的请求不使用它，而是各自调用处理器。
+//
+// 共享的处理器调用使用第一个请求的上下文，但不会随它取消：
+// 第一个请求的客户端断开后处理器继续执行，等待中的请求仍然得到完整的响应。
+//
+// 如果处理器 panic，执行它的请求会重新 panic（交给外层的恢复中间件处理），
+// 等待中的请求收到 500 Internal Server Error，不会一直挂起。
+//
+// 参数:
+//   - keyFunc: 计算合并键的函数，为 nil 时使用方法、主机和请求 URI
+//
+// 示例:
+//
+//	mux.Handle("GET /stats", h3.Singleflight(nil)(statsHandler))
+func Singleflight(keyFunc func(*http.Request) string) func(http.Handler) http.Handler {
+	shared := keyFunc == nil
+	if keyFunc == nil {
+		keyFunc = requestKey
+	}
+
+	var group singleflight.Group
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if (r.Method != http.MethodGet && r.Method != http.MethodHead) || (shared && hasCredentials(r)) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			leader := false
+			v, err, _ := group.Do(keyFunc(r), func() (result any, err error) {
+				leader = true
+				defer func() {
+					if p := recover(); p != nil {
+						err = &handlerPanic{value: p}
+					}
+				}()
+
+				cw := &captureWriter{header: make(http.Header)}
+				next.ServeHTTP(cw, r.WithContext(context.WithoutCancel(r.Context())))
+				return cw.response(), nil
+			})
+
+			if err != nil {
+				if p, ok := err.(*handlerPanic); ok && leader {
+					panic(p.value)
+				}
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			resp := v.(*CachedResponse)
+			if !leader && personalised(resp.Header) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			replay(w, resp)
+		})
+	}
+}
+
+// handlerPanic 包装处理器 panic 的值，使其可以作为错误传递
+type handlerPanic struct {
+	value any
+}
+
+func (p *handlerPanic) Error() string {
+	return "h3: handler panic"
+}
+
+// captureWriter 将响应完整缓冲在内存中的 ResponseWriter
+type captureWriter struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (w *captureWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *captureWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(p)
+}
+
+func (w *captureWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+}
+
+// response 返回捕获的响应
+func (w *captureWriter) response() *CachedResponse {
+	status := w.status
+	if !w.wroteHeader {
+		status = http.StatusOK
+	}
+	return &CachedResponse{
+		Status: status,
+		Header: w.header,
+		Body:   w.body.Bytes(),
+	}
+}
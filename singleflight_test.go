@@ -0,0 +1,199 @@
+package h3
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSingleflight(t *testing.T) {
+	var calls atomic.Int32
+	handler := Singleflight(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		time.Sleep(200 * time.Millisecond)
+		w.Header().Set("X-Result", "shared")
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("expensive"))
+	}))
+
+	const n = 10
+	recs := make([]*httptest.ResponseRecorder, n)
+	start := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := range n {
+		recs[i] = httptest.NewRecorder()
+		wg.Go(func() {
+			<-start
+			handler.ServeHTTP(recs[i], httptest.NewRequest("GET", "/stats", nil))
+		})
+	}
+	close(start)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("handler called %d times, want 1", got)
+	}
+
+	for i, rec := range recs {
+		if rec.Code != http.StatusAccepted {
+			t.Errorf("response %d status = %d, want %d", i, rec.Code, http.StatusAccepted)
+		}
+		if rec.Body.String() != "expensive" {
+			t.Errorf("response %d body = %q, want %q", i, rec.Body.String(), "expensive")
+		}
+		if got := rec.Header().Get("X-Result"); got != "shared" {
+			t.Errorf("response %d X-Result = %q, want %q", i, got, "shared")
+		}
+	}
+}
+
+func TestSingleflightNonIdempotent(t *testing.T) {
+	var calls atomic.Int32
+	handler := Singleflight(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+	}))
+
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Go(func() {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/orders", nil))
+		})
+	}
+	wg.Wait()
+
+	if got := calls.Load(); got != 5 {
+		t.Errorf("handler called %d times, want 5", got)
+	}
+}
+
+func TestSingleflightPanic(t *testing.T) {
+	handler := Singleflight(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		panic("boom")
+	}))
+
+	const n = 5
+	var panics atomic.Int32
+	var errors atomic.Int32
+	start := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for range n {
+		wg.Go(func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panics.Add(1)
+				}
+			}()
+			<-start
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+			if rec.Code == http.StatusInternalServerError {
+				errors.Add(1)
+			}
+		})
+	}
+	close(start)
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("waiters hung after handler panic")
+	}
+
+	if got := panics.Load(); got != 1 {
+		t.Errorf("panics = %d, want 1", got)
+	}
+	if got := errors.Load(); got != n-1 {
+		t.Errorf("500 responses = %d, want %d", got, n-1)
+	}
+}
+
+func TestSingleflightPersonalised(t *testing.T) {
+	tests := []struct {
+		name      string
+		cookie    string
+		setCookie bool
+	}{
+		{"request with credentials", "session=alice", false},
+		{"response sets cookie", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			handler := Singleflight(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls.Add(1)
+				time.Sleep(100 * time.Millisecond)
+				if tt.setCookie {
+					w.Header().Set("Set-Cookie", "session=new")
+				}
+				w.Write([]byte("ok"))
+			}))
+
+			const n = 5
+			start := make(chan struct{})
+			var wg sync.WaitGroup
+			for range n {
+				wg.Go(func() {
+					<-start
+					req := httptest.NewRequest("GET", "/me", nil)
+					if tt.cookie != "" {
+						req.Header.Set("Cookie", tt.cookie)
+					}
+					handler.ServeHTTP(httptest.NewRecorder(), req)
+				})
+			}
+			close(start)
+			wg.Wait()
+
+			if got := calls.Load(); got != n {
+				t.Errorf("handler called %d times, want %d", got, n)
+			}
+		})
+	}
+}
+
+func TestSingleflightLeaderCanceled(t *testing.T) {
+	entered := make(chan struct{})
+	handler := Singleflight(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		time.Sleep(100 * time.Millisecond)
+		if err := r.Context().Err(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("expensive"))
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Go(func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/stats", nil).WithContext(ctx))
+	})
+	<-entered
+
+	waiter := httptest.NewRecorder()
+	wg.Go(func() {
+		handler.ServeHTTP(waiter, httptest.NewRequest("GET", "/stats", nil))
+	})
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	wg.Wait()
+
+	if waiter.Code != http.StatusOK || waiter.Body.String() != "expensive" {
+		t.Errorf("waiter = %d %q, want 200 %q", waiter.Code, waiter.Body.String(), "expensive")
+	}
+}
@@ -0,0 +1,62 @@
+package h3
+
+import (
+	"io"
+	"net/http"
+)
+
+// SizeSink 接收请求体和响应体大小的指标接收器
+//
+// 实现通常将大小记录到直方图中，例如 Prometheus 的 HistogramVec，
+// 以 pattern 作为标签。ObserveSizes 会被并发调用，实现需要保证并发安全。
+type SizeSink interface {
+	// ObserveSizes 记录一次请求的请求体和响应体字节数
+	//
+	// pattern 为匹配的路由模式，没有匹配的路由时为空字符串。
+	ObserveSizes(pattern string, requestBytes, responseBytes int64)
+}
+
+// SizeMetrics 创建记录请求体和响应体大小的中间件
+//
+// 响应体大小取自 Response.Size。请求体大小在请求声明了 Content-Length 时取其值，
+// 否则（例如分块传输）统计处理器实际读取的字节数。
+//
+// 路由模式取自路由器设置的 r.Pattern，因此中间件应当通过 Mux.Use 注册，
+// 并位于会克隆请求的中间件（例如 RequestID）之后，否则记录的 pattern 为空。
+//
+// 示例:
+//
+//	mux.Use(h3.SizeMetrics(sink))
+func SizeMetrics(sink SizeSink) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := NewResponse(w)
+
+			var body *countingBody
+			if r.ContentLength < 0 && r.Body != nil {
+				body = &countingBody{ReadCloser: r.Body}
+				r.Body = body
+			}
+
+			next.ServeHTTP(rw, r)
+
+			reqBytes := max(r.ContentLength, 0)
+			if body != nil {
+				reqBytes = body.n
+			}
+			sink.ObserveSizes(r.Pattern, reqBytes, rw.Size())
+		})
+	}
+}
+
+// countingBody 统计已读取字节数的请求体
+type countingBody struct {
+	io.ReadCloser
+	n int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,104 @@
+package h3
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// sizeObservation 一次 ObserveSizes 调用
+type sizeObservation struct {
+	pattern           string
+	request, response int64
+}
+
+// fakeSizeSink 记录所有观测值的 SizeSink
+type fakeSizeSink struct {
+	mu  sync.Mutex
+	obs []sizeObservation
+}
+
+func (s *fakeSizeSink) ObserveSizes(pattern string, requestBytes, responseBytes int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.obs = append(s.obs, sizeObservation{pattern, requestBytes, responseBytes})
+}
+
+func TestSizeMetrics(t *testing.T) {
+	sink := &fakeSizeSink{}
+	mux := NewMux()
+	mux.Use(SizeMetrics(sink))
+	mux.HandleFunc("POST /upload/{name}", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(strings.ToUpper(string(body))))
+		w.Write([]byte("!"))
+	})
+
+	tests := []struct {
+		name    string
+		body    string
+		chunked bool
+		target  string
+		want    sizeObservation
+	}{
+		{"content length", "hello", false, "/upload/a", sizeObservation{"POST /upload/{name}", 5, 6}},
+		{"chunked", "chunked body", true, "/upload/b", sizeObservation{"POST /upload/{name}", 12, 13}},
+		{"not found", "", false, "/missing", sizeObservation{"", 0, 19}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink.obs = nil
+
+			req := httptest.NewRequest("POST", tt.target, strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+				req.TransferEncoding = []string{"chunked"}
+			}
+			mux.ServeHTTP(httptest.NewRecorder(), req)
+
+			if len(sink.obs) != 1 {
+				t.Fatalf("observations = %d, want 1", len(sink.obs))
+			}
+			if sink.obs[0] != tt.want {
+				t.Errorf("observation = %+v, want %+v", sink.obs[0], tt.want)
+			}
+		})
+	}
+}
+
+func TestSizeMetricsChunkedOverHTTP(t *testing.T) {
+	sink := &fakeSizeSink{}
+	mux := NewMux()
+	mux.Use(SizeMetrics(sink))
+	mux.HandleFunc("POST /echo", func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	pr, pw := io.Pipe()
+	go func() {
+		pw.Write([]byte("part1-"))
+		pw.Write([]byte("part2"))
+		pw.Close()
+	}()
+
+	resp, err := http.Post(srv.URL+"/echo", "text/plain", pr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	want := sizeObservation{"POST /echo", 11, 11}
+	if len(sink.obs) != 1 || sink.obs[0] != want {
+		t.Errorf("observations = %+v, want [%+v]", sink.obs, want)
+	}
+}
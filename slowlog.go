@@ -0,0 +1,31 @@
+package h3
+
+import (
+	"net/http"
+	"time"
+)
+
+// SlowLog 创建记录慢请求的中间件
+//
+// 中间件测量处理器的执行时间，只有超过 threshold 时才调用 log，
+// 并传入请求、耗时和最终的响应状态码。未超过阈值的请求只有一次计时开销。
+//
+// 示例:
+//
+//	mux.Use(h3.SlowLog(500*time.Millisecond, func(r *http.Request, d time.Duration, status int) {
+//		slog.Warn("slow request", "method", r.Method, "path", r.URL.Path, "duration", d, "status", status)
+//	}))
+func SlowLog(threshold time.Duration, log func(r *http.Request, d time.Duration, status int)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := NewResponse(w)
+			start := time.Now()
+
+			next.ServeHTTP(rw, r)
+
+			if d := time.Since(start); d > threshold {
+				log(r, d, rw.Status())
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package h3
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSlowLog(t *testing.T) {
+	type entry struct {
+		path   string
+		d      time.Duration
+		status int
+	}
+	var entries []entry
+
+	mux := NewMux()
+	mux.Use(SlowLog(50*time.Millisecond, func(r *http.Request, d time.Duration, status int) {
+		entries = append(entries, entry{r.URL.Path, d, status})
+	}))
+
+	mux.HandleFunc("GET /fast", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("fast"))
+	})
+	mux.HandleFunc("GET /slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fast", nil))
+
+	if len(entries) != 0 {
+		t.Fatalf("callback called for fast request: %+v", entries)
+	}
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow", nil))
+
+	if len(entries) != 1 {
+		t.Fatalf("callback called %d times, want 1", len(entries))
+	}
+
+	e := entries[0]
+	if e.path != "/slow" {
+		t.Errorf("path = %q, want %q", e.path, "/slow")
+	}
+	if e.d < 100*time.Millisecond || e.d > time.Second {
+		t.Errorf("duration = %v, want between 100ms and 1s", e.d)
+	}
+	if e.status != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", e.status, http.StatusServiceUnavailable)
+	}
+}
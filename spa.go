@@ -0,0 +1,76 @@
+package h3
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// spa 单页应用处理器，由 Mux.SPA 注册
+type spa struct {
+	fsys   fs.FS        // 静态文件系统
+	index  string       // 回退文件
+	assets []string     // 不回退的资源子树
+	files  http.Handler // 静态文件处理器
+}
+
+// ServeHTTP 返回存在的文件，资源子树下的缺失文件返回 404，其余路径返回 index 文件
+func (s *spa) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	p := path.Clean("/" + r.URL.Path)
+	name := strings.TrimPrefix(p, "/")
+
+	if name != "" {
+		if info, err := fs.Stat(s.fsys, name); err == nil && !info.IsDir() {
+			s.files.ServeHTTP(w, r)
+			return
+		}
+
+		for _, prefix := range s.assets {
+			if strings.HasPrefix(p+"/", prefix) {
+				http.NotFound(w, r)
+				return
+			}
+		}
+	}
+
+	s.serveIndex(w, r)
+}
+
+// serveIndex 返回 index 文件
+//
+// 不使用 http.FileServer，因为它会把以 "/index.html" 结尾的请求重定向到目录。
+func (s *spa) serveIndex(w http.ResponseWriter, r *http.Request) {
+	f, err := s.fsys.Open(s.index)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		content = bytes.NewReader(data)
+	}
+
+	http.ServeContent(w, r, info.Name(), info.ModTime(), content)
+}
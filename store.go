@@ -0,0 +1,54 @@
+package h3
+
+import "net/http"
+
+// Store 请求级的可变键值存储
+//
+// Mux 在 ServeHTTP 开始时为每个请求创建一个 Store 并放入请求上下文，
+// 挂载的子路由复用外层的 Store。中间件和处理器可以通过 StoreFromRequest 读写，
+// 在不创建新的上下文值的情况下在中间件链中传递数据，例如累积计时标记。
+//
+// Store 基于 map 实现，不是并发安全的：处理器启动的 goroutine
+// 不应与请求的其他部分同时访问同一个 Store。
+type Store struct {
+	values map[string]any
+}
+
+// Get 返回 key 对应的值，不存在时 ok 为 false
+func (s *Store) Get(key string) (value any, ok bool) {
+	value, ok = s.values[key]
+	return
+}
+
+// Set 设置 key 对应的值
+func (s *Store) Set(key string, value any) {
+	if s.values == nil {
+		s.values = make(map[string]any)
+	}
+	s.values[key] = value
+}
+
+// Delete 删除 key 对应的值
+func (s *Store) Delete(key string) {
+	delete(s.values, key)
+}
+
+// storeKey 请求上下文中 Store 的键
+type storeKey struct{}
+
+// StoreFromRequest 返回请求的 Store
+//
+// 请求没有经过 Mux 时返回 nil。
+//
+// 示例:
+//
+//	h3.StoreFromRequest(r).Set("auth.user", user)
+//
+//	// 之后的中间件或处理器
+//	if v, ok := h3.StoreFromRequest(r).Get("auth.user"); ok {
+//		user := v.(*User)
+//	}
+func StoreFromRequest(r *http.Request) *Store {
+	s, _ := r.Context().Value(storeKey{}).(*Store)
+	return s
+}
@@ -0,0 +1,70 @@
+package h3
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	var s Store
+
+	if _, ok := s.Get("missing"); ok {
+		t.Error("Get on an empty store should report missing")
+	}
+
+	s.Set("a", 1)
+	if v, ok := s.Get("a"); !ok || v != 1 {
+		t.Errorf("Get(a) = %v, %v, want 1, true", v, ok)
+	}
+
+	s.Delete("a")
+	if _, ok := s.Get("a"); ok {
+		t.Error("Get after Delete should report missing")
+	}
+}
+
+func TestStoreFromRequest(t *testing.T) {
+	mark := func(name string) func(http.Handler) http.Handler {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				s := StoreFromRequest(r)
+				marks, _ := s.Get("marks")
+				list, _ := marks.([]string)
+				s.Set("marks", append(list, name))
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	inner := NewMux()
+	inner.Use(mark("inner"))
+	inner.HandleFunc("GET /items", func(w http.ResponseWriter, r *http.Request) {
+		marks, _ := StoreFromRequest(r).Get("marks")
+		fmt.Fprint(w, strings.Join(marks.([]string), ","))
+	})
+
+	outer := NewMux()
+	outer.Use(mark("outer"))
+	// 克隆请求的中间件不影响 Store 的共享
+	outer.Use(RequestID())
+	outer.Mount("/api", inner)
+
+	for range 2 {
+		rec := httptest.NewRecorder()
+		outer.ServeHTTP(rec, httptest.NewRequest("GET", "/api/items", nil))
+
+		// 每个请求有独立的 Store，第二次请求不会看到第一次的标记
+		if rec.Body.String() != "outer,inner" {
+			t.Errorf("marks = %q, want %q", rec.Body.String(), "outer,inner")
+		}
+	}
+}
+
+func TestStoreFromRequestWithoutMux(t *testing.T) {
+	if s := StoreFromRequest(httptest.NewRequest("GET", "/", nil)); s != nil {
+		t.Errorf("StoreFromRequest = %v, want nil", s)
+	}
+}
@@ -0,0 +1,72 @@
+package h3
+
+import (
+	"context"
+	"net"
+	"net/http"
+)
+
+// AsComponent 将应用包装为可以注册到另一个应用的组件
+//
+// 返回的组件同时实现了 Servlet 接口。注册到父应用之后:
+//   - 父应用 prefix 下的请求交给本应用的 ServeHTTP 处理，
+//     本应用的中间件以及 RequestTimeout、ErrorResponseWriter 等请求级配置同样生效
+//   - 父应用启动时按添加顺序启动本应用的 Servlet，关闭时逆序停止
+//   - 本应用中实现了 AddressAwareServlet 的 Servlet 收到父应用的监听地址，
+//     本应用的 Addr 同样返回该地址
+//
+// 本应用不会绑定任何监听地址，Addr、TLSConfig 等服务器级配置不生效；
+// 本应用的 LifecycleHook 仍然会收到其 Servlet 的事件。
+// 不应再对本应用调用 Start 或 Stop。
+//
+// 示例:
+//
+//	admin := h3.New(adminMux)
+//	admin.AddServlet(metricsCollector)
+//
+//	app := h3.New(mux, h3.Options{Addr: ":8080"})
+//	app.Register(admin.AsComponent("/admin"))
+func (a *App) AsComponent(prefix string) Component {
+	m := NewMux()
+	m.Handle("/", http.HandlerFunc(a.ServeHTTP))
+	return &appComponent{app: a, mux: m, prefix: prefix}
+}
+
+// appComponent 以组件和 Servlet 的形式嵌入另一个应用的应用
+type appComponent struct {
+	app    *App
+	mux    Mux
+	prefix string
+}
+
+// Mux 返回将所有请求转发给应用的路由器
+func (c *appComponent) Mux() Mux {
+	return c.mux
+}
+
+// Prefix 返回应用挂载的路径前缀
+func (c *appComponent) Prefix() string {
+	return c.prefix
+}
+
+// Name 返回用于生命周期事件的名称
+func (c *appComponent) Name() string {
+	return "app " + c.prefix
+}
+
+// SetAddr 记录父应用的监听地址，在 Start 时传给应用的 Servlet
+func (c *appComponent) SetAddr(addr net.Addr) {
+	c.app.mu.Lock()
+	defer c.app.mu.Unlock()
+	c.app.addr = addr
+}
+
+// Start 启动应用的所有 Servlet
+func (c *appComponent) Start(ctx context.Context) error {
+	return c.app.startServlets(ctx)
+}
+
+// Stop 逆序停止应用的所有 Servlet
+func (c *appComponent) Stop() error {
+	return c.app.stopServlets()
+}
@@ -0,0 +1,89 @@
+package h3
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestAppAsComponent(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, event)
+	}
+	servlet := func(name string) Servlet {
+		return ServletFunc(
+			func(ctx context.Context) error { record(name + " start"); return nil },
+			func() error { record(name + " stop"); return nil },
+		)
+	}
+
+	adminMux := NewMux()
+	adminMux.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Admin", "true")
+			next.ServeHTTP(w, r)
+		})
+	})
+	adminMux.HandleFunc("GET /stats", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("stats:" + r.URL.Path))
+	})
+	admin := New(adminMux)
+	admin.AddServlet(servlet("collector"))
+	admin.AddServlet(servlet("exporter"))
+
+	mux := NewMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("main"))
+	})
+	app := New(mux, Options{Addr: "127.0.0.1:8125"})
+	app.AddServlet(servlet("main"))
+	app.Register(admin.AsComponent("/admin"))
+
+	ctx := context.Background()
+	if err := app.Start(ctx); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	get := func(path string) (string, http.Header) {
+		resp, err := http.Get("http://127.0.0.1:8125" + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body), resp.Header
+	}
+
+	body, header := get("/admin/stats")
+	if body != "stats:/stats" || header.Get("X-Admin") != "true" {
+		t.Errorf("GET /admin/stats = %q (X-Admin %q), want sub-app response", body, header.Get("X-Admin"))
+	}
+	if body, _ := get("/other"); body != "main" {
+		t.Errorf("GET /other = %q, want %q", body, "main")
+	}
+
+	if err := app.Stop(ctx); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+
+	want := []string{"main start", "collector start", "exporter start", "exporter stop", "collector stop", "main stop"}
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Equal(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+
+	// 子应用记录了自己 Servlet 的状态
+	for _, info := range admin.Servlets() {
+		if info.State != StateStopped {
+			t.Errorf("sub-app servlet %q state = %q, want %q", info.Name, info.State, StateStopped)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package h3
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// websocketGUID RFC 6455 中用于计算 Sec-WebSocket-Accept 的固定 GUID
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocketConfig WebSocket 握手的配置
+type WebSocketConfig struct {
+	// Subprotocols 服务器支持的子协议
+	Subprotocols []string
+
+	// RequireSubprotocol 为 true 时，客户端通过 Sec-WebSocket-Protocol 请求了子协议
+	// 但与 Subprotocols 没有交集的握手会被拒绝，返回 400 Bad Request。
+	// 为 false 时握手照常完成，响应中不包含 Sec-WebSocket-Protocol，由客户端决定是否关闭连接。
+	// 客户端没有请求子协议时不受影响。
+	RequireSubprotocol bool
+}
+
+// UpgradeWebSocket 完成 WebSocket 握手并接管底层连接
+//
+// 此函数只负责 RFC 6455 的握手部分：校验 Upgrade、Connection、
+// Sec-WebSocket-Version 和 Sec-WebSocket-Key 请求头，写出带有
+// Sec-WebSocket-Accept 的 101 Switching Protocols 响应，然后返回接管的连接。
+// 帧的编解码需要调用方或第三方库完成。
+//
+// 配置了 Subprotocols 时，按 NegotiateSubprotocol 的规则选择子协议，
+// 并在 101 响应的 Sec-WebSocket-Protocol 中返回。
+//
+// 握手请求无效时，写出 400 Bad Request（版本不支持时为 426 Upgrade Required）
+// 并返回错误。如果 w 是 Response，成功后其 Hijacked 返回 true。
+//
+// 示例:
+//
+//	mux.HandleFunc("GET /ws", func(w http.ResponseWriter, r *http.Request) {
+//		conn, rw, err := h3.UpgradeWebSocket(w, r, h3.WebSocketConfig{
+//			Subprotocols: []string{"graphql-transport-ws"},
+//		})
+//		if err != nil {
+//			return
+//		}
+//		defer conn.Close()
+//		// 使用 rw 读写 WebSocket 帧
+//	})
+func UpgradeWebSocket(w http.ResponseWriter, r *http.Request, config ...WebSocketConfig) (net.Conn, *bufio.ReadWriter, error) {
+	var cfg WebSocketConfig
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	key, err := checkWebSocketHandshake(w, r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	protocol := NegotiateSubprotocol(r, cfg.Subprotocols)
+	if protocol == "" && cfg.RequireSubprotocol && r.Header.Get("Sec-WebSocket-Protocol") != "" {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return nil, nil, errors.New("h3: no supported websocket subprotocol")
+	}
+
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return nil, nil, err
+	}
+
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	rw.WriteString("Upgrade: websocket\r\n")
+	rw.WriteString("Connection: Upgrade\r\n")
+	rw.WriteString("Sec-WebSocket-Accept: " + websocketAccept(key) + "\r\n")
+	if protocol != "" {
+		rw.WriteString("Sec-WebSocket-Protocol: " + protocol + "\r\n")
+	}
+	rw.WriteString("\r\n")
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	return conn, rw, nil
+}
+
+// NegotiateSubprotocol 返回客户端请求的子协议中第一个受支持的子协议
+//
+// 按客户端在 Sec-WebSocket-Protocol 中列出的顺序（即客户端的偏好）匹配，
+// 子协议名称区分大小写。没有共同的子协议时返回空字符串。
+// UpgradeWebSocket 使用相同的规则，处理器可以在升级前后调用它得知选中的子协议。
+func NegotiateSubprotocol(r *http.Request, supported []string) string {
+	for _, v := range r.Header.Values("Sec-WebSocket-Protocol") {
+		for p := range strings.SplitSeq(v, ",") {
+			if p = strings.TrimSpace(p); p != "" && slices.Contains(supported, p) {
+				return p
+			}
+		}
+	}
+	return ""
+}
+
+// checkWebSocketHandshake 校验 WebSocket 握手请求，返回 Sec-WebSocket-Key
+//
+// 校验失败时写出错误响应。
+func checkWebSocketHandshake(w http.ResponseWriter, r *http.Request) (string, error) {
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return "", errors.New("h3: websocket handshake requires GET")
+	}
+
+	if !headerContainsToken(r.Header, "Upgrade", "websocket") {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return "", errors.New("h3: missing or invalid Upgrade header")
+	}
+
+	if !headerContainsToken(r.Header, "Connection", "upgrade") {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return "", errors.New("h3: missing or invalid Connection header")
+	}
+
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, http.StatusText(http.StatusUpgradeRequired), http.StatusUpgradeRequired)
+		return "", errors.New("h3: unsupported websocket version")
+	}
+
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return "", errors.New("h3: missing or invalid Sec-WebSocket-Key header")
+	}
+
+	return key, nil
+}
+
+// websocketAccept 根据 Sec-WebSocket-Key 计算 Sec-WebSocket-Accept
+func websocketAccept(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// headerContainsToken 判断逗号分隔的请求头中是否包含指定的标记（不区分大小写）
+func headerContainsToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for t := range strings.SplitSeq(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+package h3
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUpgradeWebSocket(t *testing.T) {
+	hijacked := make(chan bool, 1)
+
+	mux := NewMux()
+	mux.HandleFunc("GET /ws", func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := UpgradeWebSocket(w, r)
+		if err != nil {
+			t.Errorf("UpgradeWebSocket failed: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		hijacked <- w.(Response).Hijacked()
+
+		// 升级后可以直接使用连接
+		rw.WriteString("hello")
+		rw.Flush()
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	// RFC 6455 第 1.3 节的示例
+	conn.Write([]byte("GET /ws HTTP/1.1\r\n" +
+		"Host: example.com\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: keep-alive, Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n" +
+		"Sec-WebSocket-Version: 13\r\n\r\n"))
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatalf("ReadResponse failed: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusSwitchingProtocols)
+	}
+
+	if got, want := resp.Header.Get("Sec-WebSocket-Accept"), "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="; got != want {
+		t.Errorf("Sec-WebSocket-Accept = %q, want %q", got, want)
+	}
+
+	if got := resp.Header.Get("Upgrade"); got != "websocket" {
+		t.Errorf("Upgrade = %q, want %q", got, "websocket")
+	}
+
+	buf := make([]byte, 5)
+	if _, err := br.Read(buf); err != nil || string(buf) != "hello" {
+		t.Errorf("read after upgrade = %q (%v), want %q", buf, err, "hello")
+	}
+
+	if !<-hijacked {
+		t.Error("Response should report Hijacked after upgrade")
+	}
+}
+
+func TestUpgradeWebSocketInvalid(t *testing.T) {
+	valid := map[string]string{
+		"Upgrade":               "websocket",
+		"Connection":            "Upgrade",
+		"Sec-WebSocket-Key":     "dGhlIHNhbXBsZSBub25jZQ==",
+		"Sec-WebSocket-Version": "13",
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		header string
+		value  string
+		status int
+	}{
+		{"missing key", "GET", "Sec-WebSocket-Key", "", http.StatusBadRequest},
+		{"invalid key", "GET", "Sec-WebSocket-Key", "short", http.StatusBadRequest},
+		{"missing upgrade", "GET", "Upgrade", "", http.StatusBadRequest},
+		{"missing connection", "GET", "Connection", "keep-alive", http.StatusBadRequest},
+		{"wrong version", "GET", "Sec-WebSocket-Version", "8", http.StatusUpgradeRequired},
+		{"wrong method", "POST", "", "", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/ws", nil)
+			for k, v := range valid {
+				req.Header.Set(k, v)
+			}
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+
+			conn, rw, err := UpgradeWebSocket(rec, req)
+
+			if err == nil || conn != nil || rw != nil {
+				t.Errorf("UpgradeWebSocket = (%v, %v, %v), want error", conn, rw, err)
+			}
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+		})
+	}
+}
+
+func TestUpgradeWebSocketSubprotocol(t *testing.T) {
+	tests := []struct {
+		name      string
+		requested string
+		require   bool
+		status    int
+		protocol  string
+	}{
+		{"match", "chat.v2, graphql-ws", false, http.StatusSwitchingProtocols, "graphql-ws"},
+		{"client preference", "graphql-ws, chat.v1", false, http.StatusSwitchingProtocols, "graphql-ws"},
+		{"no match allowed", "mqtt", false, http.StatusSwitchingProtocols, ""},
+		{"no match required", "mqtt", true, http.StatusBadRequest, ""},
+		{"none requested", "", true, http.StatusSwitchingProtocols, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := NewMux()
+			mux.HandleFunc("GET /ws", func(w http.ResponseWriter, r *http.Request) {
+				conn, _, err := UpgradeWebSocket(w, r, WebSocketConfig{
+					Subprotocols:       []string{"chat.v1", "graphql-ws"},
+					RequireSubprotocol: tt.require,
+				})
+				if err == nil {
+					conn.Close()
+				}
+			})
+
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			conn, err := net.Dial("tcp", server.Listener.Addr().String())
+			if err != nil {
+				t.Fatalf("Dial failed: %v", err)
+			}
+			defer conn.Close()
+
+			req := "GET /ws HTTP/1.1\r\n" +
+				"Host: example.com\r\n" +
+				"Upgrade: websocket\r\n" +
+				"Connection: Upgrade\r\n" +
+				"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n" +
+				"Sec-WebSocket-Version: 13\r\n"
+			if tt.requested != "" {
+				req += "Sec-WebSocket-Protocol: " + tt.requested + "\r\n"
+			}
+			conn.Write([]byte(req + "\r\n"))
+
+			resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+			if err != nil {
+				t.Fatalf("ReadResponse failed: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.status)
+			}
+			if got := resp.Header.Get("Sec-WebSocket-Protocol"); got != tt.protocol {
+				t.Errorf("Sec-WebSocket-Protocol = %q, want %q", got, tt.protocol)
+			}
+		})
+	}
+}